@@ -1,21 +1,69 @@
 package api
 
 import (
-	"go-countries-rest-api/api/store"
+	"go-countries-rest-api/api/audit"
+	"go-countries-rest-api/api/auth"
+	"go-countries-rest-api/api/compression"
+	"go-countries-rest-api/api/cors"
+	"go-countries-rest-api/api/idempotency"
+	"go-countries-rest-api/api/ratelimit"
 	"go-countries-rest-api/api/server"
+	"go-countries-rest-api/api/snapshot"
+	"go-countries-rest-api/api/store"
+	"go-countries-rest-api/api/upstream"
+	"go-countries-rest-api/api/webhooks"
 	"net/http"
+	"time"
 )
 
 type App struct {
 	Port string
+
+	// Optional, see LoadEnv
+	Auth        *auth.Authenticator
+	RateLimits  *ratelimit.Config
+	CORS        *cors.Config
+	Compression *compression.Config
+	CacheMaxAge time.Duration
+	Webhooks    webhooks.Config
+
+	// how long Idempotency-Key responses are kept, idempotency.DefaultTTL when zero
+	IdempotencyTTL time.Duration
+
+	// the store tracks the upstream dataset when Sync.Source is set
+	Sync upstream.Config
+
+	// snapshots kept in memory, snapshot.DefaultLimit when zero
+	SnapshotLimit int
 }
 
 func (a *App) Run() {
 	mux := http.NewServeMux()
 	countriesStorage := store.NewCountriesStorage()
+	dispatcher := webhooks.NewDispatcher(a.Webhooks)
+	dispatcher.Start(countriesStorage.Events())
+	defer dispatcher.Stop()
+
 	server := server.Server{
-		Mux:     mux,
-		Actions: countriesStorage,
+		Mux:         mux,
+		Actions:     countriesStorage,
+		Auth:        a.Auth,
+		RateLimits:  a.RateLimits,
+		CORS:        a.CORS,
+		Compression: a.Compression,
+		CacheMaxAge: a.CacheMaxAge,
+		Webhooks:    dispatcher,
+		Audit:       audit.NewLog(),
+		Idempotency: idempotency.NewStore(a.IdempotencyTTL),
+		Snapshots:   snapshot.NewStore(a.SnapshotLimit),
+	}
+
+	if a.Sync.Source != "" {
+		config := a.Sync
+		config.OnApplied = server.AuditSync(config.Source)
+		server.Sync = upstream.NewSyncer(config, countriesStorage)
+		server.Sync.Start()
+		defer server.Sync.Stop()
 	}
 	server.Initialize(a.Port)
 }
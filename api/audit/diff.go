@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"fmt"
+	"go-countries-rest-api/api/models"
+)
+
+/**
+A field that changed, e.g. {"field": "capital", "before": "Athens", "after": "Nafplio"}.
+Currencies are compared by position, like "currencies[0].code". A currency that was
+added or removed is reported as a whole, with a null before or after.
+*/
+type Change struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+/**
+Field level differences between two versions of a country. before is nil for added
+countries and after is nil for deleted ones, then every field is reported.
+*/
+func Diff(before *models.Country, after *models.Country) []Change {
+	var empty models.Country
+	if before == nil {
+		before = &empty
+	}
+	if after == nil {
+		after = &empty
+	}
+
+	changes := []Change{}
+	addChange := func(field string, beforeValue string, afterValue string) {
+		if beforeValue != afterValue {
+			changes = append(changes, Change{Field: field, Before: nullable(beforeValue), After: nullable(afterValue)})
+		}
+	}
+	addChange("name", before.Name, after.Name)
+	addChange("alpha2Code", before.Alpha2Code, after.Alpha2Code)
+	addChange("capital", before.Capital, after.Capital)
+	addChange("region", before.Region, after.Region)
+
+	for i := 0; i < len(before.Currencies) || i < len(after.Currencies); i++ {
+		field := fmt.Sprintf("currencies[%d]", i)
+		switch {
+		case i >= len(before.Currencies):
+			changes = append(changes, Change{Field: field, Before: nil, After: after.Currencies[i]})
+		case i >= len(after.Currencies):
+			changes = append(changes, Change{Field: field, Before: before.Currencies[i], After: nil})
+		default:
+			addChange(field+".code", before.Currencies[i].Code, after.Currencies[i].Code)
+			addChange(field+".name", before.Currencies[i].Name, after.Currencies[i].Name)
+			addChange(field+".symbol", before.Currencies[i].Symbol, after.Currencies[i].Symbol)
+		}
+	}
+	return changes
+}
+
+func nullable(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
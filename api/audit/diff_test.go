@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/models"
+	"testing"
+)
+
+func TestDiffChangedFields(t *testing.T) {
+	before := constructCountryGreece()
+	after := constructCountryGreece()
+	after.Capital = "Nafplio"
+	after.Currencies[0].Symbol = "€"
+
+	changes := Diff(&before, &after)
+	assert.Equal(t, []Change{
+		{Field: "capital", Before: "Athens", After: "Nafplio"},
+		{Field: "currencies[0].symbol", Before: "E", After: "€"},
+	}, changes)
+}
+
+func TestDiffAddedAndRemovedCurrencies(t *testing.T) {
+	before := constructCountryGreece()
+	after := constructCountryGreece()
+	drachma := models.Currency{Code: "GRD", Name: "Drachma", Symbol: "₯"}
+	after.Currencies = append(after.Currencies, drachma)
+
+	added := Diff(&before, &after)
+	removed := Diff(&after, &before)
+	assert.Equal(t, []Change{{Field: "currencies[1]", Before: nil, After: drachma}}, added)
+	assert.Equal(t, []Change{{Field: "currencies[1]", Before: drachma, After: nil}}, removed)
+}
+
+func TestDiffOfNewCountryReportsEveryField(t *testing.T) {
+	greece := constructCountryGreece()
+	changes := Diff(nil, &greece)
+	assert.Equal(t, 5, len(changes))
+	assert.Equal(t, Change{Field: "name", Before: nil, After: "Greece"}, changes[0])
+}
+
+func TestDiffOfSameCountryIsEmpty(t *testing.T) {
+	greece := constructCountryGreece()
+	assert.Equal(t, 0, len(Diff(&greece, &greece)))
+}
+
+func constructCountryGreece() models.Country {
+	return models.Country{
+		Name:       "Greece",
+		Alpha2Code: "GR",
+		Capital:    "Athens",
+		Region:     "Europe",
+		Currencies: []models.Currency{{Code: "EUR", Name: "Euro", Symbol: "E"}},
+	}
+}
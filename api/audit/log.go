@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"go-countries-rest-api/api/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Operation string
+
+const (
+	Add    Operation = "add"
+	Update Operation = "update"
+	Delete Operation = "delete"
+	Purge  Operation = "purge"
+)
+
+type Entry struct {
+	ID        uint64    `json:"id"`
+	Time      time.Time `json:"time"`
+	Actor     string    `json:"actor"`
+	RequestId string    `json:"requestId,omitempty"`
+	Operation Operation `json:"operation"`
+	CountryId string    `json:"countryId"`
+	Changes   []Change  `json:"changes"`
+}
+
+/**
+Selects audit entries. Empty fields match every entry, From and To are inclusive.
+*/
+type Query struct {
+	CountryId string
+	Actor     string
+	From      time.Time
+	To        time.Time
+}
+
+func (q Query) matches(entry Entry) bool {
+	return (q.CountryId == "" || strings.EqualFold(q.CountryId, entry.CountryId)) &&
+		(q.Actor == "" || q.Actor == entry.Actor) &&
+		(q.From.IsZero() || !entry.Time.Before(q.From)) &&
+		(q.To.IsZero() || !entry.Time.After(q.To))
+}
+
+/**
+Append-only, in-memory log of the country mutations. Entries are never updated or removed.
+*/
+type Log struct {
+	sync.Mutex
+	entries []Entry
+
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+func NewLog() *Log {
+	return &Log{}
+}
+
+/**
+Record a mutation of a country. The operation is derived from the versions:
+before is nil for an Add and after is nil for a Delete.
+*/
+func (l *Log) Record(actor string, requestId string, countryId string, before *models.Country, after *models.Country) Entry {
+	operation := Update
+	if before == nil {
+		operation = Add
+	} else if after == nil {
+		operation = Delete
+	}
+	return l.record(actor, requestId, operation, countryId, Diff(before, after))
+}
+
+/**
+Record the permanent deletion of a country of the trash, which was already recorded
+as a Delete when it was moved there
+*/
+func (l *Log) RecordPurge(actor string, requestId string, countryId string, purged *models.Country) Entry {
+	return l.record(actor, requestId, Purge, countryId, Diff(purged, nil))
+}
+
+func (l *Log) record(actor string, requestId string, operation Operation, countryId string, changes []Change) Entry {
+	now := time.Now
+	if l.Now != nil {
+		now = l.Now
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	entry := Entry{
+		ID:        uint64(len(l.entries) + 1),
+		Time:      now().UTC(),
+		Actor:     actor,
+		RequestId: requestId,
+		Operation: operation,
+		CountryId: strings.ToLower(countryId),
+		Changes:   changes,
+	}
+	l.entries = append(l.entries, entry)
+	return entry
+}
+
+/**
+The entries matching the query, oldest first
+*/
+func (l *Log) Query(query Query) []Entry {
+	l.Lock()
+	defer l.Unlock()
+
+	entries := []Entry{}
+	for _, entry := range l.entries {
+		if query.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
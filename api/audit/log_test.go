@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRecordDerivesOperation(t *testing.T) {
+	log := NewLog()
+	greece := constructCountryGreece()
+	moved := constructCountryGreece()
+	moved.Capital = "Nafplio"
+
+	added := log.Record("alice", "r1", "Greece", nil, &greece)
+	updated := log.Record("bob", "r2", "greece", &greece, &moved)
+	deleted := log.Record("alice", "r3", "greece", &moved, nil)
+
+	assert.Equal(t, Add, added.Operation)
+	assert.Equal(t, "greece", added.CountryId)
+	assert.Equal(t, uint64(1), added.ID)
+	assert.Equal(t, Update, updated.Operation)
+	assert.Equal(t, 1, len(updated.Changes))
+	assert.Equal(t, Delete, deleted.Operation)
+	assert.Equal(t, "r3", deleted.RequestId)
+
+	purged := log.RecordPurge("alice", "r4", "Greece", &moved)
+	assert.Equal(t, Purge, purged.Operation)
+	assert.Equal(t, "greece", purged.CountryId)
+	assert.Equal(t, uint64(4), purged.ID)
+}
+
+func TestQueryFiltersByCountryActorAndTime(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	log := NewLog()
+	log.Now = func() time.Time { return now }
+	greece := constructCountryGreece()
+
+	log.Record("alice", "", "greece", nil, &greece)
+	now = now.Add(time.Hour)
+	log.Record("bob", "", "spain", nil, &greece)
+	now = now.Add(time.Hour)
+	log.Record("alice", "", "greece", &greece, nil)
+
+	assert.Equal(t, 3, len(log.Query(Query{})))
+	assert.Equal(t, 2, len(log.Query(Query{CountryId: "Greece"})))
+	assert.Equal(t, 1, len(log.Query(Query{Actor: "bob"})))
+	window := log.Query(Query{From: now.Add(-time.Hour), To: now.Add(-time.Hour)})
+	assert.Equal(t, 1, len(window))
+	assert.Equal(t, "spain", window[0].CountryId)
+	assert.Equal(t, 1, len(log.Query(Query{Actor: "alice", From: now})))
+}
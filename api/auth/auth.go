@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMissingCredentials = errors.New("Missing credentials.")
+	ErrInvalidCredentials = errors.New("Invalid credentials.")
+)
+
+/**
+Roles are ordered, so a role allows everything the roles below it allow.
+An admin can do whatever an editor can do, and an editor whatever a reader can do.
+*/
+type Role int
+
+const (
+	RoleNone Role = iota
+	RoleReader
+	RoleEditor
+	RoleAdmin
+)
+
+func ParseRole(name string) (Role, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "reader":
+		return RoleReader, nil
+	case "editor":
+		return RoleEditor, nil
+	case "admin":
+		return RoleAdmin, nil
+	default:
+		return RoleNone, fmt.Errorf("Unknown role '%s'.", name)
+	}
+}
+
+func (r Role) String() string {
+	switch r {
+	case RoleReader:
+		return "reader"
+	case RoleEditor:
+		return "editor"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+func (r Role) Allows(required Role) bool {
+	return r >= required
+}
+
+/**
+The identity behind a request, resolved either from an API key or from a JWT bearer token
+*/
+type Principal struct {
+	Subject string
+	Role    Role
+
+	// Credential names the verified credential by its kind, "apikey:<hash of the key>" or
+	// "jwt:<subject>". Subjects of API keys and tokens may collide, credentials do not.
+	// Empty for anonymous requests.
+	Credential string
+}
+
+/**
+Authenticator resolves the Principal of a request. Two kinds of credentials are supported
+* static API keys sent as "X-API-Key: <key>" or "Authorization: ApiKey <key>"
+* HMAC signed JWTs sent as "Authorization: Bearer <token>"
+Requests without any credentials get the AnonymousRole (RoleNone by default).
+*/
+type Authenticator struct {
+	APIKeys       map[string]Principal
+	JWTSecret     []byte
+	AnonymousRole Role
+
+	// Now is used to validate token expiration. Defaults to time.Now
+	Now func() time.Time
+}
+
+func (a *Authenticator) Authenticate(request *http.Request) (*Principal, error) {
+	if key := request.Header.Get("X-API-Key"); key != "" {
+		return a.authenticateAPIKey(key)
+	}
+
+	scheme, credentials := splitAuthorization(request.Header.Get("Authorization"))
+	switch strings.ToLower(scheme) {
+	case "":
+		if a.AnonymousRole == RoleNone {
+			return nil, ErrMissingCredentials
+		}
+		return &Principal{Subject: "anonymous", Role: a.AnonymousRole}, nil
+	case "apikey":
+		return a.authenticateAPIKey(credentials)
+	case "bearer":
+		return a.authenticateToken(credentials)
+	default:
+		return nil, ErrInvalidCredentials
+	}
+}
+
+func (a *Authenticator) authenticateAPIKey(key string) (*Principal, error) {
+	principal, ok := a.APIKeys[key]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	sum := sha256.Sum256([]byte(key))
+	principal.Credential = "apikey:" + hex.EncodeToString(sum[:8])
+	return &principal, nil
+}
+
+func (a *Authenticator) authenticateToken(token string) (*Principal, error) {
+	if len(a.JWTSecret) == 0 {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+
+	claims, err := ParseToken(token, a.JWTSecret, now())
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := ParseRole(claims.Role)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return &Principal{Subject: claims.Subject, Role: role, Credential: "jwt:" + claims.Subject}, nil
+}
+
+func splitAuthorization(header string) (string, string) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return "", ""
+	}
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 {
+		return parts[0], ""
+	}
+	return parts[0], strings.TrimSpace(parts[1])
+}
+
+/**
+Parse API keys from a comma separated list like "key1=admin,key2=editor:ci-job".
+The optional part after the colon is the subject recorded for the key, otherwise the role name is used.
+*/
+func ParseAPIKeys(value string) (map[string]Principal, error) {
+	keys := map[string]Principal{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		keyAndRole := strings.SplitN(entry, "=", 2)
+		if len(keyAndRole) != 2 || keyAndRole[0] == "" {
+			return nil, fmt.Errorf("Malformed API key entry '%s'.", entry)
+		}
+
+		roleAndSubject := strings.SplitN(keyAndRole[1], ":", 2)
+		role, err := ParseRole(roleAndSubject[0])
+		if err != nil {
+			return nil, err
+		}
+
+		subject := role.String()
+		if len(roleAndSubject) == 2 && roleAndSubject[1] != "" {
+			subject = roleAndSubject[1]
+		}
+		keys[keyAndRole[0]] = Principal{Subject: subject, Role: role}
+	}
+	return keys, nil
+}
+
+type contextKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+/**
+Returns the Principal stored on the context by the authentication middleware, or nil
+*/
+func PrincipalFrom(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(contextKey{}).(*Principal)
+	return principal
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestParseAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys("k1=admin, k2=reader:dashboard,")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(keys))
+	assert.Equal(t, Principal{Subject: "admin", Role: RoleAdmin}, keys["k1"])
+	assert.Equal(t, Principal{Subject: "dashboard", Role: RoleReader}, keys["k2"])
+}
+
+func TestParseAPIKeysWithUnknownRole(t *testing.T) {
+	keys, err := ParseAPIKeys("k1=owner")
+	assert.Equal(t, "Unknown role 'owner'.", err.Error())
+	assert.Nil(t, keys)
+}
+
+func TestRolesAreOrdered(t *testing.T) {
+	assert.True(t, RoleAdmin.Allows(RoleEditor))
+	assert.True(t, RoleEditor.Allows(RoleReader))
+	assert.True(t, RoleEditor.Allows(RoleEditor))
+	assert.False(t, RoleReader.Allows(RoleEditor))
+	assert.False(t, RoleNone.Allows(RoleReader))
+}
+
+func TestAuthenticateWithAPIKeyHeaders(t *testing.T) {
+	authenticator := &Authenticator{APIKeys: map[string]Principal{"k1": {Subject: "ci", Role: RoleEditor}}}
+
+	xAPIKeyReq, _ := http.NewRequest("GET", "/countries", nil)
+	xAPIKeyReq.Header.Add("X-API-Key", "k1")
+	authorizationReq, _ := http.NewRequest("GET", "/countries", nil)
+	authorizationReq.Header.Add("Authorization", "ApiKey k1")
+
+	fromXAPIKey, xAPIKeyError := authenticator.Authenticate(xAPIKeyReq)
+	fromAuthorization, authorizationError := authenticator.Authenticate(authorizationReq)
+	assert.Nil(t, xAPIKeyError)
+	assert.Nil(t, authorizationError)
+	assert.Equal(t, "ci", fromXAPIKey.Subject)
+	assert.Equal(t, RoleEditor, fromAuthorization.Role)
+	assert.Equal(t, fromXAPIKey.Credential, fromAuthorization.Credential)
+	assert.NotContains(t, fromXAPIKey.Credential, "k1")
+}
+
+func TestAuthenticateBearerTokenWithoutSecret(t *testing.T) {
+	authenticator := &Authenticator{}
+	token, _ := SignToken(Claims{Subject: "alice", Role: "admin"}, []byte(""))
+	req, _ := http.NewRequest("GET", "/countries", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+
+	principal, err := authenticator.Authenticate(req)
+	assert.Equal(t, ErrInvalidCredentials, err)
+	assert.Nil(t, principal)
+}
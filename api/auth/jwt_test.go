@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAndParseToken(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	token, signError := SignToken(Claims{Subject: "alice", Role: "editor", ExpiresAt: now.Add(time.Minute).Unix()}, []byte("secret"))
+	claims, parseError := ParseToken(token, []byte("secret"), now)
+	assert.Nil(t, signError)
+	assert.Nil(t, parseError)
+	assert.Equal(t, "alice", claims.Subject)
+	assert.Equal(t, "editor", claims.Role)
+}
+
+func TestParseTokenWithWrongSecret(t *testing.T) {
+	token, _ := SignToken(Claims{Subject: "alice", Role: "editor"}, []byte("secret"))
+	claims, parseError := ParseToken(token, []byte("other"), time.Now())
+	assert.Equal(t, ErrTokenSignature, parseError)
+	assert.Nil(t, claims)
+}
+
+func TestParseTokenWithTamperedPayload(t *testing.T) {
+	token, _ := SignToken(Claims{Subject: "alice", Role: "reader"}, []byte("secret"))
+	admin, _ := SignToken(Claims{Subject: "alice", Role: "admin"}, []byte("other"))
+	parts := strings.Split(token, ".")
+	adminParts := strings.Split(admin, ".")
+	claims, parseError := ParseToken(parts[0]+"."+adminParts[1]+"."+parts[2], []byte("secret"), time.Now())
+	assert.Equal(t, ErrTokenSignature, parseError)
+	assert.Nil(t, claims)
+}
+
+func TestParseTokenWithNoneAlgorithm(t *testing.T) {
+	// {"alg":"none"} . {"sub":"alice","role":"admin"} . no signature
+	claims, parseError := ParseToken("eyJhbGciOiJub25lIn0.eyJzdWIiOiJhbGljZSIsInJvbGUiOiJhZG1pbiJ9.", []byte("secret"), time.Now())
+	assert.Equal(t, ErrTokenSignature, parseError)
+	assert.Nil(t, claims)
+}
+
+func TestParseExpiredAndNotActiveTokens(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	expired, _ := SignToken(Claims{Subject: "alice", Role: "editor", ExpiresAt: now.Unix()}, []byte("secret"))
+	notActive, _ := SignToken(Claims{Subject: "alice", Role: "editor", NotBefore: now.Add(time.Minute).Unix()}, []byte("secret"))
+
+	_, expiredError := ParseToken(expired, []byte("secret"), now)
+	_, notActiveError := ParseToken(notActive, []byte("secret"), now)
+	assert.Equal(t, ErrTokenExpired, expiredError)
+	assert.Equal(t, ErrTokenNotActive, notActiveError)
+}
+
+func TestParseMalformedToken(t *testing.T) {
+	_, parseError := ParseToken("not-a-token", []byte("secret"), time.Now())
+	assert.Equal(t, ErrMalformedToken, parseError)
+}
@@ -0,0 +1,237 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTimeout    = 10 * time.Second
+	DefaultMaxRetries = 3
+	DefaultBackoff    = 100 * time.Millisecond
+	DefaultMaxBackoff = 5 * time.Second
+)
+
+/**
+Zero values are replaced by the defaults
+*/
+type Config struct {
+	// e.g. "http://localhost:8080", without the trailing slash
+	BaseURL string
+
+	// sent in the X-API-Key header when not empty
+	APIKey string
+
+	// of every attempt, the context of the call bounds all of them
+	Timeout time.Duration
+
+	// attempts after the first one, a negative value disables retries
+	MaxRetries int
+
+	// waited before the first retry, doubled before every other one up to MaxBackoff.
+	// A Retry-After header of the response is honored instead.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// http.DefaultClient when nil. Redirects are never followed.
+	HTTPClient *http.Client
+}
+
+/**
+A client of the countries API implementing store.Actions, so a remote store can
+replace the in-memory one. Safe for concurrent use.
+Requests failing with a network error, a 429, 502, 503 or 504 are retried. Writes are
+idempotent (PUT, DELETE, and POST with an Idempotency-Key) so they are retried too.
+*/
+type Client struct {
+	config     Config
+	httpClient *http.Client
+}
+
+func New(config Config) *Client {
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultMaxRetries
+	}
+	if config.Backoff == 0 {
+		config.Backoff = DefaultBackoff
+	}
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = DefaultMaxBackoff
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	httpClient := http.DefaultClient
+	if config.HTTPClient != nil {
+		httpClient = config.HTTPClient
+	}
+	// a copy, to report the redirects of /countries/random instead of following them
+	noRedirect := *httpClient
+	noRedirect.CheckRedirect = func(request *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &Client{config: config, httpClient: &noRedirect}
+}
+
+/**
+A response read entirely, so the attempt can be cancelled once it is done
+*/
+type response struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+/**
+Send a request, retrying it while it fails with a transient error. Any status code
+below 400 and the ones in accepted are returned, the others are a *StatusError.
+*/
+func (c *Client) do(ctx context.Context, method string, path string, body interface{}, accepted ...int) (*response, error) {
+	var bodyBytes []byte
+	if body != nil {
+		var err error
+		if bodyBytes, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+	header := http.Header{}
+	if method == "POST" {
+		header.Set("Idempotency-Key", newIdempotencyKey())
+	}
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attempt(ctx, method, path, header, bodyBytes)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+			lastErr = err
+			wait = c.backoff(attempt)
+		case resp.statusCode == http.StatusTooManyRequests || resp.statusCode == http.StatusBadGateway ||
+			resp.statusCode == http.StatusServiceUnavailable || resp.statusCode == http.StatusGatewayTimeout:
+			lastErr = c.statusError(method, path, resp)
+			wait = c.retryAfter(resp.header, attempt)
+		case resp.statusCode < 400 || containsStatus(accepted, resp.statusCode):
+			return resp, nil
+		default:
+			return nil, c.statusError(method, path, resp)
+		}
+
+		if attempt >= c.config.MaxRetries {
+			return nil, lastErr
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method string, path string, header http.Header, body []byte) (*response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, c.config.BaseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	request.Header.Set("Accept", "application/json")
+	if c.config.APIKey != "" {
+		request.Header.Set("X-API-Key", c.config.APIKey)
+	}
+
+	resp, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &response{statusCode: resp.StatusCode, header: resp.Header, body: responseBody}, nil
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.config.Backoff
+	for i := 0; i < attempt && wait < c.config.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > c.config.MaxBackoff {
+		wait = c.config.MaxBackoff
+	}
+	return wait
+}
+
+/**
+The Retry-After header in seconds when the server sent one, capped by MaxBackoff
+*/
+func (c *Client) retryAfter(header http.Header, attempt int) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return c.backoff(attempt)
+	}
+	wait := time.Duration(seconds) * time.Second
+	if wait > c.config.MaxBackoff {
+		wait = c.config.MaxBackoff
+	}
+	return wait
+}
+
+func (c *Client) statusError(method string, path string, resp *response) *StatusError {
+	message := strings.TrimSpace(string(resp.body))
+	if strings.HasPrefix(resp.header.Get("Content-Type"), "application/problem+json") {
+		var problem struct {
+			Title  string `json:"title"`
+			Detail string `json:"detail"`
+		}
+		if json.Unmarshal(resp.body, &problem) == nil {
+			message = problem.Title
+			if problem.Detail != "" {
+				message = problem.Detail
+			}
+		}
+	}
+	return &StatusError{Method: method, URL: c.config.BaseURL + path, StatusCode: resp.statusCode, Message: message}
+}
+
+func containsStatus(statuses []int, status int) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func newIdempotencyKey() string {
+	key := make([]byte, 16)
+	rand.Read(key)
+	return hex.EncodeToString(key)
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/auth"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/server"
+	"go-countries-rest-api/api/store"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	greece = models.Country{Name: "Greece", Alpha2Code: "GR", Capital: "Athens", Region: "Europe", Currencies: []models.Currency{{Code: "EUR"}}}
+	japan  = models.Country{Name: "Japan", Alpha2Code: "JP", Capital: "Tokyo", Region: "Asia", Currencies: []models.Currency{{Code: "JPY"}}}
+	spain  = models.Country{Name: "Spain", Alpha2Code: "ES", Capital: "Madrid", Region: "Europe", Currencies: []models.Currency{{Code: "EUR"}}}
+)
+
+func startServer(t *testing.T, authenticator *auth.Authenticator) *httptest.Server {
+	s := &server.Server{Mux: http.NewServeMux(), Actions: store.NewCountriesStorage(), Auth: authenticator}
+	testServer := httptest.NewServer(s.Handler())
+	t.Cleanup(testServer.Close)
+	return testServer
+}
+
+func fastConfig(baseURL string) Config {
+	return Config{BaseURL: baseURL, Backoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+}
+
+func TestClientImplementsActions(t *testing.T) {
+	c := New(fastConfig(startServer(t, nil).URL))
+	ctx := context.Background()
+
+	_, err := c.GetRandomCountryId(ctx)
+	assert.Equal(t, store.ErrNoCountries, err)
+
+	added, err := c.AddCountry(ctx, greece)
+	assert.Nil(t, err)
+	assert.Equal(t, greece, *added)
+
+	country, err := c.GetCountryById(ctx, "greece")
+	assert.Nil(t, err)
+	assert.Equal(t, greece, *country)
+
+	id, err := c.GetRandomCountryId(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "greece", *id)
+
+	countries, err := c.GetAllCountries(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Country{greece}, *countries)
+
+	assert.Nil(t, c.DeleteCountry(ctx, "greece"))
+	assert.Equal(t, store.ErrCountryNotFound, c.DeleteCountry(ctx, "greece"))
+	_, err = c.GetCountryById(ctx, "greece")
+	assert.Equal(t, store.ErrCountryNotFound, err)
+}
+
+func TestListCountriesPages(t *testing.T) {
+	c := New(fastConfig(startServer(t, nil).URL))
+	ctx := context.Background()
+	for _, country := range []models.Country{spain, japan, greece} {
+		c.AddCountry(ctx, country)
+	}
+
+	page, err := c.ListCountries(ctx, ListOptions{Filter: models.CountryFilter{Currency: "EUR"}, Limit: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Country{greece}, page.Countries)
+	assert.Equal(t, 2, page.Total)
+	assert.Equal(t, &ListOptions{Filter: models.CountryFilter{Currency: "EUR"}, Limit: 1, Offset: 1}, page.Next)
+
+	page, err = c.ListCountries(ctx, *page.Next)
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Country{spain}, page.Countries)
+	assert.Nil(t, page.Next)
+
+	all, err := c.ListCountries(ctx, ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Country{greece, japan, spain}, all.Countries)
+	assert.Nil(t, all.Next)
+}
+
+func TestClientMapsStatusCodes(t *testing.T) {
+	authenticator := &auth.Authenticator{APIKeys: map[string]auth.Principal{
+		"reader-key": {Subject: "dashboard", Role: auth.RoleReader},
+		"editor-key": {Subject: "sync-job", Role: auth.RoleEditor},
+	}}
+	baseURL := startServer(t, authenticator).URL
+	ctx := context.Background()
+
+	_, err := New(fastConfig(baseURL)).GetAllCountries(ctx)
+	var statusError *StatusError
+	assert.True(t, errors.As(err, &statusError))
+	assert.Equal(t, http.StatusUnauthorized, statusError.StatusCode)
+	assert.Equal(t, "Missing credentials.", statusError.Message)
+	assert.True(t, errors.Is(err, ErrUnauthorized))
+
+	reader := fastConfig(baseURL)
+	reader.APIKey = "reader-key"
+	_, err = New(reader).AddCountry(ctx, greece)
+	assert.True(t, errors.Is(err, ErrForbidden))
+
+	editor := fastConfig(baseURL)
+	editor.APIKey = "editor-key"
+	_, err = New(editor).AddCountry(ctx, models.Country{Name: "Greece", Alpha2Code: "gr"})
+	assert.True(t, errors.Is(err, ErrBadRequest))
+
+	wrongPath := fastConfig(baseURL + "/v2")
+	wrongPath.APIKey = "reader-key"
+	_, err = New(wrongPath).GetCountryById(ctx, "greece")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.NotEqual(t, store.ErrCountryNotFound, err)
+}
+
+func TestClientRetriesTransientFailures(t *testing.T) {
+	var calls int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			writer.Header().Set("Retry-After", "0")
+			writer.WriteHeader(http.StatusTooManyRequests)
+		default:
+			writer.Write([]byte(`[]`))
+		}
+	}))
+	defer testServer.Close()
+
+	countries, err := New(fastConfig(testServer.URL)).GetAllCountries(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(*countries))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestClientGivesUpAfterMaxRetries(t *testing.T) {
+	var calls int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		writer.WriteHeader(http.StatusBadGateway)
+		writer.Write([]byte("upstream down"))
+	}))
+	defer testServer.Close()
+
+	config := fastConfig(testServer.URL)
+	config.MaxRetries = 2
+	_, err := New(config).GetCountryById(context.Background(), "greece")
+	assert.True(t, errors.Is(err, ErrServer))
+	assert.Contains(t, err.Error(), "upstream down")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	config.MaxRetries = -1
+	New(config).GetCountryById(context.Background(), "greece")
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+
+	var notRetried int32
+	badRequest := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&notRetried, 1)
+		writer.WriteHeader(http.StatusBadRequest)
+	}))
+	defer badRequest.Close()
+	New(fastConfig(badRequest.URL)).GetAllCountries(context.Background())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&notRetried))
+}
+
+func TestClientTimesOutAttempts(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-release:
+			case <-request.Context().Done():
+			}
+			return
+		}
+		writer.Write([]byte(`{"name": "Greece"}`))
+	}))
+	defer testServer.Close()
+	defer close(release)
+
+	config := fastConfig(testServer.URL)
+	config.Timeout = 50 * time.Millisecond
+	country, err := New(config).GetCountryById(context.Background(), "greece")
+	assert.Nil(t, err)
+	assert.Equal(t, "Greece", country.Name)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = New(config).GetCountryById(ctx, "greece")
+	assert.Equal(t, context.Canceled, err)
+}
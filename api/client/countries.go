@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/store"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var _ store.Actions = (*Client)(nil)
+
+/**
+The parameters of GET /countries. Zero values are left out: no filter, every country.
+*/
+type ListOptions struct {
+	Filter models.CountryFilter
+	Limit  int
+	Offset int
+}
+
+/**
+A page of countries. Total is the number of countries matching the filter,
+Next the options of the following page, nil for the last one.
+*/
+type Page struct {
+	Countries []models.Country
+	Total     int
+	Next      *ListOptions
+}
+
+/**
+Add the country, or replace the country with the same name
+*/
+func (c *Client) AddCountry(ctx context.Context, country models.Country) (*models.Country, error) {
+	resp, err := c.do(ctx, "PUT", "/countries/"+url.PathEscape(strings.ToLower(country.Name)), country)
+	if err != nil {
+		return nil, err
+	}
+	var added models.Country
+	if err := json.Unmarshal(resp.body, &added); err != nil {
+		return nil, err
+	}
+	return &added, nil
+}
+
+/**
+Returns store.ErrCountryNotFound when the country is missing, which is also the case
+when a retried attempt finds the country deleted by the previous one
+*/
+func (c *Client) DeleteCountry(ctx context.Context, countryId string) error {
+	_, err := c.do(ctx, "DELETE", "/countries/"+url.PathEscape(countryId), nil)
+	return notFound(err, store.ErrCountryNotFound)
+}
+
+/**
+Returns store.ErrCountryNotFound when the country is missing
+*/
+func (c *Client) GetCountryById(ctx context.Context, countryId string) (*models.Country, error) {
+	resp, err := c.do(ctx, "GET", "/countries/"+url.PathEscape(countryId), nil)
+	if err != nil {
+		return nil, notFound(err, store.ErrCountryNotFound)
+	}
+	var country models.Country
+	if err := json.Unmarshal(resp.body, &country); err != nil {
+		return nil, err
+	}
+	return &country, nil
+}
+
+func (c *Client) GetAllCountries(ctx context.Context) (*[]models.Country, error) {
+	page, err := c.ListCountries(ctx, ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &page.Countries, nil
+}
+
+/**
+Returns store.ErrNoCountries when there are no countries
+*/
+func (c *Client) GetRandomCountryId(ctx context.Context) (*string, error) {
+	resp, err := c.do(ctx, "GET", "/countries/random", nil)
+	if err != nil {
+		return nil, notFound(err, store.ErrNoCountries)
+	}
+	location := resp.header.Get("Location")
+	id := location[strings.LastIndex(location, "/")+1:]
+	if resp.statusCode != http.StatusFound || id == "" {
+		return nil, &StatusError{Method: "GET", URL: c.config.BaseURL + "/countries/random", StatusCode: resp.statusCode, Message: "expected a redirect to a country"}
+	}
+	if unescaped, err := url.PathUnescape(id); err == nil {
+		id = unescaped
+	}
+	return &id, nil
+}
+
+/**
+A page of the countries matching the filter of the options, sorted by id
+*/
+func (c *Client) ListCountries(ctx context.Context, options ListOptions) (*Page, error) {
+	resp, err := c.do(ctx, "GET", "/countries"+options.query(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{Countries: []models.Country{}}
+	if err := json.Unmarshal(resp.body, &page.Countries); err != nil {
+		return nil, err
+	}
+	page.Total = len(page.Countries)
+	if total, err := strconv.Atoi(resp.header.Get("X-Total-Count")); err == nil {
+		page.Total = total
+	}
+	if options.Limit > 0 && options.Offset+len(page.Countries) < page.Total {
+		next := options
+		next.Offset += len(page.Countries)
+		page.Next = &next
+	}
+	return page, nil
+}
+
+func (o ListOptions) query() string {
+	values := url.Values{}
+	for name, value := range map[string]string{
+		"name":       o.Filter.Name,
+		"alpha2Code": o.Filter.Alpha2Code,
+		"capital":    o.Filter.Capital,
+		"region":     o.Filter.Region,
+		"currency":   o.Filter.Currency,
+	} {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+	if o.Limit > 0 {
+		values.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		values.Set("offset", strconv.Itoa(o.Offset))
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return "?" + values.Encode()
+}
+
+/**
+Replace the 404 of a missing resource by the error of the store. The 404 of an unknown
+path, e.g. a wrong BaseURL, is kept.
+*/
+func notFound(err error, storeErr error) error {
+	if statusError, ok := err.(*StatusError); ok && statusError.StatusCode == http.StatusNotFound && statusError.Message != "Path not found" {
+		return storeErr
+	}
+	return err
+}
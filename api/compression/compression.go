@@ -0,0 +1,196 @@
+package compression
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const DefaultMinSize = 1024
+
+type Config struct {
+	// responses smaller than MinSize bytes are sent uncompressed
+	MinSize int
+
+	// gzip/zlib compression level, zero means the default level
+	Level int
+}
+
+/**
+Pick the content coding for a request from its Accept-Encoding header.
+An explicit gzip or deflate entry overrides "*", and a quality of 0 refuses the coding.
+gzip is preferred over deflate when both have the same quality. Returns "" when none is acceptable.
+*/
+func Negotiate(acceptEncoding string) string {
+	qualities := map[string]float64{}
+	for _, entry := range strings.Split(acceptEncoding, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ";")
+		coding := strings.ToLower(strings.TrimSpace(parts[0]))
+		if coding != "gzip" && coding != "deflate" && coding != "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		qualities[coding] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, coding := range []string{"gzip", "deflate"} {
+		quality, ok := qualities[coding]
+		if !ok {
+			quality, ok = qualities["*"]
+		}
+		if ok && quality > bestQuality {
+			best, bestQuality = coding, quality
+		}
+	}
+	return best
+}
+
+/**
+A http.ResponseWriter buffering the start of the response until MinSize bytes are written.
+Bigger responses are compressed with the negotiated encoding, smaller ones are sent as they are.
+Close must be called once the handler returns.
+*/
+type Writer struct {
+	http.ResponseWriter
+	encoding string
+	config   Config
+
+	status  int
+	buffer  []byte
+	decided bool
+	encoder io.WriteCloser
+}
+
+func NewWriter(writer http.ResponseWriter, encoding string, config Config) *Writer {
+	return &Writer{ResponseWriter: writer, encoding: encoding, config: config}
+}
+
+func (w *Writer) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buffer = append(w.buffer, p...)
+	if len(w.buffer) >= w.config.MinSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+/**
+Flushing forces the decision, so streaming responses are not held back by the buffer
+*/
+func (w *Writer) Flush() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+/**
+Hijacking hands the raw connection over (e.g. for WebSockets), nothing is compressed
+*/
+func (w *Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("Hijacking is not supported.")
+	}
+	w.decided = true
+	return hijacker.Hijack()
+}
+
+func (w *Writer) Close() error {
+	if !w.decided {
+		if w.status == 0 {
+			// nothing was written by the handler
+			return nil
+		}
+		if err := w.decide(false); err != nil {
+			return err
+		}
+	}
+	if w.encoder != nil {
+		return w.encoder.Close()
+	}
+	return nil
+}
+
+func (w *Writer) decide(compress bool) error {
+	w.decided = true
+	header := w.ResponseWriter.Header()
+	if header.Get("content-type") == "" && len(w.buffer) > 0 {
+		header.Set("content-type", http.DetectContentType(w.buffer))
+	}
+
+	if compress && header.Get("content-encoding") == "" && bodyAllowed(w.status) {
+		header.Set("content-encoding", w.encoding)
+		header.Del("content-length")
+		switch w.encoding {
+		case "deflate":
+			w.encoder, _ = zlib.NewWriterLevel(w.ResponseWriter, w.level())
+		default:
+			w.encoder, _ = gzip.NewWriterLevel(w.ResponseWriter, w.level())
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buffer) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(w.buffer)
+	} else {
+		_, err = w.ResponseWriter.Write(w.buffer)
+	}
+	w.buffer = nil
+	return err
+}
+
+func (w *Writer) level() int {
+	if w.config.Level == 0 {
+		return gzip.DefaultCompression
+	}
+	return w.config.Level
+}
+
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
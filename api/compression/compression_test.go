@@ -0,0 +1,84 @@
+package compression
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	assert.Equal(t, "gzip", Negotiate("gzip, deflate, br"))
+	assert.Equal(t, "gzip", Negotiate("deflate, gzip"))
+	assert.Equal(t, "deflate", Negotiate("gzip;q=0.5, deflate"))
+	assert.Equal(t, "deflate", Negotiate("gzip;q=0, deflate;q=0.1"))
+	assert.Equal(t, "gzip", Negotiate("*"))
+	assert.Equal(t, "", Negotiate("br, identity"))
+	assert.Equal(t, "", Negotiate(""))
+}
+
+func TestNegotiateHonorsRefusals(t *testing.T) {
+	assert.Equal(t, "", Negotiate("gzip;q=0"))
+	assert.Equal(t, "", Negotiate("*;q=0"))
+	assert.Equal(t, "", Negotiate("gzip;q=0, deflate;q=0"))
+	assert.Equal(t, "deflate", Negotiate("*, gzip;q=0"))
+	assert.Equal(t, "deflate", Negotiate("gzip;q=0, *"))
+	assert.Equal(t, "gzip", Negotiate("*;q=0, gzip"))
+	assert.Equal(t, "deflate", Negotiate("*;q=0.5, deflate"))
+}
+
+func TestWriterCompressesLargeResponsesWithGzip(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := NewWriter(recorder, "gzip", Config{MinSize: 10})
+	writer.Header().Set("content-type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	writer.Write([]byte(strings.Repeat("a", 100)))
+	writer.Close()
+
+	reader, err := gzip.NewReader(recorder.Body)
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(reader)
+	assert.Equal(t, "gzip", recorder.Header().Get("content-encoding"))
+	assert.Equal(t, "application/json", recorder.Header().Get("content-type"))
+	assert.Equal(t, strings.Repeat("a", 100), string(body))
+}
+
+func TestWriterCompressesLargeResponsesWithDeflate(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := NewWriter(recorder, "deflate", Config{MinSize: 10})
+	writer.Write([]byte(strings.Repeat("a", 6)))
+	writer.Write([]byte(strings.Repeat("b", 6)))
+	writer.Close()
+
+	reader, err := zlib.NewReader(recorder.Body)
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(reader)
+	assert.Equal(t, "deflate", recorder.Header().Get("content-encoding"))
+	assert.Equal(t, "aaaaaabbbbbb", string(body))
+}
+
+func TestWriterSendsSmallResponsesUncompressed(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := NewWriter(recorder, "gzip", Config{MinSize: 1024})
+	writer.WriteHeader(http.StatusNotFound)
+	writer.Write([]byte("Country not found"))
+	writer.Close()
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "", recorder.Header().Get("content-encoding"))
+	assert.Equal(t, "Country not found", recorder.Body.String())
+}
+
+func TestWriterFlushSendsBufferedBytes(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := NewWriter(recorder, "gzip", Config{MinSize: 1024})
+	writer.Write([]byte("data: 1\n\n"))
+	writer.Flush()
+
+	assert.True(t, recorder.Flushed)
+	assert.Equal(t, "data: 1\n\n", recorder.Body.String())
+}
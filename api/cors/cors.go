@@ -0,0 +1,96 @@
+package cors
+
+import (
+	"strings"
+	"time"
+)
+
+var (
+	DefaultAllowedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	DefaultAllowedHeaders = []string{"Content-Type", "Authorization", "X-API-Key", "Idempotency-Key"}
+	DefaultExposedHeaders = []string{"Location", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Idempotent-Replayed", "X-Total-Count", "Link"}
+)
+
+/**
+Cross-Origin Resource Sharing settings. "*" can be used in AllowedOrigins and AllowedHeaders
+to allow any origin or header. When credentials are allowed the request origin is echoed
+back instead of "*", as browsers reject the wildcard for credentialed requests.
+*/
+type Config struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+
+	// how long browsers can cache a preflight response, zero lets the browser decide
+	MaxAge time.Duration
+}
+
+/**
+A config allowing the given origins with the default methods and headers
+*/
+func NewConfig(origins ...string) *Config {
+	return &Config{
+		AllowedOrigins: origins,
+		AllowedMethods: DefaultAllowedMethods,
+		AllowedHeaders: DefaultAllowedHeaders,
+		ExposedHeaders: DefaultExposedHeaders,
+	}
+}
+
+func (c *Config) AllowsOrigin(origin string) bool {
+	return containsFold(c.AllowedOrigins, "*") || containsFold(c.AllowedOrigins, origin)
+}
+
+func (c *Config) AllowsMethod(method string) bool {
+	return containsFold(c.AllowedMethods, method)
+}
+
+/**
+requested is the comma separated value of the Access-Control-Request-Headers header
+*/
+func (c *Config) AllowsHeaders(requested string) bool {
+	if containsFold(c.AllowedHeaders, "*") {
+		return true
+	}
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !containsFold(c.AllowedHeaders, header) {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+The value of Access-Control-Allow-Origin for an allowed origin
+*/
+func (c *Config) AllowOriginValue(origin string) string {
+	if containsFold(c.AllowedOrigins, "*") && !c.AllowCredentials {
+		return "*"
+	}
+	return origin
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+Split a comma separated list, ignoring empty entries
+*/
+func ParseList(value string) []string {
+	list := []string{}
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
@@ -0,0 +1,32 @@
+package cors
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAllowsOrigin(t *testing.T) {
+	config := NewConfig("https://app.example.com")
+	wildcard := NewConfig("*")
+	assert.True(t, config.AllowsOrigin("https://app.example.com"))
+	assert.False(t, config.AllowsOrigin("https://evil.example.com"))
+	assert.True(t, wildcard.AllowsOrigin("https://evil.example.com"))
+}
+
+func TestAllowsHeadersIsCaseInsensitive(t *testing.T) {
+	config := NewConfig("*")
+	assert.True(t, config.AllowsHeaders("content-type, x-api-key"))
+	assert.True(t, config.AllowsHeaders(""))
+	assert.False(t, config.AllowsHeaders("content-type, x-custom"))
+}
+
+func TestAllowOriginValueWithCredentials(t *testing.T) {
+	config := NewConfig("*")
+	assert.Equal(t, "*", config.AllowOriginValue("https://app.example.com"))
+	config.AllowCredentials = true
+	assert.Equal(t, "https://app.example.com", config.AllowOriginValue("https://app.example.com"))
+}
+
+func TestParseList(t *testing.T) {
+	assert.Equal(t, []string{"GET", "POST"}, ParseList(" GET, ,POST "))
+}
@@ -0,0 +1,214 @@
+package dataset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"go-countries-rest-api/api/models"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+/**
+The file formats of a list of countries
+* json    an array of countries
+* ndjson  one country per line
+* csv     a header row then one country per row, see Columns
+*/
+type Format string
+
+const (
+	JSON   Format = "json"
+	NDJSON Format = "ndjson"
+	CSV    Format = "csv"
+)
+
+/**
+The columns of the CSV format. Currencies are "code:name:symbol" separated by ";",
+e.g. "EUR:Euro:€;USD:United States dollar:$", name and symbol being optional.
+*/
+var Columns = []string{"name", "alpha2Code", "capital", "region", "currencies"}
+
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(strings.ToLower(name)); format {
+	case JSON, NDJSON, CSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown format '%s', expected json, ndjson or csv", name)
+	}
+}
+
+/**
+The format of a file from its extension, JSON when the extension is unknown
+*/
+func FormatOf(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl":
+		return NDJSON
+	case ".csv":
+		return CSV
+	default:
+		return JSON
+	}
+}
+
+func Read(reader io.Reader, format Format) ([]models.Country, error) {
+	switch format {
+	case JSON:
+		countries := []models.Country{}
+		if err := json.NewDecoder(reader).Decode(&countries); err != nil {
+			return nil, err
+		}
+		return countries, nil
+	case NDJSON:
+		return readNDJSON(reader)
+	case CSV:
+		return readCSV(reader)
+	default:
+		return nil, fmt.Errorf("unknown format '%s'", format)
+	}
+}
+
+func Write(writer io.Writer, format Format, countries []models.Country) error {
+	switch format {
+	case JSON:
+		jsonBytes, err := json.MarshalIndent(countries, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = writer.Write(append(jsonBytes, '\n'))
+		return err
+	case NDJSON:
+		encoder := json.NewEncoder(writer)
+		for _, country := range countries {
+			if err := encoder.Encode(country); err != nil {
+				return err
+			}
+		}
+		return nil
+	case CSV:
+		return writeCSV(writer, countries)
+	default:
+		return fmt.Errorf("unknown format '%s'", format)
+	}
+}
+
+func readNDJSON(reader io.Reader) ([]models.Country, error) {
+	countries := []models.Country{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var country models.Country
+		if err := json.Unmarshal(text, &country); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		countries = append(countries, country)
+	}
+	return countries, scanner.Err()
+}
+
+func readCSV(reader io.Reader) ([]models.Country, error) {
+	csvReader := csv.NewReader(reader)
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return []models.Country{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, column := range header {
+		if !knownColumn(column) {
+			return nil, fmt.Errorf("unknown column '%s', expected %s", column, strings.Join(Columns, ", "))
+		}
+	}
+
+	countries := []models.Country{}
+	for row := 2; ; row++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return countries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var country models.Country
+		for i, value := range record {
+			switch header[i] {
+			case "name":
+				country.Name = value
+			case "alpha2Code":
+				country.Alpha2Code = value
+			case "capital":
+				country.Capital = value
+			case "region":
+				country.Region = value
+			case "currencies":
+				country.Currencies = ParseCurrencies(value)
+			}
+		}
+		countries = append(countries, country)
+	}
+}
+
+func writeCSV(writer io.Writer, countries []models.Country) error {
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Write(Columns)
+	for _, country := range countries {
+		csvWriter.Write([]string{country.Name, country.Alpha2Code, country.Capital, country.Region, formatCurrencies(country.Currencies)})
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func knownColumn(column string) bool {
+	for _, known := range Columns {
+		if column == known {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+Parse currencies written like in the currencies column, e.g. "EUR:Euro:€;USD"
+*/
+func ParseCurrencies(value string) []models.Currency {
+	if value == "" {
+		return nil
+	}
+	currencies := []models.Currency{}
+	for _, entry := range strings.Split(value, ";") {
+		parts := strings.SplitN(entry, ":", 3)
+		currency := models.Currency{Code: parts[0]}
+		if len(parts) > 1 {
+			currency.Name = parts[1]
+		}
+		if len(parts) > 2 {
+			currency.Symbol = parts[2]
+		}
+		currencies = append(currencies, currency)
+	}
+	return currencies
+}
+
+func formatCurrencies(currencies []models.Currency) string {
+	entries := []string{}
+	for _, currency := range currencies {
+		entry := currency.Code
+		if currency.Name != "" || currency.Symbol != "" {
+			entry += ":" + currency.Name
+		}
+		if currency.Symbol != "" {
+			entry += ":" + currency.Symbol
+		}
+		entries = append(entries, entry)
+	}
+	return strings.Join(entries, ";")
+}
@@ -0,0 +1,109 @@
+package dataset
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/audit"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/schema"
+	"go-countries-rest-api/api/store"
+	"strings"
+	"testing"
+)
+
+var countries = []models.Country{
+	{Name: "Greece", Alpha2Code: "GR", Capital: "Athens", Region: "Europe", Currencies: []models.Currency{{Code: "EUR", Name: "Euro", Symbol: "€"}}},
+	{Name: "Panama", Alpha2Code: "PA", Capital: "Panama City", Region: "Americas", Currencies: []models.Currency{{Code: "PAB", Name: "Panamanian balboa"}, {Code: "USD", Name: "United States dollar", Symbol: "$"}}},
+	{Name: "Antarctica", Alpha2Code: "AQ"},
+}
+
+func TestFormatsRoundTrip(t *testing.T) {
+	for _, format := range []Format{JSON, NDJSON, CSV} {
+		var buffer bytes.Buffer
+		assert.Nil(t, Write(&buffer, format, countries))
+		read, err := Read(&buffer, format)
+		assert.Nil(t, err)
+		assert.Equal(t, countries, read, string(format))
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buffer bytes.Buffer
+	Write(&buffer, CSV, countries[:2])
+	assert.Equal(t, "name,alpha2Code,capital,region,currencies\n"+
+		"Greece,GR,Athens,Europe,EUR:Euro:€\n"+
+		"Panama,PA,Panama City,Americas,PAB:Panamanian balboa;USD:United States dollar:$\n", buffer.String())
+}
+
+func TestReadCSVColumnsInAnyOrder(t *testing.T) {
+	read, err := Read(strings.NewReader("alpha2Code,name,currencies\nGR,Greece,EUR\n"), CSV)
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Country{{Name: "Greece", Alpha2Code: "GR", Currencies: []models.Currency{{Code: "EUR"}}}}, read)
+
+	_, err = Read(strings.NewReader("name,population\nGreece,10\n"), CSV)
+	assert.EqualError(t, err, "unknown column 'population', expected name, alpha2Code, capital, region, currencies")
+}
+
+func TestReadNDJSONReportsTheLine(t *testing.T) {
+	read, err := Read(strings.NewReader("{\"name\": \"Greece\"}\n\n{\"name\": \"Spain\"}\n"), NDJSON)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(read))
+
+	_, err = Read(strings.NewReader("{\"name\": \"Greece\"}\n{\"name\": 1}\n"), NDJSON)
+	assert.Contains(t, err.Error(), "line 2: ")
+}
+
+func TestFormatOf(t *testing.T) {
+	assert.Equal(t, CSV, FormatOf("countries.CSV"))
+	assert.Equal(t, NDJSON, FormatOf("dump/countries.ndjson"))
+	assert.Equal(t, NDJSON, FormatOf("countries.jsonl"))
+	assert.Equal(t, JSON, FormatOf("countries.json"))
+	assert.Equal(t, JSON, FormatOf("-"))
+
+	format, err := ParseFormat("NDJSON")
+	assert.Nil(t, err)
+	assert.Equal(t, NDJSON, format)
+	_, err = ParseFormat("xml")
+	assert.EqualError(t, err, "unknown format 'xml', expected json, ndjson or csv")
+}
+
+func TestValidate(t *testing.T) {
+	assert.Equal(t, []schema.Violation{}, Validate(countries[:2]))
+
+	invalid := []models.Country{
+		countries[0],
+		{Name: "", Alpha2Code: "es", Currencies: []models.Currency{{Code: "EUR"}, {Code: "euro"}}},
+		{Name: "GREECE", Alpha2Code: "GR"},
+	}
+	assert.Equal(t, []schema.Violation{
+		{Pointer: "/1/alpha2Code", Message: "must match the pattern ^[A-Z]{2}$"},
+		{Pointer: "/1/currencies/1/code", Message: "must match the pattern ^[A-Z]{3}$"},
+		{Pointer: "/1/name", Message: "must be at least 1 characters long"},
+		{Pointer: "/2/name", Message: "duplicates the name of /0"},
+	}, Validate(invalid))
+}
+
+func TestCompare(t *testing.T) {
+	changedGreece := countries[0]
+	changedGreece.Capital = "Nafplio"
+	spain := models.Country{Name: "Spain", Alpha2Code: "ES"}
+
+	diff := Compare(countries, []models.Country{spain, countries[1], changedGreece})
+	assert.Equal(t, []models.Country{spain}, diff.Added)
+	assert.Equal(t, []models.Country{countries[2]}, diff.Removed)
+	assert.Equal(t, []Changed{{
+		Id:      "greece",
+		Before:  countries[0],
+		After:   changedGreece,
+		Changes: []audit.Change{{Field: "capital", Before: "Athens", After: "Nafplio"}},
+	}}, diff.Changed)
+	assert.Equal(t, Summary{Added: []string{"spain"}, Removed: []string{"antarctica"}, Changed: []string{"greece"}}, diff.Summary())
+	assert.False(t, diff.Empty())
+
+	assert.True(t, Compare(countries, countries).Empty())
+}
+
+func TestSummarizeSkipsUnchangedReplacements(t *testing.T) {
+	greece := countries[0]
+	assert.Equal(t, Summary{Added: []string{}, Removed: []string{}, Changed: []string{}}, Summarize([]store.Replacement{{Id: "greece", Before: &greece, After: &greece}}))
+}
@@ -0,0 +1,130 @@
+package dataset
+
+import (
+	"go-countries-rest-api/api/audit"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/store"
+	"sort"
+	"strings"
+)
+
+/**
+The differences between two lists of countries, matched by id. The countries of
+every list are sorted by id.
+*/
+type Diff struct {
+	Added   []models.Country `json:"added"`
+	Removed []models.Country `json:"removed"`
+	Changed []Changed        `json:"changed"`
+}
+
+/**
+A country of both lists with different fields, see audit.Diff for the changes
+*/
+type Changed struct {
+	Id      string         `json:"id"`
+	Before  models.Country `json:"before"`
+	After   models.Country `json:"after"`
+	Changes []audit.Change `json:"changes"`
+}
+
+func Compare(before []models.Country, after []models.Country) Diff {
+	diff := Diff{Added: []models.Country{}, Removed: []models.Country{}, Changed: []Changed{}}
+	beforeById := byId(before)
+	afterById := byId(after)
+
+	for _, id := range sortedIds(afterById) {
+		afterCountry := afterById[id]
+		beforeCountry, ok := beforeById[id]
+		if !ok {
+			diff.Added = append(diff.Added, afterCountry)
+			continue
+		}
+		if changes := audit.Diff(&beforeCountry, &afterCountry); len(changes) > 0 {
+			diff.Changed = append(diff.Changed, Changed{Id: id, Before: beforeCountry, After: afterCountry, Changes: changes})
+		}
+	}
+	for _, id := range sortedIds(beforeById) {
+		if _, ok := afterById[id]; !ok {
+			diff.Removed = append(diff.Removed, beforeById[id])
+		}
+	}
+	return diff
+}
+
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+/**
+The changes of the diff as store replacements, removed countries first
+*/
+func (d Diff) Replacements() []store.Replacement {
+	replacements := []store.Replacement{}
+	for i := range d.Removed {
+		replacements = append(replacements, store.Replacement{Id: strings.ToLower(d.Removed[i].Name), Before: &d.Removed[i]})
+	}
+	for i := range d.Added {
+		replacements = append(replacements, store.Replacement{Id: strings.ToLower(d.Added[i].Name), After: &d.Added[i]})
+	}
+	for i := range d.Changed {
+		replacements = append(replacements, store.Replacement{Id: d.Changed[i].Id, Before: &d.Changed[i].Before, After: &d.Changed[i].After})
+	}
+	return replacements
+}
+
+/**
+The ids of the countries added, removed and changed, for reports where the countries
+themselves are too much
+*/
+type Summary struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
+
+func (d Diff) Summary() Summary {
+	return Summarize(d.Replacements())
+}
+
+/**
+The summary of replacements, e.g. the ones returned by store.Replaceable. Replacements
+leaving a country as it was are not counted. The ids are sorted.
+*/
+func Summarize(replacements []store.Replacement) Summary {
+	summary := Summary{Added: []string{}, Removed: []string{}, Changed: []string{}}
+	for _, replacement := range replacements {
+		switch {
+		case replacement.Before == nil && replacement.After != nil:
+			summary.Added = append(summary.Added, replacement.Id)
+		case replacement.After == nil && replacement.Before != nil:
+			summary.Removed = append(summary.Removed, replacement.Id)
+		case len(audit.Diff(replacement.Before, replacement.After)) > 0:
+			summary.Changed = append(summary.Changed, replacement.Id)
+		}
+	}
+	sort.Strings(summary.Added)
+	sort.Strings(summary.Removed)
+	sort.Strings(summary.Changed)
+	return summary
+}
+
+/**
+The countries by id, the last one winning when several have the same id
+*/
+func byId(countries []models.Country) map[string]models.Country {
+	countriesById := map[string]models.Country{}
+	for _, country := range countries {
+		countriesById[strings.ToLower(country.Name)] = country
+	}
+	return countriesById
+}
+
+func sortedIds(countriesById map[string]models.Country) []string {
+	ids := []string{}
+	for id := range countriesById {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,44 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/schema"
+	"reflect"
+	"strings"
+)
+
+/**
+Check the countries against the JSON Schema of models.Country, the one validating the
+request bodies of the API, and check that their ids are unique. The pointers of the
+violations start with the index of the country, e.g. "/3/alpha2Code".
+*/
+func Validate(countries []models.Country) []schema.Violation {
+	g := schema.NewGenerator("#/definitions/")
+	countrySchema := g.Schema(reflect.TypeOf(models.Country{}))
+
+	violations := []schema.Violation{}
+	indexes := map[string]int{}
+	for i, country := range countries {
+		pointer := fmt.Sprintf("/%d", i)
+		countryBytes, err := json.Marshal(country)
+		if err != nil {
+			violations = append(violations, schema.Violation{Pointer: pointer, Message: err.Error()})
+			continue
+		}
+		var document map[string]interface{}
+		json.Unmarshal(countryBytes, &document)
+		for _, violation := range schema.Validate(countrySchema, g.Definitions, document) {
+			violations = append(violations, schema.Violation{Pointer: pointer + violation.Pointer, Message: violation.Message})
+		}
+
+		id := strings.ToLower(country.Name)
+		if first, ok := indexes[id]; ok && id != "" {
+			violations = append(violations, schema.Violation{Pointer: pointer + "/name", Message: fmt.Sprintf("duplicates the name of /%d", first)})
+		} else {
+			indexes[id] = i
+		}
+	}
+	return violations
+}
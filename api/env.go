@@ -0,0 +1,167 @@
+package api
+
+import (
+	"go-countries-rest-api/api/auth"
+	"go-countries-rest-api/api/compression"
+	"go-countries-rest-api/api/cors"
+	"go-countries-rest-api/api/dataset"
+	"go-countries-rest-api/api/ratelimit"
+	"os"
+	"strconv"
+	"time"
+)
+
+/**
+Configure the application from environment variables
+* API_KEYS        comma separated "key=role[:subject]" entries, e.g. "s3cret=admin,r34d=reader"
+* JWT_SECRET      secret used to verify HMAC signed bearer tokens
+* ANONYMOUS_ROLE  role granted to requests without credentials, e.g. "reader"
+* RATE_LIMIT      default limit per client, e.g. "100/m"
+* RATE_LIMIT_ROUTES  per route limits, e.g. "GET /countries=5/s,DELETE /countries/=10/m"
+* CORS_ALLOWED_ORIGINS  comma separated origins allowed to call the API from a browser, or "*"
+* CORS_ALLOWED_METHODS  defaults to GET, POST, PUT, PATCH, DELETE, OPTIONS
+* CORS_ALLOWED_HEADERS  defaults to Content-Type, Authorization, X-API-Key, Idempotency-Key
+* CORS_ALLOW_CREDENTIALS  "true" to allow cookies and authorization headers
+* CORS_MAX_AGE          seconds browsers can cache preflight responses
+* COMPRESSION_MIN_SIZE  smallest response in bytes compressed with gzip/deflate, 1024 by default. A negative value disables compression
+* CACHE_MAX_AGE         max-age in seconds of the Cache-Control header on GET responses, "no-cache" when unset
+* WEBHOOK_MAX_ATTEMPTS  delivery attempts before a webhook payload goes to the dead letters, 5 by default
+* IDEMPOTENCY_TTL       seconds the responses of POST requests with an Idempotency-Key are kept, 24 hours by default
+* SYNC_SOURCE           path or http(s) URL of an upstream dataset the store tracks
+* SYNC_FORMAT           json, ndjson or csv, from the Content-Type or the extension of the source by default
+* SYNC_INTERVAL         seconds between two syncs, 1 hour by default
+* SNAPSHOT_LIMIT        snapshots of the store kept in memory, the oldest are dropped first, 20 by default
+Authentication is enabled only when API_KEYS or JWT_SECRET is set,
+rate limiting only when RATE_LIMIT or RATE_LIMIT_ROUTES is set,
+CORS only when CORS_ALLOWED_ORIGINS is set and the sync only when SYNC_SOURCE is set.
+*/
+func (a *App) LoadEnv() error {
+	apiKeys := os.Getenv("API_KEYS")
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if apiKeys != "" || jwtSecret != "" {
+		keys, err := auth.ParseAPIKeys(apiKeys)
+		if err != nil {
+			return err
+		}
+
+		anonymousRole := auth.RoleNone
+		if name := os.Getenv("ANONYMOUS_ROLE"); name != "" {
+			anonymousRole, err = auth.ParseRole(name)
+			if err != nil {
+				return err
+			}
+		}
+
+		a.Auth = &auth.Authenticator{
+			APIKeys:       keys,
+			JWTSecret:     []byte(jwtSecret),
+			AnonymousRole: anonymousRole,
+		}
+	}
+
+	defaultLimit := os.Getenv("RATE_LIMIT")
+	routeLimits := os.Getenv("RATE_LIMIT_ROUTES")
+	if defaultLimit != "" || routeLimits != "" {
+		config := &ratelimit.Config{Limiter: ratelimit.NewMemoryLimiter()}
+		if defaultLimit != "" {
+			limit, err := ratelimit.ParseLimit(defaultLimit)
+			if err != nil {
+				return err
+			}
+			config.Default = limit
+		}
+
+		routes, err := ratelimit.ParseRouteLimits(routeLimits)
+		if err != nil {
+			return err
+		}
+		config.Routes = routes
+		a.RateLimits = config
+	}
+
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		config := cors.NewConfig(cors.ParseList(origins)...)
+		if methods := os.Getenv("CORS_ALLOWED_METHODS"); methods != "" {
+			config.AllowedMethods = cors.ParseList(methods)
+		}
+		if headers := os.Getenv("CORS_ALLOWED_HEADERS"); headers != "" {
+			config.AllowedHeaders = cors.ParseList(headers)
+		}
+		if credentials := os.Getenv("CORS_ALLOW_CREDENTIALS"); credentials != "" {
+			allow, err := strconv.ParseBool(credentials)
+			if err != nil {
+				return err
+			}
+			config.AllowCredentials = allow
+		}
+		if maxAge := os.Getenv("CORS_MAX_AGE"); maxAge != "" {
+			seconds, err := strconv.Atoi(maxAge)
+			if err != nil {
+				return err
+			}
+			config.MaxAge = time.Duration(seconds) * time.Second
+		}
+		a.CORS = config
+	}
+
+	a.Compression = &compression.Config{MinSize: compression.DefaultMinSize}
+	if minSize := os.Getenv("COMPRESSION_MIN_SIZE"); minSize != "" {
+		size, err := strconv.Atoi(minSize)
+		if err != nil {
+			return err
+		}
+		a.Compression.MinSize = size
+		if size < 0 {
+			a.Compression = nil
+		}
+	}
+
+	if maxAge := os.Getenv("CACHE_MAX_AGE"); maxAge != "" {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil {
+			return err
+		}
+		a.CacheMaxAge = time.Duration(seconds) * time.Second
+	}
+
+	if maxAttempts := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); maxAttempts != "" {
+		attempts, err := strconv.Atoi(maxAttempts)
+		if err != nil {
+			return err
+		}
+		a.Webhooks.MaxAttempts = attempts
+	}
+
+	if ttl := os.Getenv("IDEMPOTENCY_TTL"); ttl != "" {
+		seconds, err := strconv.Atoi(ttl)
+		if err != nil {
+			return err
+		}
+		a.IdempotencyTTL = time.Duration(seconds) * time.Second
+	}
+
+	a.Sync.Source = os.Getenv("SYNC_SOURCE")
+	if format := os.Getenv("SYNC_FORMAT"); format != "" {
+		parsed, err := dataset.ParseFormat(format)
+		if err != nil {
+			return err
+		}
+		a.Sync.Format = parsed
+	}
+	if interval := os.Getenv("SYNC_INTERVAL"); interval != "" {
+		seconds, err := strconv.Atoi(interval)
+		if err != nil {
+			return err
+		}
+		a.Sync.Interval = time.Duration(seconds) * time.Second
+	}
+
+	if limit := os.Getenv("SNAPSHOT_LIMIT"); limit != "" {
+		snapshots, err := strconv.Atoi(limit)
+		if err != nil {
+			return err
+		}
+		a.SnapshotLimit = snapshots
+	}
+	return nil
+}
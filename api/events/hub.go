@@ -0,0 +1,147 @@
+package events
+
+import (
+	"go-countries-rest-api/api/models"
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	Created EventType = "created"
+	Updated EventType = "updated"
+	Deleted EventType = "deleted"
+)
+
+const DefaultLogSize = 1000
+
+/**
+A change of a country. Country is the state after the change, or the removed
+country for Deleted events. Previous is the state before an Updated event.
+IDs are increasing, so they can be used to resume a feed.
+*/
+type Event struct {
+	ID        uint64          `json:"id"`
+	Type      EventType       `json:"type"`
+	CountryId string          `json:"countryId"`
+	Country   *models.Country `json:"country,omitempty"`
+	Previous  *models.Country `json:"previous,omitempty"`
+	Time      time.Time       `json:"time"`
+}
+
+/**
+Publish/subscribe hub of country changes. The latest events are kept in a bounded
+log so subscribers can catch up on what they missed while disconnected.
+Publishing never blocks: a subscriber that does not keep up is closed and has to
+resubscribe from the last event it received.
+*/
+type Hub struct {
+	sync.Mutex
+	nextID      uint64
+	log         []Event
+	logSize     int
+	subscribers map[*Subscription]bool
+}
+
+func NewHub(logSize int) *Hub {
+	return &Hub{
+		nextID:      1,
+		logSize:     logSize,
+		subscribers: map[*Subscription]bool{},
+	}
+}
+
+type Subscription struct {
+	hub    *Hub
+	events chan Event
+	closed bool
+}
+
+/**
+Receives the published events. It is closed when the subscription is closed or falls behind.
+*/
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscription) Close() {
+	s.hub.Lock()
+	defer s.hub.Unlock()
+	s.hub.unsubscribe(s)
+}
+
+func (h *Hub) Publish(eventType EventType, countryId string, country *models.Country, previous *models.Country) Event {
+	h.Lock()
+	defer h.Unlock()
+
+	event := Event{ID: h.nextID, Type: eventType, CountryId: countryId, Country: country, Previous: previous, Time: time.Now().UTC()}
+	h.nextID++
+
+	h.log = append(h.log, event)
+	if len(h.log) > h.logSize {
+		h.log = h.log[len(h.log)-h.logSize:]
+	}
+
+	for subscription := range h.subscribers {
+		select {
+		case subscription.events <- event:
+		default:
+			h.unsubscribe(subscription)
+		}
+	}
+	return event
+}
+
+/**
+Subscribe to the events published from now on
+*/
+func (h *Hub) Subscribe(buffer int) *Subscription {
+	h.Lock()
+	defer h.Unlock()
+	return h.subscribe(buffer)
+}
+
+/**
+True when the country before or after the change matches the filter,
+so a subscriber also learns about countries leaving its selection
+*/
+func (e Event) Matches(filter models.CountryFilter) bool {
+	return (e.Country != nil && filter.Matches(*e.Country)) || (e.Previous != nil && filter.Matches(*e.Previous))
+}
+
+/**
+Subscribe and return the logged events published after lastID, atomically, so no event
+is lost or delivered twice between the replay and the live feed.
+The returned bool is false when events after lastID were already evicted from the log.
+*/
+func (h *Hub) SubscribeSince(lastID uint64, buffer int) (*Subscription, []Event, bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	subscription := h.subscribe(buffer)
+	// an ID from the future comes from a previous run of the hub
+	complete := lastID <= h.nextID-1 && (len(h.log) == 0 || h.log[0].ID <= lastID+1)
+	missed := []Event{}
+	for _, event := range h.log {
+		if event.ID > lastID {
+			missed = append(missed, event)
+		}
+	}
+	return subscription, missed, complete
+}
+
+func (h *Hub) subscribe(buffer int) *Subscription {
+	subscription := &Subscription{hub: h, events: make(chan Event, buffer)}
+	h.subscribers[subscription] = true
+	return subscription
+}
+
+func (h *Hub) unsubscribe(subscription *Subscription) {
+	if subscription.closed {
+		return
+	}
+	subscription.closed = true
+	delete(h.subscribers, subscription)
+	close(subscription.events)
+}
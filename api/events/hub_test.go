@@ -0,0 +1,85 @@
+package events
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/models"
+	"testing"
+)
+
+func TestSubscriberReceivesPublishedEvents(t *testing.T) {
+	hub := NewHub(10)
+	subscription := hub.Subscribe(10)
+	defer subscription.Close()
+
+	hub.Publish(Created, "greece", &models.Country{Name: "Greece"}, nil)
+	hub.Publish(Deleted, "greece", &models.Country{Name: "Greece"}, nil)
+
+	first := <-subscription.Events()
+	second := <-subscription.Events()
+	assert.Equal(t, uint64(1), first.ID)
+	assert.Equal(t, Created, first.Type)
+	assert.Equal(t, "greece", first.CountryId)
+	assert.Equal(t, uint64(2), second.ID)
+	assert.Equal(t, Deleted, second.Type)
+}
+
+func TestSubscribeSinceReplaysMissedEvents(t *testing.T) {
+	hub := NewHub(10)
+	hub.Publish(Created, "greece", nil, nil)
+	hub.Publish(Created, "spain", nil, nil)
+	hub.Publish(Deleted, "greece", nil, nil)
+
+	subscription, missed, complete := hub.SubscribeSince(1, 10)
+	defer subscription.Close()
+	assert.True(t, complete)
+	assert.Equal(t, 2, len(missed))
+	assert.Equal(t, "spain", missed[0].CountryId)
+	assert.Equal(t, uint64(3), missed[1].ID)
+}
+
+func TestSubscribeSinceEvictedEventIsIncomplete(t *testing.T) {
+	hub := NewHub(2)
+	hub.Publish(Created, "greece", nil, nil)
+	hub.Publish(Created, "spain", nil, nil)
+	hub.Publish(Created, "italy", nil, nil)
+
+	evicted, missedEvicted, completeEvicted := hub.SubscribeSince(0, 10)
+	upToDate, missedUpToDate, completeUpToDate := hub.SubscribeSince(3, 10)
+	fromFuture, _, completeFromFuture := hub.SubscribeSince(42, 10)
+	defer evicted.Close()
+	defer upToDate.Close()
+	defer fromFuture.Close()
+
+	assert.False(t, completeEvicted)
+	assert.Equal(t, 2, len(missedEvicted))
+	assert.True(t, completeUpToDate)
+	assert.Equal(t, 0, len(missedUpToDate))
+	assert.False(t, completeFromFuture)
+}
+
+func TestSlowSubscriberIsClosed(t *testing.T) {
+	hub := NewHub(10)
+	subscription := hub.Subscribe(1)
+	hub.Publish(Created, "greece", nil, nil)
+	hub.Publish(Created, "spain", nil, nil)
+
+	first, firstOpen := <-subscription.Events()
+	_, secondOpen := <-subscription.Events()
+	assert.True(t, firstOpen)
+	assert.Equal(t, "greece", first.CountryId)
+	assert.False(t, secondOpen)
+
+	// closing twice is harmless
+	subscription.Close()
+}
+
+func TestEventMatchesPreviousOrCurrentCountry(t *testing.T) {
+	euro := []models.Currency{{Code: "EUR"}}
+	drachma := []models.Currency{{Code: "GRD"}}
+	filter := models.CountryFilter{Currency: "eur"}
+	leftEuro := Event{Type: Updated, Country: &models.Country{Currencies: drachma}, Previous: &models.Country{Currencies: euro}}
+	neverEuro := Event{Type: Updated, Country: &models.Country{Currencies: drachma}, Previous: &models.Country{Currencies: drachma}}
+
+	assert.True(t, leftEuro.Matches(filter))
+	assert.False(t, neverEuro.Matches(filter))
+}
@@ -0,0 +1,376 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/**
+Resolves a root field from its arguments. Arguments are decoded like encoding/json does,
+numbers being float64 and input objects map[string]interface{}.
+*/
+type Resolver func(ctx context.Context, arguments map[string]interface{}) (interface{}, error)
+
+/**
+A schema made of the resolvers of the root fields. The values returned by the resolvers
+are Go values: structs are objects whose fields are named after their json tags and
+__typename is the name of their type, slices are lists and the others are scalars.
+Sub fields are read from the values, only the root fields have resolvers.
+*/
+type Schema struct {
+	Query    map[string]Resolver
+	Mutation map[string]Resolver
+}
+
+/**
+The body of a POST request, see https://graphql.org/learn/serving-over-http/
+*/
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+/**
+Data is absent when the request could not be executed at all, e.g. a syntax error,
+and partial when some fields failed
+*/
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+/**
+A GraphQL error. Resolvers can return one to add extensions to the response, e.g. a code.
+*/
+type Error struct {
+	Message    string                 `json:"message"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+/**
+Parse and execute the operation of a request. Query fields are resolved in order,
+like mutation fields which must run serially anyway.
+*/
+func (s *Schema) Execute(ctx context.Context, request Request) Response {
+	doc, err := parse(request.Query)
+	if err != nil {
+		return Response{Errors: []*Error{err.(*Error)}}
+	}
+
+	o, err := doc.operation(request.OperationName)
+	if err != nil {
+		return Response{Errors: []*Error{err.(*Error)}}
+	}
+	variables, err := o.coerceVariables(request.Variables)
+	if err != nil {
+		return Response{Errors: []*Error{err.(*Error)}}
+	}
+
+	e := &executor{document: doc, variables: variables}
+	resolvers, typeName := s.Query, "Query"
+	if o.kind == "mutation" {
+		resolvers, typeName = s.Mutation, "Mutation"
+	}
+
+	data := object{}
+	for _, field := range e.collectFields(o.selections) {
+		path := []interface{}{field.responseKey()}
+		if field.name == "__typename" {
+			data = append(data, objectField{field.responseKey(), typeName})
+			continue
+		}
+		resolver, ok := resolvers[field.name]
+		if !ok {
+			e.fail(field, path, fmt.Sprintf("Cannot query field %q on type %q.", field.name, typeName))
+			data = append(data, objectField{field.responseKey(), nil})
+			continue
+		}
+
+		value, err := resolver(ctx, e.resolve(field.arguments).(map[string]interface{}))
+		if err != nil {
+			e.failWith(field, path, err)
+			data = append(data, objectField{field.responseKey(), nil})
+			continue
+		}
+		data = append(data, objectField{field.responseKey(), e.complete(field, reflect.ValueOf(value), path)})
+	}
+	return Response{Data: data, Errors: e.errors}
+}
+
+func (d *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) != 1 {
+			return nil, &Error{Message: "Must provide operationName if query contains several operations, or one operation."}
+		}
+		return d.operations[0], nil
+	}
+	for _, o := range d.operations {
+		if o.name == name {
+			return o, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("Unknown operation named %q.", name)}
+}
+
+func (o *operation) coerceVariables(values map[string]interface{}) (map[string]interface{}, error) {
+	variables := map[string]interface{}{}
+	for _, definition := range o.variables {
+		value, ok := values[definition.name]
+		if !ok && definition.defaultValue != nil {
+			value, ok = definition.defaultValue, true
+		}
+		if value == nil && definition.nonNull {
+			return nil, &Error{Message: fmt.Sprintf("Variable \"$%s\" of non-null type was not provided.", definition.name), Locations: []Location{o.location}}
+		}
+		if ok {
+			variables[definition.name] = value
+		}
+	}
+	return variables, nil
+}
+
+type executor struct {
+	document  *document
+	variables map[string]interface{}
+	errors    []*Error
+}
+
+func (e *executor) fail(field *selection, path []interface{}, message string) {
+	e.failWith(field, path, &Error{Message: message})
+}
+
+func (e *executor) failWith(field *selection, path []interface{}, err error) {
+	graphqlError, ok := err.(*Error)
+	if !ok {
+		graphqlError = &Error{Message: err.Error()}
+	}
+	failure := *graphqlError
+	failure.Locations = []Location{field.location}
+	failure.Path = append([]interface{}{}, path...)
+	e.errors = append(e.errors, &failure)
+}
+
+/**
+Flatten the fragments of a selection set into its fields, leaving out the ones
+skipped by their directives. Fields with the same response key are merged.
+*/
+func (e *executor) collectFields(selections []*selection) []*selection {
+	fields := []*selection{}
+	byKey := map[string]*selection{}
+	var collect func(selections []*selection, visited map[string]bool)
+	collect = func(selections []*selection, visited map[string]bool) {
+		for _, s := range selections {
+			if !e.included(s) {
+				continue
+			}
+			switch {
+			case s.spread != "":
+				f, ok := e.document.fragments[s.spread]
+				if ok && !visited[s.spread] {
+					visited[s.spread] = true
+					collect(f.selections, visited)
+				}
+			case s.name == "":
+				collect(s.selections, visited)
+			default:
+				if existing, ok := byKey[s.responseKey()]; ok {
+					merged := *existing
+					merged.selections = append(append([]*selection{}, existing.selections...), s.selections...)
+					*existing = merged
+					continue
+				}
+				copied := *s
+				byKey[s.responseKey()] = &copied
+				fields = append(fields, &copied)
+			}
+		}
+	}
+	collect(selections, map[string]bool{})
+	return fields
+}
+
+func (e *executor) included(s *selection) bool {
+	for _, d := range s.directives {
+		condition, _ := e.resolve(d.arguments["if"]).(bool)
+		if (d.name == "skip" && condition) || (d.name == "include" && !condition) {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+Replace the variables of a value literal by their values
+*/
+func (e *executor) resolve(literal interface{}) interface{} {
+	switch literal := literal.(type) {
+	case variable:
+		return e.variables[string(literal)]
+	case enumValue:
+		return string(literal)
+	case []interface{}:
+		list := make([]interface{}, len(literal))
+		for i, item := range literal {
+			list[i] = e.resolve(item)
+		}
+		return list
+	case map[string]interface{}:
+		object := map[string]interface{}{}
+		for name, value := range literal {
+			// a variable which is not provided leaves the argument or the input field out
+			if v, ok := value.(variable); ok {
+				if _, provided := e.variables[string(v)]; !provided {
+					continue
+				}
+			}
+			object[name] = e.resolve(value)
+		}
+		return object
+	default:
+		return literal
+	}
+}
+
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+/**
+Shape a resolved value after the selection set of its field
+*/
+func (e *executor) complete(field *selection, value reflect.Value, path []interface{}) interface{} {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if !value.IsValid() {
+		return nil
+	}
+
+	leaf := value.Type().Implements(marshalerType) || (value.Kind() != reflect.Struct && value.Kind() != reflect.Map &&
+		value.Kind() != reflect.Slice && value.Kind() != reflect.Array) || value.Type() == reflect.TypeOf([]byte{})
+	if leaf {
+		if len(field.selections) > 0 {
+			e.fail(field, path, fmt.Sprintf("Field %q must not have a selection since type %q has no subfields.", field.name, value.Type().Name()))
+			return nil
+		}
+		return value.Interface()
+	}
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, value.Len())
+		for i := range list {
+			list[i] = e.complete(field, value.Index(i), append(append([]interface{}{}, path...), i))
+		}
+		return list
+	case reflect.Map:
+		if len(field.selections) == 0 {
+			return value.Interface()
+		}
+		return e.completeObject(field, path, "", func(name string) (reflect.Value, bool) {
+			v := value.MapIndex(reflect.ValueOf(name))
+			return v, v.IsValid()
+		})
+	default:
+		if len(field.selections) == 0 {
+			e.fail(field, path, fmt.Sprintf("Field %q of type %q must have a selection of subfields.", field.name, value.Type().Name()))
+			return nil
+		}
+		return e.completeObject(field, path, value.Type().Name(), func(name string) (reflect.Value, bool) {
+			return fieldByJsonName(value, name)
+		})
+	}
+}
+
+func (e *executor) completeObject(field *selection, path []interface{}, typeName string, lookup func(string) (reflect.Value, bool)) object {
+	result := object{}
+	for _, subField := range e.collectFields(field.selections) {
+		subPath := append(append([]interface{}{}, path...), subField.responseKey())
+		if subField.name == "__typename" {
+			result = append(result, objectField{subField.responseKey(), typeName})
+			continue
+		}
+		value, ok := lookup(subField.name)
+		if !ok {
+			e.fail(subField, subPath, fmt.Sprintf("Cannot query field %q on type %q.", subField.name, typeName))
+			result = append(result, objectField{subField.responseKey(), nil})
+			continue
+		}
+		result = append(result, objectField{subField.responseKey(), e.complete(subField, value, subPath)})
+	}
+	return result
+}
+
+/**
+The field of a struct serialized by encoding/json with the name, embedded structs included
+*/
+func fieldByJsonName(value reflect.Value, name string) (reflect.Value, bool) {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			if v, ok := fieldByJsonName(value.Field(i), name); ok {
+				return v, true
+			}
+			continue
+		}
+		if field.PkgPath != "" || tag == "-" {
+			continue
+		}
+		fieldName := strings.Split(tag, ",")[0]
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+		if fieldName == name {
+			return value.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+/**
+A JSON object keeping the order of the selection set, as required by the specification
+*/
+type object []objectField
+
+type objectField struct {
+	key   string
+	value interface{}
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+	for i, field := range o {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		key, _ := json.Marshal(field.key)
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
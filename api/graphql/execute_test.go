@@ -0,0 +1,127 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type author struct {
+	Name  string    `json:"name"`
+	Born  time.Time `json:"born"`
+	Books []book    `json:"books"`
+}
+
+type book struct {
+	Title string `json:"title"`
+	Pages int    `json:"pages"`
+}
+
+var tolkien = author{
+	Name:  "Tolkien",
+	Born:  time.Date(1892, 1, 3, 0, 0, 0, 0, time.UTC),
+	Books: []book{{Title: "The Hobbit", Pages: 310}, {Title: "The Silmarillion", Pages: 365}},
+}
+
+func testSchema() *Schema {
+	return &Schema{
+		Query: map[string]Resolver{
+			"author": func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+				if arguments["name"] != "Tolkien" {
+					return nil, nil
+				}
+				return &tolkien, nil
+			},
+			"echo": func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+				return arguments, nil
+			},
+			"broken": func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+				return nil, &Error{Message: "broken on purpose", Extensions: map[string]interface{}{"code": "BROKEN"}}
+			},
+		},
+		Mutation: map[string]Resolver{
+			"fail": func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+				return nil, errors.New("failed")
+			},
+		},
+	}
+}
+
+func execute(request Request) string {
+	response := testSchema().Execute(context.Background(), request)
+	jsonBytes, _ := json.Marshal(response)
+	return string(jsonBytes)
+}
+
+func TestExecuteSelectsFieldsInOrder(t *testing.T) {
+	result := execute(Request{Query: `{ author(name: "Tolkien") { books { pages title } name } }`})
+	assert.Equal(t, `{"data":{"author":{"books":[{"pages":310,"title":"The Hobbit"},{"pages":365,"title":"The Silmarillion"}],"name":"Tolkien"}}}`, result)
+}
+
+func TestExecuteAliasesFragmentsAndTypename(t *testing.T) {
+	query := `
+		query Authors($name: String = "Tolkien") {
+			writer: author(name: $name) { __typename ...names born books { ... on book { title } } }
+			missing: author(name: "Rowling") { name }
+		}
+		fragment names on author { name }`
+	result := execute(Request{Query: query})
+	assert.Equal(t, `{"data":{"writer":{"__typename":"author","name":"Tolkien","born":"1892-01-03T00:00:00Z","books":[{"title":"The Hobbit"},{"title":"The Silmarillion"}]},"missing":null}}`, result)
+}
+
+func TestExecuteResolvesVariablesAndLiterals(t *testing.T) {
+	query := `query Echo($limit: Int!, $missing: String) {
+		echo(limit: $limit, list: [1, "two", true, null], object: {nested: $limit, left: $missing}, order: DESC, float: -1.5e1)
+	}`
+	result := execute(Request{Query: query, Variables: map[string]interface{}{"limit": 10.0}})
+	assert.Equal(t, `{"data":{"echo":{"float":-15,"limit":10,"list":[1,"two",true,null],"object":{"nested":10},"order":"DESC"}}}`, result)
+
+	missing := execute(Request{Query: query})
+	assert.Equal(t, `{"errors":[{"message":"Variable \"$limit\" of non-null type was not provided.","locations":[{"line":1,"column":1}]}]}`, missing)
+}
+
+func TestExecuteDirectives(t *testing.T) {
+	query := `query ($withBooks: Boolean!) { author(name: "Tolkien") { name books @include(if: $withBooks) { title } born @skip(if: true) } }`
+	result := execute(Request{Query: query, Variables: map[string]interface{}{"withBooks": false}})
+	assert.Equal(t, `{"data":{"author":{"name":"Tolkien"}}}`, result)
+}
+
+func TestExecuteReportsFieldErrors(t *testing.T) {
+	result := execute(Request{Query: `{
+  broken
+  author(name: "Tolkien") { name age books }
+  unknown
+}`})
+	assert.Equal(t, `{"data":{"broken":null,"author":{"name":"Tolkien","age":null,"books":[null,null]},"unknown":null},"errors":[`+
+		`{"message":"broken on purpose","locations":[{"line":2,"column":3}],"path":["broken"],"extensions":{"code":"BROKEN"}},`+
+		`{"message":"Cannot query field \"age\" on type \"author\".","locations":[{"line":3,"column":34}],"path":["author","age"]},`+
+		`{"message":"Field \"books\" of type \"book\" must have a selection of subfields.","locations":[{"line":3,"column":38}],"path":["author","books",0]},`+
+		`{"message":"Field \"books\" of type \"book\" must have a selection of subfields.","locations":[{"line":3,"column":38}],"path":["author","books",1]},`+
+		`{"message":"Cannot query field \"unknown\" on type \"Query\".","locations":[{"line":4,"column":3}],"path":["unknown"]}]}`, result)
+}
+
+func TestExecuteMutation(t *testing.T) {
+	result := execute(Request{Query: `mutation { __typename fail }`})
+	assert.Equal(t, `{"data":{"__typename":"Mutation","fail":null},"errors":[{"message":"failed","locations":[{"line":1,"column":23}],"path":["fail"]}]}`, result)
+}
+
+func TestExecuteSelectsOperation(t *testing.T) {
+	query := `query A { author(name: "Tolkien") { name } } mutation B { fail }`
+	assert.Equal(t, `{"data":{"author":{"name":"Tolkien"}}}`, execute(Request{Query: query, OperationName: "A"}))
+	assert.Contains(t, execute(Request{Query: query}), "Must provide operationName")
+	assert.Contains(t, execute(Request{Query: query, OperationName: "C"}), `Unknown operation named \"C\".`)
+}
+
+func TestExecuteReportsSyntaxErrors(t *testing.T) {
+	for query, expected := range map[string]string{
+		`{ author(name: "Tolkien") { name }`: `{"errors":[{"message":"Syntax Error: unexpected \u003cEOF\u003e","locations":[{"line":1,"column":35}]}]}`,
+		`{ author(name: "Tolk`:               `{"errors":[{"message":"Syntax Error: unterminated string","locations":[{"line":1,"column":16}]}]}`,
+		"{\n  author(name: ?) }":             `{"errors":[{"message":"Syntax Error: unexpected character '?'","locations":[{"line":2,"column":16}]}]}`,
+		`subscription { author }`:            `{"errors":[{"message":"Syntax Error: unexpected \"subscription\"","locations":[{"line":1,"column":1}]}]}`,
+	} {
+		assert.Equal(t, expected, execute(Request{Query: query}), query)
+	}
+}
@@ -0,0 +1,162 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind     tokenKind
+	value    string
+	location Location
+}
+
+/**
+Splits a GraphQL document into tokens. Commas are insignificant, comments run from # to
+the end of the line. Block strings are not supported.
+*/
+type lexer struct {
+	source string
+	pos    int
+	line   int
+	column int
+}
+
+func newLexer(source string) *lexer {
+	return &lexer{source: source, line: 1, column: 1}
+}
+
+func (l *lexer) advance(n int) {
+	for i := 0; i < n; i++ {
+		if l.source[l.pos] == '\n' {
+			l.line++
+			l.column = 1
+		} else {
+			l.column++
+		}
+		l.pos++
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	location := Location{Line: l.line, Column: l.column}
+	if l.pos >= len(l.source) {
+		return token{kind: tokenEOF, location: location}, nil
+	}
+
+	c := l.source[l.pos]
+	switch {
+	case strings.HasPrefix(l.source[l.pos:], "..."):
+		l.advance(3)
+		return token{kind: tokenPunctuator, value: "...", location: location}, nil
+	case strings.IndexByte("!$():=@[]{}|", c) >= 0:
+		l.advance(1)
+		return token{kind: tokenPunctuator, value: string(c), location: location}, nil
+	case c == '_' || isLetter(c):
+		start := l.pos
+		for l.pos < len(l.source) && (l.source[l.pos] == '_' || isLetter(l.source[l.pos]) || isDigit(l.source[l.pos])) {
+			l.advance(1)
+		}
+		return token{kind: tokenName, value: l.source[start:l.pos], location: location}, nil
+	case c == '-' || isDigit(c):
+		return l.number(location)
+	case c == '"':
+		return l.string(location)
+	default:
+		return token{}, &Error{Message: fmt.Sprintf("Syntax Error: unexpected character %q", c), Locations: []Location{location}}
+	}
+}
+
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.source) {
+		switch c := l.source[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.advance(1)
+		case c == '#':
+			for l.pos < len(l.source) && l.source[l.pos] != '\n' {
+				l.advance(1)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) number(location Location) (token, error) {
+	start := l.pos
+	kind := tokenInt
+	if l.source[l.pos] == '-' {
+		l.advance(1)
+	}
+	digits := l.digits()
+	if l.pos < len(l.source) && l.source[l.pos] == '.' {
+		kind = tokenFloat
+		l.advance(1)
+		digits = l.digits() && digits
+	}
+	if l.pos < len(l.source) && (l.source[l.pos] == 'e' || l.source[l.pos] == 'E') {
+		kind = tokenFloat
+		l.advance(1)
+		if l.pos < len(l.source) && (l.source[l.pos] == '+' || l.source[l.pos] == '-') {
+			l.advance(1)
+		}
+		digits = l.digits() && digits
+	}
+	if !digits {
+		return token{}, &Error{Message: fmt.Sprintf("Syntax Error: invalid number %q", l.source[start:l.pos]), Locations: []Location{location}}
+	}
+	return token{kind: kind, value: l.source[start:l.pos], location: location}, nil
+}
+
+func (l *lexer) digits() bool {
+	start := l.pos
+	for l.pos < len(l.source) && isDigit(l.source[l.pos]) {
+		l.advance(1)
+	}
+	return l.pos > start
+}
+
+/**
+GraphQL strings escape like JSON strings, so they are decoded by encoding/json
+*/
+func (l *lexer) string(location Location) (token, error) {
+	start := l.pos
+	l.advance(1)
+	for l.pos < len(l.source) && l.source[l.pos] != '"' && l.source[l.pos] != '\n' {
+		if l.source[l.pos] == '\\' && l.pos+1 < len(l.source) {
+			l.advance(1)
+		}
+		l.advance(1)
+	}
+	if l.pos >= len(l.source) || l.source[l.pos] != '"' {
+		return token{}, &Error{Message: "Syntax Error: unterminated string", Locations: []Location{location}}
+	}
+	l.advance(1)
+
+	var value string
+	if err := json.Unmarshal([]byte(l.source[start:l.pos]), &value); err != nil {
+		return token{}, &Error{Message: "Syntax Error: invalid string " + l.source[start:l.pos], Locations: []Location{location}}
+	}
+	return token{kind: tokenString, value: value, location: location}, nil
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,446 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+)
+
+/**
+The executable subset of the GraphQL language: operations with variables, fields with
+aliases and arguments, fragments, inline fragments and the @include and @skip directives.
+Type conditions are parsed but not checked, the schema has no abstract types.
+*/
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string
+	name       string
+	variables  []variableDefinition
+	selections []*selection
+	location   Location
+}
+
+type variableDefinition struct {
+	name         string
+	nonNull      bool
+	defaultValue interface{}
+}
+
+type fragment struct {
+	name       string
+	selections []*selection
+}
+
+/**
+A field, a fragment spread when spread is set, or an inline fragment when name is empty
+*/
+type selection struct {
+	alias      string
+	name       string
+	arguments  map[string]interface{}
+	directives []directive
+	selections []*selection
+	spread     string
+	location   Location
+}
+
+type directive struct {
+	name      string
+	arguments map[string]interface{}
+}
+
+/**
+A $name argument value, resolved from the variables of the request
+*/
+type variable string
+
+/**
+An enum argument value, resolvers receive its name as a string
+*/
+type enumValue string
+
+func (s *selection) responseKey() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+type parser struct {
+	lexer *lexer
+	token token
+}
+
+func parse(source string) (*document, error) {
+	p := &parser{lexer: newLexer(source)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{fragments: map[string]*fragment{}}
+	for p.token.kind != tokenEOF {
+		switch {
+		case p.peek("{"):
+			o := &operation{kind: "query", location: p.token.location}
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			o.selections = selections
+			doc.operations = append(doc.operations, o)
+		case p.token.kind == tokenName && p.token.value == "fragment":
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			doc.fragments[f.name] = f
+		case p.token.kind == tokenName && (p.token.value == "query" || p.token.value == "mutation"):
+			o, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, o)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	return doc, nil
+}
+
+func (p *parser) advance() error {
+	t, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.token = t
+	return nil
+}
+
+func (p *parser) peek(punctuator string) bool {
+	return p.token.kind == tokenPunctuator && p.token.value == punctuator
+}
+
+func (p *parser) unexpected() error {
+	description := fmt.Sprintf("%q", p.token.value)
+	if p.token.kind == tokenEOF {
+		description = "<EOF>"
+	}
+	return &Error{Message: "Syntax Error: unexpected " + description, Locations: []Location{p.token.location}}
+}
+
+func (p *parser) expect(punctuator string) error {
+	if !p.peek(punctuator) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.token.kind != tokenName {
+		return "", p.unexpected()
+	}
+	name := p.token.value
+	return name, p.advance()
+}
+
+func (p *parser) operation() (*operation, error) {
+	o := &operation{kind: p.token.value, location: p.token.location}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.token.kind == tokenName {
+		o.name = p.token.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek("(") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !p.peek(")") {
+			definition, err := p.variableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			o.variables = append(o.variables, definition)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	o.selections = selections
+	return o, nil
+}
+
+func (p *parser) variableDefinition() (variableDefinition, error) {
+	definition := variableDefinition{}
+	if err := p.expect("$"); err != nil {
+		return definition, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return definition, err
+	}
+	definition.name = name
+	if err := p.expect(":"); err != nil {
+		return definition, err
+	}
+	if definition.nonNull, err = p.typeReference(); err != nil {
+		return definition, err
+	}
+
+	if p.peek("=") {
+		if err := p.advance(); err != nil {
+			return definition, err
+		}
+		if definition.defaultValue, err = p.value(true); err != nil {
+			return definition, err
+		}
+	}
+	return definition, nil
+}
+
+/**
+Skip a type like [String!]!, returns whether the outer type is non null
+*/
+func (p *parser) typeReference() (bool, error) {
+	if p.peek("[") {
+		if err := p.advance(); err != nil {
+			return false, err
+		}
+		if _, err := p.typeReference(); err != nil {
+			return false, err
+		}
+		if err := p.expect("]"); err != nil {
+			return false, err
+		}
+	} else if _, err := p.name(); err != nil {
+		return false, err
+	}
+
+	if p.peek("!") {
+		return true, p.advance()
+	}
+	return false, nil
+}
+
+func (p *parser) fragment() (*fragment, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if p.token.kind != tokenName || p.token.value != "on" {
+		return nil, p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if _, err := p.name(); err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragment{name: name, selections: selections}, nil
+}
+
+func (p *parser) selectionSet() ([]*selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	selections := []*selection{}
+	for !p.peek("}") {
+		s, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, s)
+	}
+	return selections, p.advance()
+}
+
+func (p *parser) selection() (*selection, error) {
+	s := &selection{location: p.token.location}
+	var err error
+	if p.peek("...") {
+		if err = p.advance(); err != nil {
+			return nil, err
+		}
+		if p.token.kind == tokenName && p.token.value != "on" {
+			s.spread = p.token.value
+			if err = p.advance(); err != nil {
+				return nil, err
+			}
+			s.directives, err = p.directives()
+			return s, err
+		}
+		if p.token.kind == tokenName {
+			if err = p.advance(); err != nil {
+				return nil, err
+			}
+			if _, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+		if s.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		s.selections, err = p.selectionSet()
+		return s, err
+	}
+
+	if s.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if p.peek(":") {
+		if err = p.advance(); err != nil {
+			return nil, err
+		}
+		s.alias = s.name
+		if s.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if s.arguments, err = p.arguments(); err != nil {
+		return nil, err
+	}
+	if s.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek("{") {
+		s.selections, err = p.selectionSet()
+	}
+	return s, err
+}
+
+func (p *parser) arguments() (map[string]interface{}, error) {
+	arguments := map[string]interface{}{}
+	if !p.peek("(") {
+		return arguments, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	for !p.peek(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if arguments[name], err = p.value(false); err != nil {
+			return nil, err
+		}
+	}
+	return arguments, p.advance()
+}
+
+func (p *parser) directives() ([]directive, error) {
+	directives := []directive{}
+	for p.peek("@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		arguments, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, directive{name: name, arguments: arguments})
+	}
+	return directives, nil
+}
+
+/**
+Parse a value literal. Numbers are float64 like the variables decoded by encoding/json,
+constant values (the defaults of the variables) can not contain variables.
+*/
+func (p *parser) value(constant bool) (interface{}, error) {
+	t := p.token
+	switch {
+	case t.kind == tokenPunctuator && t.value == "$" && !constant:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		return variable(name), err
+	case t.kind == tokenInt || t.kind == tokenFloat:
+		number, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, &Error{Message: "Syntax Error: invalid number " + t.value, Locations: []Location{t.location}}
+		}
+		return number, p.advance()
+	case t.kind == tokenString:
+		return t.value, p.advance()
+	case t.kind == tokenName:
+		var value interface{}
+		switch t.value {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		default:
+			value = enumValue(t.value)
+		}
+		return value, p.advance()
+	case p.peek("["):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := []interface{}{}
+		for !p.peek("]") {
+			item, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, p.advance()
+	case p.peek("{"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		object := map[string]interface{}{}
+		for !p.peek("}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.value(constant); err != nil {
+				return nil, err
+			}
+		}
+		return object, p.advance()
+	default:
+		return nil, p.unexpected()
+	}
+}
@@ -0,0 +1,144 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const DefaultTTL = 24 * time.Hour
+
+var (
+	ErrFingerprintMismatch = errors.New("Idempotency-Key was already used with a different request.")
+	ErrInProgress          = errors.New("A request with the same Idempotency-Key is still being processed.")
+)
+
+/**
+The response saved for a key, replayed to the retries of the request
+*/
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+type entry struct {
+	fingerprint string
+	response    *Response
+	expires     time.Time
+}
+
+/**
+In-memory store of the idempotency keys. A key is reserved by Begin while its request is
+processed, then holds the response until TTL has elapsed.
+*/
+type Store struct {
+	sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+	ttl       time.Duration
+
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+/**
+A ttl of zero or less means DefaultTTL
+*/
+func NewStore(ttl time.Duration) *Store {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Store{
+		entries: map[string]*entry{},
+		ttl:     ttl,
+	}
+}
+
+/**
+Reserve the key for a request. Returns the saved response when the request was already
+processed, nil when the caller has to process it and then call Complete or Abandon.
+ErrFingerprintMismatch means the key was used for another request and ErrInProgress
+that the first request with the key has not completed yet.
+*/
+func (s *Store) Begin(key string, fingerprint string) (*Response, error) {
+	now := s.now()
+
+	s.Lock()
+	defer s.Unlock()
+	s.sweep(now)
+
+	existing, ok := s.entries[key]
+	if ok && now.Before(existing.expires) {
+		if existing.fingerprint != fingerprint {
+			return nil, ErrFingerprintMismatch
+		}
+		if existing.response == nil {
+			return nil, ErrInProgress
+		}
+		return existing.response, nil
+	}
+
+	s.entries[key] = &entry{fingerprint: fingerprint, expires: now.Add(s.ttl)}
+	return nil, nil
+}
+
+/**
+Save the response of a reserved key
+*/
+func (s *Store) Complete(key string, response Response) {
+	now := s.now()
+
+	s.Lock()
+	defer s.Unlock()
+	if existing, ok := s.entries[key]; ok {
+		existing.response = &response
+		existing.expires = now.Add(s.ttl)
+	}
+}
+
+/**
+Release a reserved key without saving a response, so the request can be retried
+*/
+func (s *Store) Abandon(key string) {
+	s.Lock()
+	defer s.Unlock()
+	if existing, ok := s.entries[key]; ok && existing.response == nil {
+		delete(s.entries, key)
+	}
+}
+
+func (s *Store) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+/**
+Forget the expired keys. Runs at most once per minute so Begin stays cheap.
+*/
+func (s *Store) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, existing := range s.entries {
+		if !now.Before(existing.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+/**
+Identifies a request by its method, URL and body
+*/
+func Fingerprint(method string, url string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + url + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,57 @@
+package idempotency
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBeginReservesKeyAndReplaysResponse(t *testing.T) {
+	store := NewStore(time.Hour)
+	saved, err := store.Begin("key", "fingerprint")
+	assert.Nil(t, saved)
+	assert.Nil(t, err)
+
+	_, err = store.Begin("key", "fingerprint")
+	assert.Equal(t, ErrInProgress, err)
+
+	store.Complete("key", Response{StatusCode: http.StatusCreated, Body: []byte("done")})
+	saved, err = store.Begin("key", "fingerprint")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, saved.StatusCode)
+	assert.Equal(t, "done", string(saved.Body))
+
+	_, err = store.Begin("key", "other fingerprint")
+	assert.Equal(t, ErrFingerprintMismatch, err)
+}
+
+func TestAbandonedKeyCanBeReused(t *testing.T) {
+	store := NewStore(time.Hour)
+	store.Begin("key", "fingerprint")
+	store.Abandon("key")
+
+	saved, err := store.Begin("key", "other fingerprint")
+	assert.Nil(t, saved)
+	assert.Nil(t, err)
+}
+
+func TestKeysExpireAfterTTL(t *testing.T) {
+	now := time.Now()
+	store := NewStore(time.Hour)
+	store.Now = func() time.Time { return now }
+	store.Begin("key", "fingerprint")
+	store.Complete("key", Response{StatusCode: http.StatusOK})
+
+	now = now.Add(time.Hour)
+	saved, err := store.Begin("key", "other fingerprint")
+	assert.Nil(t, saved)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(store.entries))
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(t, Fingerprint("POST", "/countries", []byte("{}")), Fingerprint("POST", "/countries", []byte("{}")))
+	assert.NotEqual(t, Fingerprint("POST", "/countries", []byte("{}")), Fingerprint("POST", "/transactions", []byte("{}")))
+	assert.NotEqual(t, Fingerprint("POST", "/countries", []byte("{}")), Fingerprint("POST", "/countries", []byte("[]")))
+}
@@ -0,0 +1,228 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+/**
+JSON-RPC 2.0, see https://www.jsonrpc.org/specification
+*/
+const Version = "2.0"
+
+/**
+The codes defined by the specification. -32000 to -32099 are left to the applications.
+*/
+const (
+	ParseError     = -32700
+	InvalidRequest = -32600
+	MethodNotFound = -32601
+	InvalidParams  = -32602
+	InternalError  = -32603
+)
+
+/**
+A call. ID is absent for the notifications, which get no response.
+*/
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+/**
+Exactly one of Result and Error is set. A successful call returning nothing has the "null" Result.
+*/
+type Response struct {
+	JSONRPC string           `json:"jsonrpc"`
+	Result  *json.RawMessage `json:"result,omitempty"`
+	Error   *Error           `json:"error,omitempty"`
+	ID      json.RawMessage  `json:"id"`
+}
+
+/**
+The error of a call. Methods return one to choose the code, any other error is an InternalError.
+*/
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s", e.Code, e.Message)
+}
+
+/**
+A method receives the params of the call as sent, see DecodeParams
+*/
+type Method func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+type Server struct {
+	Methods map[string]Method
+}
+
+var null = json.RawMessage("null")
+
+/**
+Handle the body of a request, a call or a batch of calls run in order.
+Returns the body of the response, nil when there is nothing to respond:
+a notification, or a batch of notifications.
+*/
+func (s *Server) Handle(ctx context.Context, body []byte) []byte {
+	trimmed := bytes.TrimSpace(body)
+	if !json.Valid(trimmed) {
+		return marshal(errorResponse(null, &Error{Code: ParseError, Message: "Parse error"}))
+	}
+
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		response := s.call(ctx, trimmed)
+		if response == nil {
+			return nil
+		}
+		return marshal(response)
+	}
+
+	var batch []json.RawMessage
+	json.Unmarshal(trimmed, &batch)
+	if len(batch) == 0 {
+		return marshal(errorResponse(null, &Error{Code: InvalidRequest, Message: "Invalid Request", Data: "empty batch"}))
+	}
+	responses := []*Response{}
+	for _, call := range batch {
+		if response := s.call(ctx, call); response != nil {
+			responses = append(responses, response)
+		}
+	}
+	if len(responses) == 0 {
+		return nil
+	}
+	return marshal(responses)
+}
+
+func (s *Server) call(ctx context.Context, body json.RawMessage) *Response {
+	var request Request
+	if err := json.Unmarshal(body, &request); err != nil {
+		return errorResponse(null, &Error{Code: InvalidRequest, Message: "Invalid Request", Data: "a call is an object with a string method"})
+	}
+	id := request.ID
+	if id != nil && !validID(id) {
+		return errorResponse(null, &Error{Code: InvalidRequest, Message: "Invalid Request", Data: "id must be a string, a number or null"})
+	}
+	if request.JSONRPC != Version || request.Method == "" {
+		if id == nil {
+			id = null
+		}
+		return errorResponse(id, &Error{Code: InvalidRequest, Message: "Invalid Request", Data: "jsonrpc must be \"2.0\" and method is required"})
+	}
+
+	result, err := s.invoke(ctx, request)
+	if id == nil {
+		return nil
+	}
+	if err != nil {
+		return errorResponse(id, err)
+	}
+	resultBytes, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		return errorResponse(id, &Error{Code: InternalError, Message: marshalErr.Error()})
+	}
+	raw := json.RawMessage(resultBytes)
+	return &Response{JSONRPC: Version, Result: &raw, ID: id}
+}
+
+func (s *Server) invoke(ctx context.Context, request Request) (interface{}, *Error) {
+	method, ok := s.Methods[request.Method]
+	if !ok {
+		return nil, &Error{Code: MethodNotFound, Message: "Method not found", Data: request.Method}
+	}
+	if len(request.Params) > 0 && request.Params[0] != '[' && request.Params[0] != '{' {
+		return nil, &Error{Code: InvalidRequest, Message: "Invalid Request", Data: "params must be an array or an object"}
+	}
+
+	result, err := method(ctx, request.Params)
+	if err == nil {
+		return result, nil
+	}
+	if rpcError, ok := err.(*Error); ok {
+		return nil, rpcError
+	}
+	return nil, &Error{Code: InternalError, Message: err.Error()}
+}
+
+func validID(id json.RawMessage) bool {
+	var value interface{}
+	json.Unmarshal(id, &value)
+	switch value.(type) {
+	case nil, string, float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func errorResponse(id json.RawMessage, err *Error) *Response {
+	return &Response{JSONRPC: Version, Error: err, ID: id}
+}
+
+func marshal(value interface{}) []byte {
+	jsonBytes, _ := json.Marshal(value)
+	return jsonBytes
+}
+
+/**
+Decode params given by position, e.g. ["greece"], or by name, e.g. {"countryId": "greece"},
+into one target per name. Every param is required. Returns an InvalidParams error.
+*/
+func DecodeParams(params json.RawMessage, names []string, targets ...interface{}) error {
+	if len(params) == 0 {
+		if len(names) == 0 {
+			return nil
+		}
+		return &Error{Code: InvalidParams, Message: "Invalid params", Data: fmt.Sprintf("expected params %v", names)}
+	}
+
+	values := make([]json.RawMessage, len(names))
+	if params[0] == '[' {
+		var positional []json.RawMessage
+		json.Unmarshal(params, &positional)
+		if len(positional) != len(names) {
+			return &Error{Code: InvalidParams, Message: "Invalid params", Data: fmt.Sprintf("expected %d params %v, got %d", len(names), names, len(positional))}
+		}
+		copy(values, positional)
+	} else {
+		var named map[string]json.RawMessage
+		json.Unmarshal(params, &named)
+		for name := range named {
+			if !contains(names, name) {
+				return &Error{Code: InvalidParams, Message: "Invalid params", Data: fmt.Sprintf("unknown param %q", name)}
+			}
+		}
+		for i, name := range names {
+			value, ok := named[name]
+			if !ok {
+				return &Error{Code: InvalidParams, Message: "Invalid params", Data: fmt.Sprintf("missing param %q", name)}
+			}
+			values[i] = value
+		}
+	}
+
+	for i, value := range values {
+		if err := json.Unmarshal(value, targets[i]); err != nil {
+			return &Error{Code: InvalidParams, Message: "Invalid params", Data: fmt.Sprintf("%s: %s", names[i], err.Error())}
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func testServer(calls *[]string) *Server {
+	return &Server{Methods: map[string]Method{
+		"subtract": func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			var minuend, subtrahend float64
+			if err := DecodeParams(params, []string{"minuend", "subtrahend"}, &minuend, &subtrahend); err != nil {
+				return nil, err
+			}
+			return minuend - subtrahend, nil
+		},
+		"notify": func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			*calls = append(*calls, string(params))
+			return nil, nil
+		},
+		"fail": func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			return nil, errors.New("boom")
+		},
+		"missing": func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			return nil, &Error{Code: -32001, Message: "Not found", Data: "greece"}
+		},
+	}}
+}
+
+func handle(body string) string {
+	calls := []string{}
+	return string(testServer(&calls).Handle(context.Background(), []byte(body)))
+}
+
+func TestCallByPositionAndByName(t *testing.T) {
+	assert.Equal(t, `{"jsonrpc":"2.0","result":19,"id":1}`, handle(`{"jsonrpc": "2.0", "method": "subtract", "params": [42, 23], "id": 1}`))
+	assert.Equal(t, `{"jsonrpc":"2.0","result":-19,"id":"a"}`, handle(`{"jsonrpc": "2.0", "method": "subtract", "params": {"subtrahend": 42, "minuend": 23}, "id": "a"}`))
+	assert.Equal(t, `{"jsonrpc":"2.0","result":null,"id":null}`, handle(`{"jsonrpc": "2.0", "method": "notify", "id": null}`))
+}
+
+func TestErrors(t *testing.T) {
+	for body, expected := range map[string]string{
+		`{"jsonrpc": "2.0", "method": "foobar", "id": 1}`:                       `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"foobar"},"id":1}`,
+		`{"jsonrpc": "2.0", "method": "fail", "id": 1}`:                         `{"jsonrpc":"2.0","error":{"code":-32603,"message":"boom"},"id":1}`,
+		`{"jsonrpc": "2.0", "method": "missing", "id": 1}`:                      `{"jsonrpc":"2.0","error":{"code":-32001,"message":"Not found","data":"greece"},"id":1}`,
+		`{"jsonrpc": "2.0", "method": "subtract", "params": [1], "id": 1}`:      `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"expected 2 params [minuend subtrahend], got 1"},"id":1}`,
+		`{"jsonrpc": "2.0", "method": "subtract", "params": {"x": 1}, "id": 1}`: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"unknown param \"x\""},"id":1}`,
+		`{"jsonrpc": "2.0", "method": "subtract", "params": "1", "id": 1}`:      `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"params must be an array or an object"},"id":1}`,
+		`{"jsonrpc": "1.0", "method": "subtract", "id": 1}`:                     `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"jsonrpc must be \"2.0\" and method is required"},"id":1}`,
+		`{"jsonrpc": "2.0", "method": "subtract", "id": {}}`:                    `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"id must be a string, a number or null"},"id":null}`,
+		`{"jsonrpc": "2.0", "method": 1, "params": "bar"}`:                      `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"a call is an object with a string method"},"id":null}`,
+		`{"jsonrpc": "2.0", "method": "foobar, "params": "bar", "baz]`:          `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`,
+		`[]`: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"empty batch"},"id":null}`,
+	} {
+		assert.Equal(t, expected, handle(body), body)
+	}
+}
+
+func TestNotificationsGetNoResponse(t *testing.T) {
+	calls := []string{}
+	server := testServer(&calls)
+	assert.Nil(t, server.Handle(context.Background(), []byte(`{"jsonrpc": "2.0", "method": "notify", "params": [1]}`)))
+	assert.Nil(t, server.Handle(context.Background(), []byte(`{"jsonrpc": "2.0", "method": "fail"}`)))
+	assert.Nil(t, server.Handle(context.Background(), []byte(`[{"jsonrpc": "2.0", "method": "notify", "params": [2]}, {"jsonrpc": "2.0", "method": "unknown"}]`)))
+	assert.Equal(t, []string{"[1]", "[2]"}, calls)
+}
+
+func TestBatch(t *testing.T) {
+	body := `[
+		{"jsonrpc": "2.0", "method": "subtract", "params": [3, 1], "id": "1"},
+		{"jsonrpc": "2.0", "method": "notify", "params": [7]},
+		{"foo": "boo"},
+		{"jsonrpc": "2.0", "method": "missing", "id": "2"},
+		1
+	]`
+	assert.Equal(t, `[`+
+		`{"jsonrpc":"2.0","result":2,"id":"1"},`+
+		`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"jsonrpc must be \"2.0\" and method is required"},"id":null},`+
+		`{"jsonrpc":"2.0","error":{"code":-32001,"message":"Not found","data":"greece"},"id":"2"},`+
+		`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"a call is an object with a string method"},"id":null}`+
+		`]`, handle(body))
+}
@@ -1,8 +1,9 @@
 package models
 
 type Country struct {
-	Name       string     `json:"name"`
-	Alpha2Code string     `json:"alpha2Code"`
+	Name       string     `json:"name" description:"Common name of the country, its lower case is the id of the country" schema:"required,minLength=1,maxLength=100"`
+	Alpha2Code string     `json:"alpha2Code" description:"ISO 3166-1 alpha-2 code" schema:"required,pattern=^[A-Z]{2}$"`
 	Capital    string     `json:"capital"`
+	Region     string     `json:"region" description:"e.g. Europe, Asia"`
 	Currencies []Currency `json:"currencies"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,38 @@
+package models
+
+import "strings"
+
+/**
+Selects countries by their fields. Empty fields match any country, and values are
+compared case-insensitively. Currency matches the code of any of the country's currencies.
+*/
+type CountryFilter struct {
+	Name       string `json:"name,omitempty"`
+	Alpha2Code string `json:"alpha2Code,omitempty"`
+	Capital    string `json:"capital,omitempty"`
+	Region     string `json:"region,omitempty"`
+	Currency   string `json:"currency,omitempty"`
+}
+
+func (f CountryFilter) Matches(country Country) bool {
+	if !matchesField(f.Name, country.Name) ||
+		!matchesField(f.Alpha2Code, country.Alpha2Code) ||
+		!matchesField(f.Capital, country.Capital) ||
+		!matchesField(f.Region, country.Region) {
+		return false
+	}
+
+	if f.Currency == "" {
+		return true
+	}
+	for _, currency := range country.Currencies {
+		if strings.EqualFold(f.Currency, currency.Code) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesField(expected string, actual string) bool {
+	return expected == "" || strings.EqualFold(expected, actual)
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCountryFilterMatches(t *testing.T) {
+	greece := Country{
+		Name:       "Greece",
+		Alpha2Code: "GR",
+		Capital:    "Athens",
+		Region:     "Europe",
+		Currencies: []Currency{{Code: "EUR", Name: "Euro", Symbol: "E"}},
+	}
+
+	assert.True(t, CountryFilter{}.Matches(greece))
+	assert.True(t, CountryFilter{Currency: "eur", Region: "europe"}.Matches(greece))
+	assert.True(t, CountryFilter{Name: "GREECE", Alpha2Code: "gr", Capital: "athens"}.Matches(greece))
+	assert.False(t, CountryFilter{Currency: "USD"}.Matches(greece))
+	assert.False(t, CountryFilter{Currency: "EUR", Region: "Asia"}.Matches(greece))
+}
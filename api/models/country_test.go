@@ -18,6 +18,7 @@ func TestCountryDeserialization(t *testing.T) {
 	assert.Equal(t, "Greece", country.Name)
 	assert.Equal(t, "GR", country.Alpha2Code)
 	assert.Equal(t, "Athens", country.Capital)
+	assert.Equal(t, "Europe", country.Region)
 	assert.Equal(t, 1, len(country.Currencies))
 	assert.Equal(t, "Euro", country.Currencies[0].Name)
 	assert.Equal(t, "EUR", country.Currencies[0].Code)
@@ -1,7 +1,7 @@
 package models
 
 type Currency struct {
-	Code   string `json:"code"`
+	Code   string `json:"code" description:"ISO 4217 code" schema:"required,pattern=^[A-Z]{3}$"`
 	Name   string `json:"name"`
 	Symbol string `json:"symbol"`
-}
\ No newline at end of file
+}
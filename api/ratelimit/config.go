@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strings"
+)
+
+/**
+A limit applied to the requests matching Method (empty matches every method)
+and starting with the Path prefix
+*/
+type RouteLimit struct {
+	Method string
+	Path   string
+	Limit  Limit
+}
+
+type Config struct {
+	Limiter Limiter
+
+	// applied when no route limit matches. A zero Default means unlimited
+	Default Limit
+
+	// checked in order, the first match wins
+	Routes []RouteLimit
+}
+
+/**
+Returns the limit for a request and the name of the bucket it counts against.
+Every route limit has its own bucket, so a client exhausting one route can still call the others.
+*/
+func (c *Config) LimitFor(method string, path string) (string, Limit, bool) {
+	for _, route := range c.Routes {
+		if (route.Method == "" || route.Method == method) && strings.HasPrefix(path, route.Path) {
+			return strings.TrimSpace(fmt.Sprintf("%s %s", route.Method, route.Path)), route.Limit, true
+		}
+	}
+	if c.Default.Burst == 0 {
+		return "", Limit{}, false
+	}
+	return "default", c.Default, true
+}
+
+/**
+Parse route limits from a comma separated list like "GET /countries=5/s,POST /countries=10/m".
+The method can be omitted to match every method, e.g. "/countries=100/m".
+*/
+func ParseRouteLimits(value string) ([]RouteLimit, error) {
+	routes := []RouteLimit{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		routeAndLimit := strings.SplitN(entry, "=", 2)
+		if len(routeAndLimit) != 2 {
+			return nil, fmt.Errorf("Malformed route limit '%s'.", entry)
+		}
+
+		limit, err := ParseLimit(routeAndLimit[1])
+		if err != nil {
+			return nil, err
+		}
+
+		route := RouteLimit{Path: strings.TrimSpace(routeAndLimit[0]), Limit: limit}
+		if methodAndPath := strings.Fields(route.Path); len(methodAndPath) == 2 {
+			route.Method = strings.ToUpper(methodAndPath[0])
+			route.Path = methodAndPath[1]
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+A token bucket limit. Tokens are refilled at Rate per second up to Burst tokens
+*/
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+/**
+Outcome of taking a token for a key. Remaining, Reset and RetryAfter are used to
+produce the RateLimit-* and Retry-After response headers.
+*/
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+
+	// time until the bucket is full again
+	Reset time.Duration
+
+	// time until the next token is available, zero when allowed
+	RetryAfter time.Duration
+}
+
+/**
+Limiter is the storage abstraction behind rate limiting. MemoryLimiter keeps the buckets
+in process, an implementation backed by a shared store (e.g. Redis) can be plugged in
+when the API runs on several instances.
+*/
+type Limiter interface {
+	Take(key string, limit Limit) (Result, error)
+}
+
+/**
+Parse limits like "100/m", "5/s" or "1000/h". The burst is equal to the count.
+*/
+func ParseLimit(value string) (Limit, error) {
+	parts := strings.SplitN(strings.TrimSpace(value), "/", 2)
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("Malformed rate limit '%s'.", value)
+	}
+
+	count, err := strconv.Atoi(parts[0])
+	if err != nil || count <= 0 {
+		return Limit{}, fmt.Errorf("Malformed rate limit '%s'.", value)
+	}
+
+	var period time.Duration
+	switch parts[1] {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("Malformed rate limit '%s'.", value)
+	}
+
+	return Limit{Rate: float64(count) / period.Seconds(), Burst: count}, nil
+}
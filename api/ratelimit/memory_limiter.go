@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+type MemoryLimiter struct {
+	sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: map[string]*bucket{},
+	}
+}
+
+func (limiter *MemoryLimiter) Take(key string, limit Limit) (Result, error) {
+	now := limiter.now()
+
+	limiter.Lock()
+	defer limiter.Unlock()
+	limiter.sweep(now)
+
+	b, ok := limiter.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Burst), updated: now, limit: limit}
+		limiter.buckets[key] = b
+	}
+	b.refill(now)
+
+	result := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = durationFor(1-b.tokens, limit.Rate)
+	}
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = durationFor(float64(limit.Burst)-b.tokens, limit.Rate)
+	return result, nil
+}
+
+func (limiter *MemoryLimiter) now() time.Time {
+	if limiter.Now != nil {
+		return limiter.Now()
+	}
+	return time.Now()
+}
+
+/**
+Forget the buckets that are full again, they behave exactly like a new bucket.
+Runs at most once per minute so Take stays cheap.
+*/
+func (limiter *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(limiter.lastSweep) < time.Minute {
+		return
+	}
+	limiter.lastSweep = now
+	for key, b := range limiter.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(limiter.buckets, key)
+		}
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+		b.updated = now
+	}
+}
+
+func durationFor(tokens float64, rate float64) time.Duration {
+	if tokens <= 0 || rate <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / rate * float64(time.Second))
+}
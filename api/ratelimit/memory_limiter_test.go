@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiterAllowsBurstThenRejects(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	limiter := NewMemoryLimiter()
+	limiter.Now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 2}
+
+	first, _ := limiter.Take("client", limit)
+	second, _ := limiter.Take("client", limit)
+	third, _ := limiter.Take("client", limit)
+	assert.True(t, first.Allowed)
+	assert.Equal(t, 1, first.Remaining)
+	assert.True(t, second.Allowed)
+	assert.Equal(t, 0, second.Remaining)
+	assert.False(t, third.Allowed)
+	assert.Equal(t, time.Second, third.RetryAfter)
+	assert.Equal(t, 2*time.Second, third.Reset)
+}
+
+func TestMemoryLimiterRefillsOverTime(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	limiter := NewMemoryLimiter()
+	limiter.Now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 1}
+
+	first, _ := limiter.Take("client", limit)
+	rejected, _ := limiter.Take("client", limit)
+	now = now.Add(time.Second)
+	afterRefill, _ := limiter.Take("client", limit)
+	assert.True(t, first.Allowed)
+	assert.False(t, rejected.Allowed)
+	assert.True(t, afterRefill.Allowed)
+}
+
+func TestMemoryLimiterKeysAreIndependent(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	first, _ := limiter.Take("client-a", limit)
+	second, _ := limiter.Take("client-b", limit)
+	assert.True(t, first.Allowed)
+	assert.True(t, second.Allowed)
+}
+
+func TestParseLimit(t *testing.T) {
+	perMinute, perMinuteError := ParseLimit("120/m")
+	_, malformedError := ParseLimit("120 per minute")
+	assert.Nil(t, perMinuteError)
+	assert.Equal(t, Limit{Rate: 2, Burst: 120}, perMinute)
+	assert.Equal(t, "Malformed rate limit '120 per minute'.", malformedError.Error())
+}
+
+func TestParseRouteLimitsAndLimitFor(t *testing.T) {
+	routes, err := ParseRouteLimits("GET /countries=5/s, /countries/=10/m")
+	config := Config{Default: Limit{Rate: 1, Burst: 1}, Routes: routes}
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(routes))
+
+	getBucket, getLimit, _ := config.LimitFor("GET", "/countries")
+	deleteBucket, deleteLimit, _ := config.LimitFor("DELETE", "/countries/greece")
+	postBucket, postLimit, _ := config.LimitFor("POST", "/countries")
+	assert.Equal(t, "GET /countries", getBucket)
+	assert.Equal(t, 5, getLimit.Burst)
+	assert.Equal(t, "/countries/", deleteBucket)
+	assert.Equal(t, 10, deleteLimit.Burst)
+	assert.Equal(t, "default", postBucket)
+	assert.Equal(t, 1, postLimit.Burst)
+}
@@ -0,0 +1,206 @@
+package restcountries
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/store"
+	"sort"
+)
+
+/**
+The versions of the REST Countries format, https://restcountries.com
+*/
+const (
+	V2 = 2
+	V3 = 3
+)
+
+/**
+The countries mapped from a REST Countries file. Unmapped counts the countries having
+each field models.Country has no place for, e.g. {"population": 250, "name.official": 250}.
+*/
+type Result struct {
+	Version   int
+	Countries []models.Country
+	Unmapped  map[string]int
+}
+
+/**
+The unmapped fields sorted by name
+*/
+func (r Result) UnmappedFields() []string {
+	fields := []string{}
+	for field := range r.Unmapped {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+/**
+Map a REST Countries file, an array of countries or a single one, into models.Country.
+The version is detected from the fields of the first country: v2 has an "alpha2Code",
+v3 a "cca2".
+*/
+func Decode(data []byte) (*Result, error) {
+	var countries []map[string]json.RawMessage
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var country map[string]json.RawMessage
+		if err := json.Unmarshal(data, &country); err != nil {
+			return nil, err
+		}
+		countries = append(countries, country)
+	} else if err := json.Unmarshal(data, &countries); err != nil {
+		return nil, err
+	}
+
+	result := &Result{Countries: []models.Country{}, Unmapped: map[string]int{}}
+	if len(countries) == 0 {
+		return result, nil
+	}
+	switch {
+	case countries[0]["alpha2Code"] != nil:
+		result.Version = V2
+	case countries[0]["cca2"] != nil:
+		result.Version = V3
+	default:
+		return nil, errors.New("not a REST Countries file, the countries have no alpha2Code (v2) nor cca2 (v3)")
+	}
+
+	for i, fields := range countries {
+		m := mapper{unmapped: map[string]bool{}}
+		var country models.Country
+		if result.Version == V2 {
+			country = m.v2(fields)
+		} else {
+			country = m.v3(fields)
+		}
+		if m.err != nil {
+			return nil, fmt.Errorf("country %d: %v", i, m.err)
+		}
+		for field := range m.unmapped {
+			result.Unmapped[field]++
+		}
+		result.Countries = append(result.Countries, country)
+	}
+	return result, nil
+}
+
+/**
+Add the countries to the store, replacing the ones with the same name. It stops at the
+first error, returning the number of countries added until then.
+*/
+func Load(ctx context.Context, actions store.Actions, countries []models.Country) (int, error) {
+	for i, country := range countries {
+		if _, err := actions.AddCountry(ctx, country); err != nil {
+			return i, fmt.Errorf("%s: %w", country.Name, err)
+		}
+	}
+	return len(countries), nil
+}
+
+/**
+Decodes the fields of one country, collecting the ones left unmapped once however many
+currencies have them. The first decoding error is kept in err.
+*/
+type mapper struct {
+	unmapped map[string]bool
+	err      error
+}
+
+func (m *mapper) v2(fields map[string]json.RawMessage) models.Country {
+	country := models.Country{}
+	m.decode(fields["name"], &country.Name)
+	m.decode(fields["alpha2Code"], &country.Alpha2Code)
+	m.decode(fields["capital"], &country.Capital)
+	m.decode(fields["region"], &country.Region)
+
+	var currencies []map[string]json.RawMessage
+	m.decode(fields["currencies"], &currencies)
+	for _, currencyFields := range currencies {
+		var currency models.Currency
+		m.decode(currencyFields["code"], &currency.Code)
+		m.decode(currencyFields["name"], &currency.Name)
+		m.decode(currencyFields["symbol"], &currency.Symbol)
+		m.skip("currencies.", currencyFields, "code", "name", "symbol")
+		// the dataset has currencies without code, like "(none)" ones
+		if currency.Code != "" && currency.Code != "(none)" {
+			country.Currencies = append(country.Currencies, currency)
+		}
+	}
+
+	m.skip("", fields, "name", "alpha2Code", "capital", "region", "currencies")
+	return country
+}
+
+func (m *mapper) v3(fields map[string]json.RawMessage) models.Country {
+	country := models.Country{}
+	var name map[string]json.RawMessage
+	m.decode(fields["name"], &name)
+	m.decode(name["common"], &country.Name)
+	m.skip("name.", name, "common")
+	m.decode(fields["cca2"], &country.Alpha2Code)
+	m.decode(fields["region"], &country.Region)
+
+	var capitals []string
+	m.decode(fields["capital"], &capitals)
+	if len(capitals) > 0 {
+		country.Capital = capitals[0]
+	}
+	if len(capitals) > 1 {
+		m.unmapped["capital[1:]"] = true
+	}
+
+	var currencies map[string]map[string]json.RawMessage
+	m.decode(fields["currencies"], &currencies)
+	codes := []string{}
+	for code := range currencies {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		currency := models.Currency{Code: code}
+		m.decode(currencies[code]["name"], &currency.Name)
+		m.decode(currencies[code]["symbol"], &currency.Symbol)
+		m.skip("currencies.", currencies[code], "name", "symbol")
+		country.Currencies = append(country.Currencies, currency)
+	}
+
+	m.skip("", fields, "name", "cca2", "capital", "region", "currencies")
+	return country
+}
+
+/**
+Decode a field when it is present and not null
+*/
+func (m *mapper) decode(field json.RawMessage, target interface{}) {
+	if field == nil || m.err != nil || string(field) == "null" {
+		return
+	}
+	m.err = json.Unmarshal(field, target)
+}
+
+/**
+Collect the fields other than the mapped ones, prefixed by the path of their object
+*/
+func (m *mapper) skip(prefix string, fields map[string]json.RawMessage, mapped ...string) {
+	for field := range fields {
+		if !contains(mapped, field) {
+			m.unmapped[prefix+field] = true
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
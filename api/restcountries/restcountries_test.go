@@ -0,0 +1,99 @@
+package restcountries
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/dataset"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/schema"
+	"go-countries-rest-api/api/store"
+	"io/ioutil"
+	"testing"
+)
+
+func decodeFile(t *testing.T, name string) *Result {
+	data, err := ioutil.ReadFile("testdata/" + name)
+	assert.Nil(t, err)
+	result, err := Decode(data)
+	assert.Nil(t, err)
+	return result
+}
+
+func TestDecodeV2(t *testing.T) {
+	result := decodeFile(t, "v2.json")
+
+	assert.Equal(t, V2, result.Version)
+	assert.Equal(t, []models.Country{
+		{Name: "Greece", Alpha2Code: "GR", Capital: "Athens", Region: "Europe", Currencies: []models.Currency{{Code: "EUR", Name: "Euro", Symbol: "€"}}},
+		{Name: "Cuba", Alpha2Code: "CU", Capital: "Havana", Region: "Americas", Currencies: []models.Currency{
+			{Code: "CUC", Name: "Cuban convertible peso", Symbol: "$"},
+			{Code: "CUP", Name: "Cuban peso", Symbol: "$"},
+		}},
+		{Name: "Antarctica", Alpha2Code: "AQ", Region: "Polar"},
+	}, result.Countries)
+	assert.Equal(t, map[string]int{
+		"alpha3Code": 3, "callingCodes": 1, "languages": 1, "population": 3, "subregion": 1, "topLevelDomain": 1,
+	}, result.Unmapped)
+	assert.Equal(t, []schema.Violation{}, dataset.Validate(result.Countries))
+}
+
+func TestDecodeV3(t *testing.T) {
+	result := decodeFile(t, "v3.json")
+
+	assert.Equal(t, V3, result.Version)
+	assert.Equal(t, []models.Country{
+		{Name: "Greece", Alpha2Code: "GR", Capital: "Athens", Region: "Europe", Currencies: []models.Currency{{Code: "EUR", Name: "Euro", Symbol: "€"}}},
+		{Name: "South Africa", Alpha2Code: "ZA", Capital: "Pretoria", Region: "Africa", Currencies: []models.Currency{{Code: "ZAR", Name: "South African rand", Symbol: "R"}}},
+		{Name: "Antarctica", Alpha2Code: "AQ", Region: "Antarctic"},
+	}, result.Countries)
+	assert.Equal(t, []string{
+		"capital[1:]", "cca3", "independent", "name.nativeName", "name.official", "population", "subregion", "tld",
+	}, result.UnmappedFields())
+	assert.Equal(t, 3, result.Unmapped["name.official"])
+	assert.Equal(t, 1, result.Unmapped["capital[1:]"])
+}
+
+func TestDecodeSingleCountry(t *testing.T) {
+	result, err := Decode([]byte(`{"name": {"common": "Japan"}, "cca2": "JP", "currencies": {"JPY": {"name": "Japanese yen", "symbol": "¥"}}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Country{{Name: "Japan", Alpha2Code: "JP", Currencies: []models.Currency{{Code: "JPY", Name: "Japanese yen", Symbol: "¥"}}}}, result.Countries)
+	assert.Equal(t, map[string]int{}, result.Unmapped)
+}
+
+func TestUnmappedFieldsAreCountedOncePerCountry(t *testing.T) {
+	result, err := Decode([]byte(`[
+		{"name": {"common": "Switzerland"}, "cca2": "CH", "currencies": {"CHF": {"name": "Swiss franc", "symbol": "Fr.", "rate": 1}, "EUR": {"name": "Euro", "symbol": "€", "rate": 1}}},
+		{"name": {"common": "Greece"}, "cca2": "GR", "currencies": {"EUR": {"name": "Euro", "symbol": "€", "rate": 1}}}
+	]`))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"currencies.rate": 2}, result.Unmapped)
+}
+
+func TestDecodeRejectsOtherFiles(t *testing.T) {
+	_, err := Decode([]byte(`[{"name": "Greece", "code": "GR"}]`))
+	assert.EqualError(t, err, "not a REST Countries file, the countries have no alpha2Code (v2) nor cca2 (v3)")
+
+	_, err = Decode([]byte(`[{"name": "Greece", "alpha2Code": "GR"}, {"name": {"common": "Spain"}, "alpha2Code": "ES"}]`))
+	assert.EqualError(t, err, "country 1: json: cannot unmarshal object into Go value of type string")
+
+	result, err := Decode([]byte(`[]`))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(result.Countries))
+}
+
+func TestLoad(t *testing.T) {
+	storage := store.NewCountriesStorage()
+	result := decodeFile(t, "v3.json")
+
+	loaded, err := Load(context.Background(), storage, result.Countries)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, loaded)
+	country, _ := storage.GetCountryById(context.Background(), "south africa")
+	assert.Equal(t, "Pretoria", country.Capital)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	loaded, err = Load(ctx, storage, result.Countries)
+	assert.Equal(t, 0, loaded)
+	assert.EqualError(t, err, "Greece: context canceled")
+}
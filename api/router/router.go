@@ -0,0 +1,156 @@
+package router
+
+import (
+	"context"
+	utils "go-countries-rest-api/api/utils"
+	"net/http"
+	"strings"
+)
+
+/**
+A minimal URL router dispatching on the method and the path of the requests, with the path
+parameters available to the handlers through Param.
+Patterns are made of segments which are either
+* literals, e.g. "countries"
+* parameters, e.g. "{id}", matching any non empty segment
+* parameters with a literal suffix, e.g. "{id}:restore", matching "greece:restore"
+When several patterns match a path, the most specific wins: segment by segment,
+a literal beats a parameter with suffix, which beats a plain parameter.
+So "/countries/random" is preferred over "/countries/{id}".
+*/
+type Router struct {
+	routes []Route
+}
+
+type Route struct {
+	Method   string
+	Pattern  string
+	segments []string
+	handler  http.HandlerFunc
+}
+
+type pathParamsKey struct{}
+
+func (r *Router) Handle(method string, pattern string, handler http.HandlerFunc) {
+	r.routes = append(r.routes, Route{
+		Method:   method,
+		Pattern:  pattern,
+		segments: strings.Split(strings.Trim(pattern, "/"), "/"),
+		handler:  handler,
+	})
+}
+
+/**
+The routes in the order they were added
+*/
+func (r *Router) Routes() []Route {
+	return r.routes
+}
+
+func (r *Router) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	segments := strings.Split(strings.Trim(request.URL.Path, "/"), "/")
+
+	var best *Route
+	var bestParams map[string]string
+	var bestRank []int
+	allowed := []string{}
+	for i := range r.routes {
+		candidate := &r.routes[i]
+		params, rank, ok := candidate.match(segments)
+		if !ok {
+			continue
+		}
+		if !containsString(allowed, candidate.Method) {
+			allowed = append(allowed, candidate.Method)
+		}
+		if candidate.Method == request.Method && (best == nil || moreSpecific(rank, bestRank)) {
+			best, bestParams, bestRank = candidate, params, rank
+		}
+	}
+
+	switch {
+	case best != nil:
+		ctx := context.WithValue(request.Context(), pathParamsKey{}, bestParams)
+		best.handler(writer, request.WithContext(ctx))
+	case len(allowed) == 0:
+		utils.ConstructErrorResponse(writer, "Path not found", http.StatusNotFound)
+	case request.Method == "OPTIONS":
+		writer.Header().Set("Allow", strings.Join(append(allowed, "OPTIONS"), ", "))
+		writer.WriteHeader(http.StatusNoContent)
+	default:
+		writer.Header().Set("Allow", strings.Join(allowed, ", "))
+		utils.ConstructErrorResponse(writer, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+/**
+Returns the parameters of the path and the rank of every segment (2 literal, 1 parameter with suffix, 0 parameter)
+*/
+func (r *Route) match(segments []string) (map[string]string, []int, bool) {
+	if len(segments) != len(r.segments) {
+		return nil, nil, false
+	}
+
+	params := map[string]string{}
+	rank := make([]int, len(segments))
+	for i, patternSegment := range r.segments {
+		segment := segments[i]
+		if !strings.HasPrefix(patternSegment, "{") {
+			if segment != patternSegment {
+				return nil, nil, false
+			}
+			rank[i] = 2
+			continue
+		}
+
+		end := strings.Index(patternSegment, "}")
+		name, suffix := patternSegment[1:end], patternSegment[end+1:]
+		if !strings.HasSuffix(segment, suffix) || len(segment) == len(suffix) {
+			return nil, nil, false
+		}
+		params[name] = strings.TrimSuffix(segment, suffix)
+		if suffix != "" {
+			rank[i] = 1
+		}
+	}
+	return params, rank, true
+}
+
+func moreSpecific(rank []int, than []int) bool {
+	for i := range rank {
+		if rank[i] != than[i] {
+			return rank[i] > than[i]
+		}
+	}
+	return false
+}
+
+/**
+The names of the parameters of the pattern, in order
+*/
+func (r Route) Params() []string {
+	names := []string{}
+	for _, segment := range r.segments {
+		if strings.HasPrefix(segment, "{") {
+			names = append(names, segment[1:strings.Index(segment, "}")])
+		}
+	}
+	return names
+}
+
+/**
+The value of a parameter of the route pattern, e.g. Param(request, "id") for "/countries/{id}"
+*/
+func Param(request *http.Request, name string) string {
+	params, _ := request.Context().Value(pathParamsKey{}).(map[string]string)
+	return params[name]
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package router
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouterPrefersMostSpecificRoute(t *testing.T) {
+	r := &Router{}
+	r.Handle("GET", "/countries/{id}", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("country " + Param(request, "id")))
+	})
+	r.Handle("GET", "/countries/random", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("random"))
+	})
+	r.Handle("POST", "/countries/{id}:restore", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("restore " + Param(request, "id")))
+	})
+
+	randomReq, _ := http.NewRequest("GET", "/countries/random", nil)
+	assert.Equal(t, "random", record(randomReq, r).Body.String())
+
+	countryReq, _ := http.NewRequest("GET", "/countries/greece", nil)
+	assert.Equal(t, "country greece", record(countryReq, r).Body.String())
+
+	restoreReq, _ := http.NewRequest("POST", "/countries/greece:restore", nil)
+	assert.Equal(t, "restore greece", record(restoreReq, r).Body.String())
+}
+
+func TestRouterRespondsNotFoundAndMethodNotAllowed(t *testing.T) {
+	r := &Router{}
+	r.Handle("GET", "/countries/{id}", func(writer http.ResponseWriter, request *http.Request) {})
+	r.Handle("DELETE", "/countries/{id}", func(writer http.ResponseWriter, request *http.Request) {})
+
+	notFoundReq, _ := http.NewRequest("GET", "/countries/greece/cities", nil)
+	assert.Equal(t, http.StatusNotFound, record(notFoundReq, r).Code)
+
+	emptyReq, _ := http.NewRequest("GET", "/countries/", nil)
+	assert.Equal(t, http.StatusNotFound, record(emptyReq, r).Code)
+
+	putReq, _ := http.NewRequest("PUT", "/countries/greece", nil)
+	putReqRecorder := record(putReq, r)
+	assert.Equal(t, http.StatusMethodNotAllowed, putReqRecorder.Code)
+	assert.Equal(t, "GET, DELETE", putReqRecorder.Header().Get("Allow"))
+}
+
+func TestRouterAnswersOptionsWithTheAllowedMethods(t *testing.T) {
+	r := &Router{}
+	r.Handle("GET", "/countries/{id}/revisions/{revision}:restore", func(writer http.ResponseWriter, request *http.Request) {})
+
+	optionsReq, _ := http.NewRequest("OPTIONS", "/countries/greece/revisions/1:restore", nil)
+	optionsReqRecorder := record(optionsReq, r)
+	assert.Equal(t, http.StatusNoContent, optionsReqRecorder.Code)
+	assert.Equal(t, "GET, OPTIONS", optionsReqRecorder.Header().Get("Allow"))
+	assert.Equal(t, []string{"id", "revision"}, r.Routes()[0].Params())
+}
+
+func record(request *http.Request, handler http.Handler) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
@@ -0,0 +1,223 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+The subset of JSON Schema used to describe the models, also valid as an OpenAPI 3 schema object.
+$schema, $id and definitions are only set on the root of a standalone document, see Document.
+Nullable is the OpenAPI 3 keyword, set on slices since encoding/json writes a nil slice as null.
+*/
+type Schema struct {
+	SchemaURI            string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	Definitions          map[string]*Schema `json:"definitions,omitempty"`
+}
+
+/**
+The version of JSON Schema of the documents, the definitions keyword is from draft 7
+*/
+const Draft7 = "http://json-schema.org/draft-07/schema#"
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+/**
+Generator derives schemas from Go types by reflection. Named structs are added to
+Definitions once and referenced with RefPrefix + their name, e.g. "#/components/schemas/Country".
+Properties are named after the json tags and described by the description tags.
+The schema tags add constraints, separated by commas, e.g.
+	`schema:"required,minLength=1,maxLength=100"`
+	`schema:"required,pattern=^[A-Z]{2}$"`
+A pattern takes the rest of the tag, so it comes last and may contain commas.
+*/
+type Generator struct {
+	RefPrefix   string
+	Definitions map[string]*Schema
+}
+
+func NewGenerator(refPrefix string) *Generator {
+	return &Generator{RefPrefix: refPrefix, Definitions: map[string]*Schema{}}
+}
+
+func (g *Generator) Schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		// any JSON value
+		return &Schema{}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		if _, ok := g.Definitions[t.Name()]; !ok {
+			// registered before the properties so recursive types terminate
+			g.Definitions[t.Name()] = &Schema{}
+			*g.Definitions[t.Name()] = *g.object(t)
+		}
+		return &Schema{Ref: g.RefPrefix + t.Name()}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return g.object(t)
+	case reflect.Slice:
+		return &Schema{Type: "array", Nullable: true, Items: g.Schema(t.Elem())}
+	case reflect.Array:
+		return &Schema{Type: "array", Items: g.Schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.Schema(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	default:
+		// interfaces accept any value
+		return &Schema{}
+	}
+}
+
+func (g *Generator) object(t reflect.Type) *Schema {
+	object := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Tag.Get("json") == "" && field.Type.Kind() == reflect.Struct {
+			// embedded structs are flattened by encoding/json
+			embedded := g.object(field.Type)
+			for name, property := range embedded.Properties {
+				object.Properties[name] = property
+			}
+			object.Required = append(object.Required, embedded.Required...)
+			continue
+		}
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+
+		property := g.Schema(field.Type)
+		// siblings of $ref are ignored, the referenced schema is described instead
+		if property.Ref == "" {
+			property.Description = field.Tag.Get("description")
+		}
+		if constrain(property, field.Tag.Get("schema")) {
+			// a required slice must be there, not null
+			property.Nullable = false
+			object.Required = append(object.Required, name)
+		}
+		object.Properties[name] = property
+	}
+	return object
+}
+
+/**
+Apply the constraints of a schema tag to a property, returns whether the property is required
+*/
+func constrain(property *Schema, tag string) bool {
+	required := false
+	for tag != "" {
+		var constraint string
+		if strings.HasPrefix(tag, "pattern=") {
+			constraint, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			constraint, tag = tag[:i], tag[i+1:]
+		} else {
+			constraint, tag = tag, ""
+		}
+
+		key, value := constraint, ""
+		if i := strings.Index(constraint, "="); i >= 0 {
+			key, value = constraint[:i], constraint[i+1:]
+		}
+		switch key {
+		case "required":
+			required = true
+		case "pattern":
+			property.Pattern = value
+		case "minLength":
+			property.MinLength = intValue(value)
+		case "maxLength":
+			property.MaxLength = intValue(value)
+		case "minItems":
+			property.MinItems = intValue(value)
+		}
+	}
+	return required
+}
+
+func intValue(value string) *int {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+/**
+A standalone JSON Schema document of a Go type, e.g. Document(reflect.TypeOf(models.Country{}), "/schemas/country").
+The schemas of the nested named structs are under definitions.
+*/
+func Document(t reflect.Type, id string) *Schema {
+	g := NewGenerator("#/definitions/")
+	root := g.Schema(t)
+	if root.Ref != "" {
+		name := strings.TrimPrefix(root.Ref, g.RefPrefix)
+		copied := *g.Definitions[name]
+		root = &copied
+		delete(g.Definitions, name)
+		root.Title = name
+	}
+
+	root.SchemaURI = Draft7
+	root.ID = id
+	if len(g.Definitions) > 0 {
+		root.Definitions = g.Definitions
+	}
+	return root
+}
+
+/**
+The name of the property of a struct field, empty for the fields not serialized
+*/
+func jsonName(field reflect.StructField) string {
+	if field.PkgPath != "" || field.Anonymous {
+		return ""
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name
+	}
+	return field.Name
+}
@@ -0,0 +1,36 @@
+package schema
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type tree struct {
+	Name     string            `json:"name" description:"Name of the node"`
+	Children []tree            `json:"children,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	Weight   float64           `json:"weight"`
+	Size     int64             `json:"size"`
+	Visible  bool              `json:"visible"`
+	Created  time.Time         `json:"created"`
+	Parent   *tree             `json:"-"`
+	internal string
+}
+
+func TestGeneratorDescribesStructs(t *testing.T) {
+	g := NewGenerator("#/definitions/")
+	assert.Equal(t, &Schema{Ref: "#/definitions/tree"}, g.Schema(reflect.TypeOf(&tree{})))
+
+	definition := g.Definitions["tree"]
+	assert.Equal(t, "object", definition.Type)
+	assert.Equal(t, 7, len(definition.Properties))
+	assert.Equal(t, &Schema{Type: "string", Description: "Name of the node"}, definition.Properties["name"])
+	assert.Equal(t, &Schema{Type: "array", Nullable: true, Items: &Schema{Ref: "#/definitions/tree"}}, definition.Properties["children"])
+	assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}, definition.Properties["labels"])
+	assert.Equal(t, "number", definition.Properties["weight"].Type)
+	assert.Equal(t, "int64", definition.Properties["size"].Format)
+	assert.Equal(t, "boolean", definition.Properties["visible"].Type)
+	assert.Equal(t, "date-time", definition.Properties["created"].Format)
+}
@@ -0,0 +1,154 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/**
+A value not matching its schema, Pointer is the RFC 6901 JSON pointer of the value in the
+document, "" being the whole document
+*/
+type Violation struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Pointer, v.Message)
+}
+
+/**
+Validate a document decoded by encoding/json into an interface{} against a schema.
+References are resolved by their last segment in definitions, so both "#/definitions/Country"
+and "#/components/schemas/Country" find definitions["Country"]; a schema built by Document
+is validated with its own Definitions.
+The violations are sorted by pointer, none means the document is valid.
+*/
+func Validate(s *Schema, definitions map[string]*Schema, document interface{}) []Violation {
+	v := validator{definitions: definitions}
+	v.validate(s, document, "")
+	sort.SliceStable(v.violations, func(i, j int) bool {
+		return v.violations[i].Pointer < v.violations[j].Pointer
+	})
+	return v.violations
+}
+
+type validator struct {
+	definitions map[string]*Schema
+	violations  []Violation
+}
+
+func (v *validator) fail(pointer string, format string, args ...interface{}) {
+	v.violations = append(v.violations, Violation{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(s *Schema, value interface{}, pointer string) {
+	if s.Ref != "" {
+		resolved, ok := v.definitions[s.Ref[strings.LastIndex(s.Ref, "/")+1:]]
+		if !ok {
+			v.fail(pointer, "unknown schema %s", s.Ref)
+			return
+		}
+		s = resolved
+	}
+
+	if value == nil && s.Nullable {
+		return
+	}
+	if s.Type != "" && !hasType(value, s.Type) {
+		v.fail(pointer, "must be of type %s, got %s", s.Type, typeOf(value))
+		return
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(s, value, pointer)
+	case []interface{}:
+		if s.MinItems != nil && len(value) < *s.MinItems {
+			v.fail(pointer, "must have at least %d items", *s.MinItems)
+		}
+		if s.Items != nil {
+			for i, item := range value {
+				v.validate(s.Items, item, pointer+"/"+strconv.Itoa(i))
+			}
+		}
+	case string:
+		v.validateString(s, value, pointer)
+	}
+}
+
+func (v *validator) validateObject(s *Schema, object map[string]interface{}, pointer string) {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			v.fail(pointer+"/"+escape(name), "is required")
+		}
+	}
+	for name, property := range object {
+		if propertySchema, ok := s.Properties[name]; ok {
+			v.validate(propertySchema, property, pointer+"/"+escape(name))
+		} else if s.AdditionalProperties != nil {
+			v.validate(s.AdditionalProperties, property, pointer+"/"+escape(name))
+		}
+	}
+}
+
+func (v *validator) validateString(s *Schema, value string, pointer string) {
+	length := utf8.RuneCountInString(value)
+	if s.MinLength != nil && length < *s.MinLength {
+		v.fail(pointer, "must be at least %d characters long", *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		v.fail(pointer, "must be at most %d characters long", *s.MaxLength)
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			v.fail(pointer, "invalid pattern %s", s.Pattern)
+		} else if !pattern.MatchString(value) {
+			v.fail(pointer, "must match the pattern %s", s.Pattern)
+		}
+	}
+}
+
+func hasType(value interface{}, schemaType string) bool {
+	if schemaType == "integer" {
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	}
+	return typeOf(value) == schemaType
+}
+
+/**
+The JSON type of a value decoded by encoding/json
+*/
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+/**
+Escape a reference token of a JSON pointer, see RFC 6901
+*/
+func escape(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
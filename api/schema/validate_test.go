@@ -0,0 +1,98 @@
+package schema
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+type city struct {
+	Name      string   `json:"name" schema:"required,minLength=1,maxLength=5"`
+	Code      string   `json:"code" schema:"pattern=^[A-Z]{3}$"`
+	Districts []string `json:"districts" schema:"minItems=1"`
+	Area      int      `json:"area"`
+}
+
+type atlas struct {
+	Cities []city `json:"cities" schema:"required"`
+}
+
+func decode(document string) interface{} {
+	var value interface{}
+	json.Unmarshal([]byte(document), &value)
+	return value
+}
+
+func TestGeneratorAddsTagConstraints(t *testing.T) {
+	g := NewGenerator("#/definitions/")
+	g.Schema(reflect.TypeOf(city{}))
+
+	definition := g.Definitions["city"]
+	assert.Equal(t, []string{"name"}, definition.Required)
+	assert.Equal(t, 1, *definition.Properties["name"].MinLength)
+	assert.Equal(t, 5, *definition.Properties["name"].MaxLength)
+	assert.Equal(t, "^[A-Z]{3}$", definition.Properties["code"].Pattern)
+	assert.Equal(t, 1, *definition.Properties["districts"].MinItems)
+}
+
+func TestValidateAcceptsValidDocument(t *testing.T) {
+	g := NewGenerator("#/definitions/")
+	s := g.Schema(reflect.TypeOf(atlas{}))
+
+	document := decode(`{"cities": [{"name": "Paris", "code": "PAR", "districts": ["Marais"], "area": 105, "unknown": true}]}`)
+	assert.Empty(t, Validate(s, g.Definitions, document))
+}
+
+func TestValidateReportsPointers(t *testing.T) {
+	g := NewGenerator("#/definitions/")
+	s := g.Schema(reflect.TypeOf(atlas{}))
+
+	document := decode(`{"cities": [{"name": "Paris"}, {"code": "par", "districts": [], "area": 1.5}, {"name": 3}]}`)
+	assert.Equal(t, []Violation{
+		{Pointer: "/cities/1/area", Message: "must be of type integer, got number"},
+		{Pointer: "/cities/1/code", Message: "must match the pattern ^[A-Z]{3}$"},
+		{Pointer: "/cities/1/districts", Message: "must have at least 1 items"},
+		{Pointer: "/cities/1/name", Message: "is required"},
+		{Pointer: "/cities/2/name", Message: "must be of type string, got number"},
+	}, Validate(s, g.Definitions, document))
+
+	assert.Equal(t, []Violation{{Pointer: "/cities", Message: "is required"}}, Validate(s, g.Definitions, decode(`{}`)))
+	assert.Equal(t, []Violation{{Pointer: "", Message: "must be of type object, got array"}}, Validate(s, g.Definitions, decode(`[]`)))
+}
+
+func TestValidateAcceptsNullSlices(t *testing.T) {
+	g := NewGenerator("#/definitions/")
+	s := g.Schema(reflect.TypeOf(atlas{}))
+
+	assert.Empty(t, Validate(s, g.Definitions, decode(`{"cities": [{"name": "Paris", "districts": null}]}`)))
+	assert.Equal(t, []Violation{{Pointer: "/cities", Message: "must be of type array, got null"}}, Validate(s, g.Definitions, decode(`{"cities": null}`)))
+}
+
+func TestValidateCountsCharacters(t *testing.T) {
+	g := NewGenerator("#/definitions/")
+	s := g.Schema(reflect.TypeOf(city{}))
+
+	assert.Empty(t, Validate(s, g.Definitions, decode(`{"name": "Köln"}`)))
+	assert.Equal(t, []Violation{{Pointer: "/name", Message: "must be at most 5 characters long"}}, Validate(s, g.Definitions, decode(`{"name": "Zürich"}`)))
+	assert.Equal(t, []Violation{{Pointer: "/name", Message: "must be at least 1 characters long"}}, Validate(s, g.Definitions, decode(`{"name": ""}`)))
+}
+
+func TestValidateEscapesPointers(t *testing.T) {
+	s := &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}
+	assert.Equal(t, []Violation{{Pointer: "/a~1b~0c", Message: "must be of type string, got boolean"}}, Validate(s, nil, decode(`{"a/b~c": true}`)))
+}
+
+func TestDocument(t *testing.T) {
+	document := Document(reflect.TypeOf(atlas{}), "/schemas/atlas")
+	assert.Equal(t, Draft7, document.SchemaURI)
+	assert.Equal(t, "/schemas/atlas", document.ID)
+	assert.Equal(t, "atlas", document.Title)
+	assert.Equal(t, "object", document.Type)
+	assert.Equal(t, "#/definitions/city", document.Properties["cities"].Items.Ref)
+	assert.Contains(t, document.Definitions, "city")
+	assert.NotContains(t, document.Definitions, "atlas")
+
+	assert.Empty(t, Validate(document, document.Definitions, decode(`{"cities": [{"name": "Rome"}]}`)))
+	assert.Len(t, Validate(document, document.Definitions, decode(`{"cities": [{}]}`)), 1)
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-countries-rest-api/api/audit"
+	"go-countries-rest-api/api/auth"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/store"
+	utils "go-countries-rest-api/api/utils"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+/**
+Record a country mutation in the audit log, when one is configured.
+The actor is the authenticated principal, or the request id for anonymous requests.
+*/
+func (s *Server) recordAudit(request *http.Request, countryId string, before *models.Country, after *models.Country) {
+	if s.Audit == nil {
+		return
+	}
+	s.Audit.Record(actorOf(request), requestIdFrom(request.Context()), countryId, before, after)
+}
+
+/**
+Record the purge of a country of the trash, when an audit log is configured
+*/
+func (s *Server) recordPurge(request *http.Request, countryId string, purged *models.Country) {
+	if s.Audit == nil {
+		return
+	}
+	s.Audit.RecordPurge(actorOf(request), requestIdFrom(request.Context()), countryId, purged)
+}
+
+/**
+Add or replace a country, also returning the country it replaced for the audit log.
+Stores implementing store.Swappable read it under the lock of the write, the others
+just before the write.
+*/
+func (s *Server) swapCountry(ctx context.Context, country models.Country) (*models.Country, *models.Country, error) {
+	if swappable, ok := s.Actions.(store.Swappable); ok {
+		return swappable.SwapCountry(ctx, country)
+	}
+	previous, err := s.Actions.GetCountryById(ctx, strings.ToLower(country.Name))
+	if err != nil && !errors.Is(err, store.ErrCountryNotFound) {
+		return nil, nil, err
+	}
+	saved, err := s.Actions.AddCountry(ctx, country)
+	return previous, saved, err
+}
+
+/**
+Delete a country, returning it for the audit log, see swapCountry
+*/
+func (s *Server) takeCountry(ctx context.Context, countryId string) (*models.Country, error) {
+	if swappable, ok := s.Actions.(store.Swappable); ok {
+		return swappable.TakeCountry(ctx, countryId)
+	}
+	previous, err := s.Actions.GetCountryById(ctx, countryId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Actions.DeleteCountry(ctx, countryId); err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+/**
+Add a country only when it is missing, store.ErrCountryExists otherwise. Stores implementing
+store.Conditional check it under the lock of the write, the others just before the write.
+*/
+func (s *Server) insertCountry(ctx context.Context, country models.Country) (*models.Country, error) {
+	if conditional, ok := s.Actions.(store.Conditional); ok {
+		return conditional.InsertCountry(ctx, country)
+	}
+	_, err := s.Actions.GetCountryById(ctx, strings.ToLower(country.Name))
+	if err == nil {
+		return nil, store.ErrCountryExists
+	}
+	if !errors.Is(err, store.ErrCountryNotFound) {
+		return nil, err
+	}
+	return s.Actions.AddCountry(ctx, country)
+}
+
+/**
+Replace a country read before only when it did not change since, store.ErrCountryChanged
+otherwise, so concurrent read-modify-writes do not lose updates. See insertCountry.
+*/
+func (s *Server) updateCountryIf(ctx context.Context, expected models.Country, country models.Country) (*models.Country, error) {
+	if conditional, ok := s.Actions.(store.Conditional); ok {
+		return conditional.UpdateCountryIf(ctx, expected, country)
+	}
+	current, err := s.Actions.GetCountryById(ctx, strings.ToLower(country.Name))
+	if err != nil {
+		return nil, err
+	}
+	if !reflect.DeepEqual(*current, expected) {
+		return nil, store.ErrCountryChanged
+	}
+	return s.Actions.AddCountry(ctx, country)
+}
+
+/**
+The OnApplied hook of an upstream sync, recording every country it changed with the
+"sync:<source>" actor
+*/
+func (s *Server) AuditSync(source string) func(replacements []store.Replacement) {
+	return func(replacements []store.Replacement) {
+		if s.Audit == nil {
+			return
+		}
+		for _, replacement := range replacements {
+			s.Audit.Record("sync:"+source, "", replacement.Id, replacement.Before, replacement.After)
+		}
+	}
+}
+
+func actorOf(request *http.Request) string {
+	if principal := auth.PrincipalFrom(request.Context()); principal != nil {
+		return principal.Subject
+	}
+	if id := requestIdFrom(request.Context()); id != "" {
+		return "request:" + id
+	}
+	return "anonymous"
+}
+
+/**
+Handle requests with path "/audit" like
+GET /audit?countryId=greece&actor=alice&from=2021-01-01T00:00:00Z&to=2021-02-01T00:00:00Z
+*/
+func (s *Server) auditEntries(writer http.ResponseWriter, request *http.Request) {
+	if s.Audit == nil {
+		utils.ConstructErrorResponse(writer, "Audit log is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	parameters := request.URL.Query()
+	query := audit.Query{CountryId: parameters.Get("countryId"), Actor: parameters.Get("actor")}
+	for name, target := range map[string]*time.Time{"from": &query.From, "to": &query.To} {
+		value := parameters.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			utils.ConstructErrorResponse(writer, fmt.Sprintf("'%s' must be a RFC 3339 timestamp", name), http.StatusBadRequest)
+			return
+		}
+		*target = parsed
+	}
+
+	s.writeJson(writer, http.StatusOK, s.Audit.Query(query))
+}
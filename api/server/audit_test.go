@@ -0,0 +1,121 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/audit"
+	"go-countries-rest-api/api/auth"
+	"go-countries-rest-api/api/store"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMutationsAreAuditedWithActorAndDiff(t *testing.T) {
+	server := initializeServerWithAuth(auth.RoleNone)
+	server.Audit = audit.NewLog()
+	handler := server.handler()
+
+	addReq, _ := http.NewRequest("POST", "/countries", strings.NewReader(greeceBody))
+	addReq.Header.Add("Content-Type", "application/json")
+	addReq.Header.Add("X-API-Key", "editor-key")
+	newRequestRecorder(addReq, handler)
+
+	updateReq, _ := http.NewRequest("POST", "/countries", strings.NewReader(strings.Replace(greeceBody, "Athens", "Nafplio", 1)))
+	updateReq.Header.Add("Content-Type", "application/json")
+	updateReq.Header.Add("X-API-Key", "editor-key")
+	updateReq.Header.Add("X-Request-ID", "update-capital")
+	newRequestRecorder(updateReq, handler)
+
+	deleteReq, _ := http.NewRequest("DELETE", "/countries/greece", nil)
+	deleteReq.Header.Add("X-API-Key", "editor-key")
+	newRequestRecorder(deleteReq, handler)
+
+	entries := server.Audit.Query(audit.Query{})
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, audit.Add, entries[0].Operation)
+	assert.Equal(t, "sync-job", entries[0].Actor)
+	assert.Equal(t, audit.Update, entries[1].Operation)
+	assert.Equal(t, "update-capital", entries[1].RequestId)
+	assert.Equal(t, []audit.Change{{Field: "capital", Before: "Athens", After: "Nafplio"}}, entries[1].Changes)
+	assert.Equal(t, audit.Delete, entries[2].Operation)
+	assert.Equal(t, "greece", entries[2].CountryId)
+}
+
+func TestStoresWithoutSwapAreAuditedToo(t *testing.T) {
+	server := initializeServer()
+	server.Actions = struct{ store.Actions }{server.Actions}
+	server.Audit = audit.NewLog()
+	handler := server.handler()
+	addCountry(handler, greeceBody)
+	addCountry(handler, strings.Replace(greeceBody, "Athens", "Nafplio", 1))
+	deleteReq, _ := http.NewRequest("DELETE", "/countries/greece", nil)
+	newRequestRecorder(deleteReq, handler)
+
+	entries := server.Audit.Query(audit.Query{})
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, []audit.Operation{audit.Add, audit.Update, audit.Delete}, []audit.Operation{entries[0].Operation, entries[1].Operation, entries[2].Operation})
+	assert.Equal(t, []audit.Change{{Field: "capital", Before: "Athens", After: "Nafplio"}}, entries[1].Changes)
+}
+
+func TestDeletingMissingCountryIsNotAudited(t *testing.T) {
+	server := initializeServer()
+	server.Audit = audit.NewLog()
+	deleteReq, _ := http.NewRequest("DELETE", "/countries/atlantis", nil)
+	newRequestRecorder(deleteReq, server.handler())
+	assert.Equal(t, 0, len(server.Audit.Query(audit.Query{})))
+}
+
+func TestAnonymousActorIsTheRequestId(t *testing.T) {
+	server := initializeServer()
+	server.Audit = audit.NewLog()
+	addReq, _ := http.NewRequest("POST", "/countries", strings.NewReader(greeceBody))
+	addReq.Header.Add("Content-Type", "application/json")
+	addReqRecorder := newRequestRecorder(addReq, server.handler())
+
+	requestId := addReqRecorder.Header().Get("X-Request-ID")
+	entries := server.Audit.Query(audit.Query{})
+	assert.Equal(t, 16, len(requestId))
+	assert.Equal(t, "request:"+requestId, entries[0].Actor)
+}
+
+func TestGetAuditEntriesWithFilters(t *testing.T) {
+	server := initializeServer()
+	server.Audit = audit.NewLog()
+	handler := server.handler()
+	addCountry(handler, greeceBody)
+	addCountry(handler, strings.Replace(greeceBody, "Greece", "Cyprus", 1))
+
+	auditReq, _ := http.NewRequest("GET", "/audit?countryId=cyprus&from=2000-01-01T00:00:00Z", nil)
+	auditReqRecorder := newRequestRecorder(auditReq, handler)
+	var entries []audit.Entry
+	json.Unmarshal(auditReqRecorder.Body.Bytes(), &entries)
+	assert.Equal(t, http.StatusOK, auditReqRecorder.Code)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "cyprus", entries[0].CountryId)
+
+	malformedReq, _ := http.NewRequest("GET", "/audit?to=yesterday", nil)
+	malformedReqRecorder := newRequestRecorder(malformedReq, handler)
+	assert.Equal(t, http.StatusBadRequest, malformedReqRecorder.Code)
+	assert.Equal(t, "'to' must be a RFC 3339 timestamp", malformedReqRecorder.Body.String())
+}
+
+func TestAuditRequiresAdmin(t *testing.T) {
+	server := initializeServerWithAuth(auth.RoleReader)
+	server.Audit = audit.NewLog()
+	auditReq, _ := http.NewRequest("GET", "/audit", nil)
+	assert.Equal(t, http.StatusForbidden, newRequestRecorder(auditReq, server.handler()).Code)
+}
+
+func TestRequestIdIsEchoedOrGenerated(t *testing.T) {
+	handler := initializeServer().handler()
+	withIdReq, _ := http.NewRequest("GET", "/countries", nil)
+	withIdReq.Header.Add("X-Request-ID", "abc-123")
+	invalidIdReq, _ := http.NewRequest("GET", "/countries", nil)
+	invalidIdReq.Header.Add("X-Request-ID", "has spaces")
+
+	assert.Equal(t, "abc-123", newRequestRecorder(withIdReq, handler).Header().Get("X-Request-ID"))
+	generated := newRequestRecorder(invalidIdReq, handler).Header().Get("X-Request-ID")
+	assert.NotEqual(t, "has spaces", generated)
+	assert.Equal(t, 16, len(generated))
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"go-countries-rest-api/api/auth"
+	utils "go-countries-rest-api/api/utils"
+	"net/http"
+	"strings"
+)
+
+/**
+Paths reserved to admins. Webhooks expose partner endpoints, the audit log
+tells who changed what and /admin operates the whole store, whatever the verb.
+*/
+var adminPaths = []string{"/webhooks", "/audit", "/admin"}
+
+/**
+Paths open to anyone, the documentation is useful before having credentials
+*/
+var publicPaths = []string{"/openapi.json", "/schemas"}
+
+/**
+Paths accepting reads and writes with POST, they check the role needed by the writes themselves
+*/
+var readerPaths = []string{"/graphql", "/rpc"}
+
+/**
+Reading is allowed to readers, every other verb mutates the store so an editor is needed.
+*/
+func requiredRole(request *http.Request) auth.Role {
+	for _, path := range publicPaths {
+		if underPath(request, path) {
+			return auth.RoleNone
+		}
+	}
+	for _, path := range adminPaths {
+		if underPath(request, path) {
+			return auth.RoleAdmin
+		}
+	}
+
+	if containsString(readerPaths, request.URL.Path) {
+		return auth.RoleReader
+	}
+
+	switch request.Method {
+	case "GET", "HEAD", "OPTIONS":
+		return auth.RoleReader
+	default:
+		return auth.RoleEditor
+	}
+}
+
+/**
+Middleware resolving the principal of the request and checking it against the role
+required for the request. Responds with 401 when the credentials are missing or wrong,
+and with 403 when the principal is known but its role is not enough.
+*/
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		request, principal, err := s.authenticateOnce(request)
+		if err == auth.ErrMissingCredentials && requiredRole(request) == auth.RoleNone {
+			next.ServeHTTP(writer, request)
+			return
+		}
+		if err != nil {
+			writer.Header().Set("WWW-Authenticate", `Bearer realm="countries", ApiKey realm="countries"`)
+			utils.ConstructProblemResponse(writer, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		required := requiredRole(request)
+		if !principal.Role.Allows(required) {
+			detail := fmt.Sprintf("role '%s' is required, but '%s' has role '%s'", required, principal.Subject, principal.Role)
+			utils.ConstructProblemResponse(writer, http.StatusForbidden, detail)
+			return
+		}
+
+		next.ServeHTTP(writer, request.WithContext(auth.WithPrincipal(request.Context(), principal)))
+	})
+}
+
+type authenticationKey struct{}
+
+type authentication struct {
+	principal *auth.Principal
+	err       error
+}
+
+/**
+Authenticate the request, or reuse the result an earlier middleware stored on it. Verifying
+a token is not free, and the rate limits already need the principal.
+*/
+func (s *Server) authenticateOnce(request *http.Request) (*http.Request, *auth.Principal, error) {
+	if result, ok := request.Context().Value(authenticationKey{}).(authentication); ok {
+		return request, result.principal, result.err
+	}
+	principal, err := s.Auth.Authenticate(request)
+	ctx := context.WithValue(request.Context(), authenticationKey{}, authentication{principal, err})
+	return request.WithContext(ctx), principal, err
+}
+
+/**
+Whether the path of the request is path or one of its sub paths
+*/
+func underPath(request *http.Request, path string) bool {
+	return request.URL.Path == path || strings.HasPrefix(request.URL.Path, path+"/")
+}
+
+/**
+The readerPaths only need a reader, the handlers check the writes with this:
+an editor is needed when authentication is enabled.
+*/
+func (s *Server) allowsWrites(request *http.Request) bool {
+	if s.Auth == nil {
+		return true
+	}
+	principal := auth.PrincipalFrom(request.Context())
+	return principal != nil && principal.Role.Allows(auth.RoleEditor)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
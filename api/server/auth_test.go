@@ -0,0 +1,116 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/auth"
+	utils "go-countries-rest-api/api/utils"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestWithoutCredentialsIsUnauthorized(t *testing.T) {
+	handler := initializeServerWithAuth(auth.RoleNone).handler()
+	getAllReq, _ := http.NewRequest("GET", "/countries", nil)
+	getAllReqRecorder := newRequestRecorder(getAllReq, handler)
+
+	problem := constructProblemFromJson(getAllReqRecorder.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, getAllReqRecorder.Code)
+	assert.Equal(t, "application/problem+json", getAllReqRecorder.Header().Get("content-type"))
+	assert.NotEmpty(t, getAllReqRecorder.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, http.StatusUnauthorized, problem.Status)
+	assert.Equal(t, "Missing credentials.", problem.Detail)
+}
+
+func TestAnonymousReaderCanGetButCanNotDelete(t *testing.T) {
+	handler := initializeServerWithAuth(auth.RoleReader).handler()
+	getAllReq, _ := http.NewRequest("GET", "/countries", nil)
+	getAllReqRecorder := newRequestRecorder(getAllReq, handler)
+	assert.Equal(t, http.StatusOK, getAllReqRecorder.Code)
+
+	deleteReq, _ := http.NewRequest("DELETE", "/countries/greece", nil)
+	deleteReqRecorder := newRequestRecorder(deleteReq, handler)
+	assert.Equal(t, http.StatusForbidden, deleteReqRecorder.Code)
+}
+
+func TestWrongAPIKeyIsUnauthorized(t *testing.T) {
+	handler := initializeServerWithAuth(auth.RoleReader).handler()
+	getAllReq, _ := http.NewRequest("GET", "/countries", nil)
+	getAllReq.Header.Add("X-API-Key", "wrong")
+	getAllReqRecorder := newRequestRecorder(getAllReq, handler)
+
+	problem := constructProblemFromJson(getAllReqRecorder.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, getAllReqRecorder.Code)
+	assert.Equal(t, "Invalid credentials.", problem.Detail)
+}
+
+func TestReaderAPIKeyCanNotAddCountry(t *testing.T) {
+	handler := initializeServerWithAuth(auth.RoleNone).handler()
+	addReq, _ := http.NewRequest("POST", "/countries", strings.NewReader(greeceBody))
+	addReq.Header.Add("Content-Type", "application/json")
+	addReq.Header.Add("X-API-Key", "reader-key")
+	addReqRecorder := newRequestRecorder(addReq, handler)
+
+	problem := constructProblemFromJson(addReqRecorder.Body.String())
+	assert.Equal(t, http.StatusForbidden, addReqRecorder.Code)
+	assert.Equal(t, "role 'editor' is required, but 'dashboard' has role 'reader'", problem.Detail)
+}
+
+func TestEditorAPIKeyCanAddAndDeleteCountry(t *testing.T) {
+	handler := initializeServerWithAuth(auth.RoleNone).handler()
+	addReq, _ := http.NewRequest("POST", "/countries", strings.NewReader(greeceBody))
+	addReq.Header.Add("Content-Type", "application/json")
+	addReq.Header.Add("Authorization", "ApiKey editor-key")
+	addReqRecorder := newRequestRecorder(addReq, handler)
+	assert.Equal(t, http.StatusOK, addReqRecorder.Code)
+
+	deleteReq, _ := http.NewRequest("DELETE", "/countries/greece", nil)
+	deleteReq.Header.Add("X-API-Key", "editor-key")
+	deleteReqRecorder := newRequestRecorder(deleteReq, handler)
+	assert.Equal(t, http.StatusOK, deleteReqRecorder.Code)
+}
+
+func TestBearerTokenWithEditorRoleCanAddCountry(t *testing.T) {
+	handler := initializeServerWithAuth(auth.RoleNone).handler()
+	token, _ := auth.SignToken(auth.Claims{Subject: "sync-job", Role: "editor", ExpiresAt: time.Now().Add(time.Hour).Unix()}, []byte("secret"))
+	addReq, _ := http.NewRequest("POST", "/countries", strings.NewReader(greeceBody))
+	addReq.Header.Add("Content-Type", "application/json")
+	addReq.Header.Add("Authorization", "Bearer "+token)
+	addReqRecorder := newRequestRecorder(addReq, handler)
+	assert.Equal(t, http.StatusOK, addReqRecorder.Code)
+}
+
+func TestExpiredBearerTokenIsUnauthorized(t *testing.T) {
+	handler := initializeServerWithAuth(auth.RoleNone).handler()
+	token, _ := auth.SignToken(auth.Claims{Subject: "sync-job", Role: "admin", ExpiresAt: time.Now().Add(-time.Hour).Unix()}, []byte("secret"))
+	getAllReq, _ := http.NewRequest("GET", "/countries", nil)
+	getAllReq.Header.Add("Authorization", "Bearer "+token)
+	getAllReqRecorder := newRequestRecorder(getAllReq, handler)
+
+	problem := constructProblemFromJson(getAllReqRecorder.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, getAllReqRecorder.Code)
+	assert.Equal(t, "Token has expired.", problem.Detail)
+}
+
+const greeceBody = "{\"name\": \"Greece\",\"alpha2Code\": \"GR\",\"capital\": \"Athens\",\"currencies\": [{\"code\": \"EUR\",\"name\": \"Euro\",\"symbol\": \"E\"}]}"
+
+func initializeServerWithAuth(anonymousRole auth.Role) *Server {
+	server := initializeServer()
+	server.Auth = &auth.Authenticator{
+		APIKeys: map[string]auth.Principal{
+			"reader-key": {Subject: "dashboard", Role: auth.RoleReader},
+			"editor-key": {Subject: "sync-job", Role: auth.RoleEditor},
+		},
+		JWTSecret:     []byte("secret"),
+		AnonymousRole: anonymousRole,
+	}
+	return server
+}
+
+func constructProblemFromJson(jsonData string) *utils.Problem {
+	problem := &utils.Problem{}
+	json.Unmarshal([]byte(jsonData), problem)
+	return problem
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"fmt"
+	"go-countries-rest-api/api/store"
+	"net/http"
+	"time"
+)
+
+/**
+Set the Cache-Control and Last-Modified headers of a GET response, and answer with
+304 Not Modified when the store was not written since the If-Modified-Since date of the request.
+Returns true when the 304 response was written.
+*/
+func (s *Server) notModified(writer http.ResponseWriter, request *http.Request) bool {
+	writer.Header().Set("Cache-Control", s.cacheControl())
+
+	timestamped, ok := s.Actions.(store.Timestamped)
+	if !ok {
+		return false
+	}
+
+	// HTTP dates have a precision of one second
+	lastModified := timestamped.LastModified().UTC().Truncate(time.Second)
+	writer.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+
+	since, err := http.ParseTime(request.Header.Get("If-Modified-Since"))
+	if err != nil || lastModified.After(since) {
+		return false
+	}
+
+	writer.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+func (s *Server) cacheControl() string {
+	if s.CacheMaxAge <= 0 {
+		return "no-cache"
+	}
+
+	// responses depending on credentials must not be stored by shared caches
+	visibility := "public"
+	if s.Auth != nil {
+		visibility = "private"
+	}
+	return fmt.Sprintf("%s, max-age=%d", visibility, int(s.CacheMaxAge.Seconds()))
+}
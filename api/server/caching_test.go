@@ -0,0 +1,89 @@
+package server
+
+import (
+	"compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/compression"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetAllCountriesHasCachingHeaders(t *testing.T) {
+	server := initializeServer()
+	server.CacheMaxAge = time.Minute
+	getAllReq, _ := http.NewRequest("GET", "/countries", nil)
+	getAllReqRecorder := newRequestRecorder(getAllReq, server.handler())
+
+	lastModified, err := http.ParseTime(getAllReqRecorder.Header().Get("Last-Modified"))
+	assert.Equal(t, http.StatusOK, getAllReqRecorder.Code)
+	assert.Equal(t, "public, max-age=60", getAllReqRecorder.Header().Get("Cache-Control"))
+	assert.Nil(t, err)
+	assert.WithinDuration(t, time.Now(), lastModified, 2*time.Second)
+}
+
+func TestGetCountryNotModifiedSinceLastWrite(t *testing.T) {
+	mux := initializeHandlers()
+	addCountry(mux, greeceBody)
+
+	getGreeceReq, _ := http.NewRequest("GET", "/countries/greece", nil)
+	getGreeceReq.Header.Add("If-Modified-Since", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	getGreeceReqRecorder := newRequestRecorder(getGreeceReq, mux)
+
+	assert.Equal(t, http.StatusNotModified, getGreeceReqRecorder.Code)
+	assert.Equal(t, "no-cache", getGreeceReqRecorder.Header().Get("Cache-Control"))
+	assert.Equal(t, "", getGreeceReqRecorder.Body.String())
+}
+
+func TestGetAllCountriesModifiedAfterWrite(t *testing.T) {
+	mux := initializeHandlers()
+	since := time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)
+	addCountry(mux, greeceBody)
+
+	getAllReq, _ := http.NewRequest("GET", "/countries", nil)
+	getAllReq.Header.Add("If-Modified-Since", since)
+	getAllReqRecorder := newRequestRecorder(getAllReq, mux)
+
+	assert.Equal(t, http.StatusOK, getAllReqRecorder.Code)
+	assert.Equal(t, 1, len(*constructCountriesFromJson(getAllReqRecorder.Body.String())))
+}
+
+func TestGetAllCountriesIsCompressedAboveThreshold(t *testing.T) {
+	server := initializeServer()
+	server.Compression = &compression.Config{MinSize: 100}
+	handler := server.handler()
+	addCountry(handler, greeceBody)
+	addCountry(handler, strings.Replace(greeceBody, "Greece", "Cyprus", 1))
+
+	getAllReq, _ := http.NewRequest("GET", "/countries", nil)
+	getAllReq.Header.Add("Accept-Encoding", "gzip")
+	getAllReqRecorder := newRequestRecorder(getAllReq, handler)
+
+	reader, err := gzip.NewReader(getAllReqRecorder.Body)
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(reader)
+	assert.Equal(t, http.StatusOK, getAllReqRecorder.Code)
+	assert.Equal(t, "gzip", getAllReqRecorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, "application/json", getAllReqRecorder.Header().Get("Content-Type"))
+	assert.Contains(t, getAllReqRecorder.Header()["Vary"], "Accept-Encoding")
+	assert.Equal(t, 2, len(*constructCountriesFromJson(string(body))))
+}
+
+func TestSmallResponseIsNotCompressed(t *testing.T) {
+	server := initializeServer()
+	server.Compression = &compression.Config{MinSize: 100}
+	getAllReq, _ := http.NewRequest("GET", "/countries", nil)
+	getAllReq.Header.Add("Accept-Encoding", "gzip")
+	getAllReqRecorder := newRequestRecorder(getAllReq, server.handler())
+
+	assert.Equal(t, "", getAllReqRecorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, "[]", getAllReqRecorder.Body.String())
+}
+
+func addCountry(handler http.Handler, body string) {
+	addReq, _ := http.NewRequest("POST", "/countries", strings.NewReader(body))
+	addReq.Header.Add("Content-Type", "application/json")
+	newRequestRecorder(addReq, handler)
+}
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"go-countries-rest-api/api/compression"
+	"net/http"
+)
+
+/**
+Middleware compressing responses with gzip or deflate, when the client accepts it
+and the response is at least Compression.MinSize bytes long
+*/
+func (s *Server) compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := compression.Negotiate(request.Header.Get("Accept-Encoding"))
+		if encoding == "" || request.Method == "HEAD" {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		compressed := compression.NewWriter(writer, encoding, *s.Compression)
+		defer compressed.Close()
+		next.ServeHTTP(compressed, request)
+	})
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/store"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestDeadlineReachesTheStore(t *testing.T) {
+	mux := initializeHandlers()
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	getAllReq, _ := http.NewRequest("GET", "/countries", nil)
+	getAllReqRecorder := newRequestRecorder(getAllReq.WithContext(ctx), mux)
+	assert.Equal(t, http.StatusGatewayTimeout, getAllReqRecorder.Code)
+	assert.Equal(t, "Store did not respond in time", getAllReqRecorder.Body.String())
+}
+
+func TestCancelledRequestDoesNotWrite(t *testing.T) {
+	mux := initializeHandlers()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	addReq, _ := http.NewRequest("POST", "/countries", strings.NewReader(greeceBody))
+	addReq.Header.Add("Content-Type", "application/json")
+	assert.Equal(t, http.StatusServiceUnavailable, newRequestRecorder(addReq.WithContext(ctx), mux).Code)
+
+	getReq, _ := http.NewRequest("GET", "/countries/greece", nil)
+	assert.Equal(t, http.StatusNotFound, newRequestRecorder(getReq, mux).Code)
+}
+
+/**
+A store written before the contexts, failing its reads with its own errors
+*/
+type legacyStore struct{}
+
+func (legacyStore) AddCountry(country models.Country) (*models.Country, error) {
+	return &country, nil
+}
+
+func (legacyStore) DeleteCountry(countryId string) error {
+	return nil
+}
+
+func (legacyStore) GetCountryById(countryId string) (*models.Country, error) {
+	if countryId == "broken" {
+		return nil, errors.New("read tcp 10.0.0.2:5432: connection reset by peer")
+	}
+	return nil, errors.New("Country not found.")
+}
+
+func (legacyStore) GetAllCountries() (*[]models.Country, error) {
+	return nil, fmt.Errorf("listing countries: %w", context.DeadlineExceeded)
+}
+
+func (legacyStore) GetRandomCountryId() (*string, error) {
+	return nil, errors.New("No countries available to choose randomly.")
+}
+
+func TestLegacyStoreErrorsKeepTheirStatus(t *testing.T) {
+	server := initializeServer()
+	server.Actions = store.FromLegacy(legacyStore{})
+	handler := server.handler()
+
+	response := newRequestRecorder(httptest.NewRequest("GET", "/countries/greece", nil), handler)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.Equal(t, "Country not found", response.Body.String())
+
+	response = newRequestRecorder(httptest.NewRequest("GET", "/countries/broken", nil), handler)
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+	assert.Equal(t, "read tcp 10.0.0.2:5432: connection reset by peer", response.Body.String())
+
+	response = newRequestRecorder(httptest.NewRequest("GET", "/countries/random", nil), handler)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	response = newRequestRecorder(httptest.NewRequest("GET", "/countries", nil), handler)
+	assert.Equal(t, http.StatusGatewayTimeout, response.Code)
+
+	response = newRequestRecorder(rpcRequest(`{"jsonrpc": "2.0", "method": "GetCountryById", "params": ["greece"], "id": 1}`), handler)
+	assert.Contains(t, response.Body.String(), `"code":-32001`)
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	utils "go-countries-rest-api/api/utils"
+)
+
+/**
+Middleware adding the CORS headers to responses for allowed origins, and answering
+preflight requests (OPTIONS with Access-Control-Request-Method) on every route
+before they reach authentication or rate limiting.
+*/
+func (s *Server) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		origin := request.Header.Get("Origin")
+		preflight := request.Method == "OPTIONS" && request.Header.Get("Access-Control-Request-Method") != ""
+
+		writer.Header().Add("Vary", "Origin")
+		if preflight {
+			writer.Header().Add("Vary", "Access-Control-Request-Method")
+			writer.Header().Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if origin == "" {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		if !s.CORS.AllowsOrigin(origin) {
+			if preflight {
+				utils.ConstructProblemResponse(writer, http.StatusForbidden, "origin '"+origin+"' is not allowed")
+				return
+			}
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		writer.Header().Set("Access-Control-Allow-Origin", s.CORS.AllowOriginValue(origin))
+		if s.CORS.AllowCredentials {
+			writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if len(s.CORS.ExposedHeaders) > 0 {
+				writer.Header().Set("Access-Control-Expose-Headers", strings.Join(s.CORS.ExposedHeaders, ", "))
+			}
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		requestedMethod := request.Header.Get("Access-Control-Request-Method")
+		requestedHeaders := request.Header.Get("Access-Control-Request-Headers")
+		if !s.CORS.AllowsMethod(requestedMethod) || !s.CORS.AllowsHeaders(requestedHeaders) {
+			utils.ConstructProblemResponse(writer, http.StatusForbidden, "method or headers are not allowed")
+			return
+		}
+
+		writer.Header().Set("Access-Control-Allow-Methods", strings.Join(s.CORS.AllowedMethods, ", "))
+		if requestedHeaders != "" {
+			// echo the requested headers back, which also covers the "*" configuration
+			writer.Header().Set("Access-Control-Allow-Headers", requestedHeaders)
+		}
+		if s.CORS.MaxAge > 0 {
+			writer.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(s.CORS.MaxAge.Seconds())))
+		}
+		writer.WriteHeader(http.StatusNoContent)
+	})
+}
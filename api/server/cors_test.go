@@ -0,0 +1,94 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/auth"
+	"go-countries-rest-api/api/cors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPreflightRequestIsAnsweredOnEveryRoute(t *testing.T) {
+	server := initializeServerWithAuth(auth.RoleNone)
+	server.CORS = cors.NewConfig("https://app.example.com")
+	server.CORS.MaxAge = 10 * time.Minute
+	handler := server.handler()
+
+	for _, path := range []string{"/countries", "/countries/greece"} {
+		preflightReq, _ := http.NewRequest("OPTIONS", path, nil)
+		preflightReq.Header.Add("Origin", "https://app.example.com")
+		preflightReq.Header.Add("Access-Control-Request-Method", "DELETE")
+		preflightReq.Header.Add("Access-Control-Request-Headers", "x-api-key")
+		preflightReqRecorder := newRequestRecorder(preflightReq, handler)
+
+		assert.Equal(t, http.StatusNoContent, preflightReqRecorder.Code)
+		assert.Equal(t, "https://app.example.com", preflightReqRecorder.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST, PUT, PATCH, DELETE, OPTIONS", preflightReqRecorder.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "x-api-key", preflightReqRecorder.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", preflightReqRecorder.Header().Get("Access-Control-Max-Age"))
+		assert.Contains(t, preflightReqRecorder.Header()["Vary"], "Origin")
+	}
+}
+
+func TestPreflightRequestFromUnknownOriginIsForbidden(t *testing.T) {
+	server := initializeServer()
+	server.CORS = cors.NewConfig("https://app.example.com")
+	preflightReq, _ := http.NewRequest("OPTIONS", "/countries", nil)
+	preflightReq.Header.Add("Origin", "https://evil.example.com")
+	preflightReq.Header.Add("Access-Control-Request-Method", "GET")
+	preflightReqRecorder := newRequestRecorder(preflightReq, server.handler())
+
+	assert.Equal(t, http.StatusForbidden, preflightReqRecorder.Code)
+	assert.Equal(t, "", preflightReqRecorder.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestPreflightRequestWithNotAllowedHeaderIsForbidden(t *testing.T) {
+	server := initializeServer()
+	server.CORS = cors.NewConfig("https://app.example.com")
+	preflightReq, _ := http.NewRequest("OPTIONS", "/countries", nil)
+	preflightReq.Header.Add("Origin", "https://app.example.com")
+	preflightReq.Header.Add("Access-Control-Request-Method", "POST")
+	preflightReq.Header.Add("Access-Control-Request-Headers", "x-custom")
+	preflightReqRecorder := newRequestRecorder(preflightReq, server.handler())
+
+	assert.Equal(t, http.StatusForbidden, preflightReqRecorder.Code)
+}
+
+func TestCorsHeadersOnActualRequestWithCredentials(t *testing.T) {
+	server := initializeServer()
+	server.CORS = cors.NewConfig("*")
+	server.CORS.AllowCredentials = true
+	getAllReq, _ := http.NewRequest("GET", "/countries", nil)
+	getAllReq.Header.Add("Origin", "https://app.example.com")
+	getAllReqRecorder := newRequestRecorder(getAllReq, server.handler())
+
+	assert.Equal(t, http.StatusOK, getAllReqRecorder.Code)
+	assert.Equal(t, "https://app.example.com", getAllReqRecorder.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", getAllReqRecorder.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Contains(t, getAllReqRecorder.Header().Get("Access-Control-Expose-Headers"), "Location")
+}
+
+func TestUnauthorizedResponseCarriesCorsHeaders(t *testing.T) {
+	server := initializeServerWithAuth(auth.RoleNone)
+	server.CORS = cors.NewConfig("https://app.example.com")
+	getAllReq, _ := http.NewRequest("GET", "/countries", nil)
+	getAllReq.Header.Add("Origin", "https://app.example.com")
+	getAllReqRecorder := newRequestRecorder(getAllReq, server.handler())
+
+	assert.Equal(t, http.StatusUnauthorized, getAllReqRecorder.Code)
+	assert.Equal(t, "https://app.example.com", getAllReqRecorder.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestOptionsVerbIsSupportedWithoutCors(t *testing.T) {
+	mux := initializeHandlers()
+	countriesReq, _ := http.NewRequest("OPTIONS", "/countries", nil)
+	countryReq, _ := http.NewRequest("OPTIONS", "/countries/greece", nil)
+	countriesReqRecorder := newRequestRecorder(countriesReq, mux)
+	countryReqRecorder := newRequestRecorder(countryReq, mux)
+
+	assert.Equal(t, http.StatusNoContent, countriesReqRecorder.Code)
+	assert.Equal(t, "GET, POST, OPTIONS", countriesReqRecorder.Header().Get("Allow"))
+	assert.Equal(t, http.StatusNoContent, countryReqRecorder.Code)
+	assert.Equal(t, "GET, DELETE, PUT, PATCH, OPTIONS", countryReqRecorder.Header().Get("Allow"))
+}
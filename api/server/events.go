@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-countries-rest-api/api/events"
+	"go-countries-rest-api/api/store"
+	utils "go-countries-rest-api/api/utils"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// how often a comment is sent on idle streams, so proxies do not close them
+var sseKeepAlive = 15 * time.Second
+
+const sseBuffer = 64
+
+/**
+Handle requests with path "/countries/events" like
+GET /countries/events
+Streams the country changes as Server-Sent Events. A client reconnecting with the
+Last-Event-ID header (or the lastEventId query parameter) first receives the events it missed.
+When they are no longer in the log, a "reset" event tells the client to fetch the countries again.
+*/
+func (s *Server) streamEvents(writer http.ResponseWriter, request *http.Request) {
+	observable, ok := s.Actions.(store.Observable)
+	if !ok {
+		utils.ConstructErrorResponse(writer, "Change feed is not supported by the store", http.StatusNotImplemented)
+		return
+	}
+
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		utils.ConstructErrorResponse(writer, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	lastEventId := request.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = request.URL.Query().Get("lastEventId")
+	}
+
+	var subscription *events.Subscription
+	var missed []events.Event
+	complete := true
+	if lastEventId == "" {
+		subscription = observable.Events().Subscribe(sseBuffer)
+	} else {
+		lastId, err := strconv.ParseUint(lastEventId, 10, 64)
+		if err != nil {
+			utils.ConstructErrorResponse(writer, fmt.Sprintf("Malformed event id '%s'", lastEventId), http.StatusBadRequest)
+			return
+		}
+		subscription, missed, complete = observable.Events().SubscribeSince(lastId, sseBuffer)
+	}
+	defer subscription.Close()
+
+	writer.Header().Set("content-type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.WriteHeader(http.StatusOK)
+	fmt.Fprint(writer, "retry: 3000\n\n")
+	if !complete {
+		fmt.Fprint(writer, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range missed {
+		writeEvent(writer, event)
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(writer, ": keepalive\n\n")
+			flusher.Flush()
+		case event, open := <-subscription.Events():
+			if !open {
+				// the client fell behind, it reconnects with its Last-Event-ID
+				return
+			}
+			writeEvent(writer, event)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(writer http.ResponseWriter, event events.Event) {
+	jsonBytes, _ := json.Marshal(event)
+	fmt.Fprintf(writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, jsonBytes)
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"bufio"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEventsAreStreamedForMutations(t *testing.T) {
+	server := initializeServer()
+	httpServer := httptest.NewServer(server.handler())
+	defer httpServer.Close()
+
+	response, err := http.Get(httpServer.URL + "/countries/events")
+	assert.Nil(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(response.Body)
+	assert.Equal(t, []string{"retry: 3000"}, readServerSentEvent(reader))
+
+	addCountry(server.Mux, greeceBody)
+	addCountry(server.Mux, greeceBody)
+	deleteReq, _ := http.NewRequest("DELETE", "/countries/greece", nil)
+	newRequestRecorder(deleteReq, server.Mux)
+
+	created := readServerSentEvent(reader)
+	updated := readServerSentEvent(reader)
+	deleted := readServerSentEvent(reader)
+	assert.Equal(t, "id: 1", created[0])
+	assert.Equal(t, "event: created", created[1])
+	assert.Contains(t, created[2], "\"countryId\":\"greece\"")
+	assert.Contains(t, created[2], "\"capital\":\"Athens\"")
+	assert.Equal(t, "event: updated", updated[1])
+	assert.Equal(t, "id: 3", deleted[0])
+	assert.Equal(t, "event: deleted", deleted[1])
+}
+
+func TestEventsAreResumedFromLastEventId(t *testing.T) {
+	server := initializeServer()
+	httpServer := httptest.NewServer(server.handler())
+	defer httpServer.Close()
+
+	addCountry(server.Mux, greeceBody)
+	addCountry(server.Mux, strings.Replace(greeceBody, "Greece", "Cyprus", 1))
+
+	request, _ := http.NewRequest("GET", httpServer.URL+"/countries/events", nil)
+	request.Header.Add("Last-Event-ID", "1")
+	response, err := http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+	defer response.Body.Close()
+
+	reader := bufio.NewReader(response.Body)
+	readServerSentEvent(reader)
+	missed := readServerSentEvent(reader)
+	assert.Equal(t, "id: 2", missed[0])
+	assert.Contains(t, missed[2], "\"countryId\":\"cyprus\"")
+}
+
+func TestEventsWithMalformedLastEventId(t *testing.T) {
+	mux := initializeHandlers()
+	eventsReq, _ := http.NewRequest("GET", "/countries/events?lastEventId=abc", nil)
+	eventsReqRecorder := newRequestRecorder(eventsReq, mux)
+
+	assert.Equal(t, http.StatusBadRequest, eventsReqRecorder.Code)
+	assert.Equal(t, "Malformed event id 'abc'", eventsReqRecorder.Body.String())
+}
+
+// reads the lines of the next event, up to the blank line ending it
+func readServerSentEvent(reader *bufio.Reader) []string {
+	lines := []string{}
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\n")
+		if err != nil || line == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
@@ -0,0 +1,331 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-countries-rest-api/api/graphql"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/store"
+	utils "go-countries-rest-api/api/utils"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+/**
+A page of the countries query, following the Relay connection specification
+*/
+type CountryConnection struct {
+	TotalCount int              `json:"totalCount"`
+	Edges      []CountryEdge    `json:"edges"`
+	Nodes      []models.Country `json:"nodes"`
+	PageInfo   PageInfo         `json:"pageInfo"`
+}
+
+type CountryEdge struct {
+	Cursor string         `json:"cursor"`
+	Node   models.Country `json:"node"`
+}
+
+type PageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+/**
+Handle requests like
+POST /graphql
+{"query": "{ countries(filter: {region: \"Europe\"}, first: 10) { edges { node { name currencies { code } } } } }"}
+Queries:
+* country(id: String!): Country, null when missing
+* countries(filter: CountryFilter, first: Int, after: String): CountryConnection, sorted by id
+* currencies: [Currency], every currency used by a country once, sorted by code
+Mutations, which need an editor when authentication is enabled:
+* addCountry(country: CountryInput!): Country, fails when the country exists
+* updateCountry(id: String!, country: CountryInput!): Country, merging the given fields like PATCH does, fails when the country changes meanwhile
+* deleteCountry(id: String!): Country, the deleted country
+Responds 400 when the query can not be executed at all, 200 with the data and the
+errors of the fields otherwise.
+*/
+func (s *Server) postGraphQL(writer http.ResponseWriter, request *http.Request) {
+	bodyBytes, err := ioutil.ReadAll(request.Body)
+	defer request.Body.Close()
+	if err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ct := request.Header.Get("content-type")
+	if ct != "application/json" {
+		utils.ConstructErrorResponse(writer, fmt.Sprintf("need content-type 'application/json', but got '%s'", ct), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var body graphql.Request
+	err = json.Unmarshal(bodyBytes, &body)
+	if err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(body.Query) == "" {
+		utils.ConstructErrorResponse(writer, "A query is required", http.StatusBadRequest)
+		return
+	}
+
+	response := s.graphQLSchema(request).Execute(request.Context(), body)
+	statusCode := http.StatusOK
+	if response.Data == nil {
+		statusCode = http.StatusBadRequest
+	}
+	s.writeJson(writer, statusCode, response)
+}
+
+/**
+The schema resolving against the store, bound to the request for the audit log
+*/
+func (s *Server) graphQLSchema(request *http.Request) *graphql.Schema {
+	return &graphql.Schema{
+		Query: map[string]graphql.Resolver{
+			"country":    s.resolveCountry,
+			"countries":  s.resolveCountries,
+			"currencies": s.resolveCurrencies,
+		},
+		Mutation: map[string]graphql.Resolver{
+			"addCountry": func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+				return s.resolveAddCountry(request, arguments)
+			},
+			"updateCountry": func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+				return s.resolveUpdateCountry(request, arguments)
+			},
+			"deleteCountry": func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+				return s.resolveDeleteCountry(request, arguments)
+			},
+		},
+	}
+}
+
+func (s *Server) resolveCountry(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	id, err := stringArgument(arguments, "id")
+	if err != nil {
+		return nil, err
+	}
+	country, err := s.Actions.GetCountryById(ctx, id)
+	if errors.Is(err, store.ErrCountryNotFound) {
+		return nil, nil
+	}
+	return country, err
+}
+
+func (s *Server) resolveCountries(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	var filter models.CountryFilter
+	if err := decodeArgument(arguments["filter"], &filter); err != nil {
+		return nil, graphQLError("BAD_USER_INPUT", "filter: "+err.Error())
+	}
+	first := -1
+	if value, ok := arguments["first"]; ok && value != nil {
+		number, ok := value.(float64)
+		if !ok || number < 0 || number != float64(int(number)) {
+			return nil, graphQLError("BAD_USER_INPUT", "first must be a positive integer")
+		}
+		first = int(number)
+	}
+	after := ""
+	if value, ok := arguments["after"]; ok && value != nil {
+		cursor, _ := value.(string)
+		id, err := base64.StdEncoding.DecodeString(cursor)
+		if err != nil || cursor == "" {
+			return nil, graphQLError("BAD_USER_INPUT", "after is not a cursor of the countries")
+		}
+		after = string(id)
+	}
+
+	all, err := s.Actions.GetAllCountries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matching := []models.Country{}
+	for _, country := range *all {
+		if filter.Matches(country) {
+			matching = append(matching, country)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		return strings.ToLower(matching[i].Name) < strings.ToLower(matching[j].Name)
+	})
+
+	connection := &CountryConnection{TotalCount: len(matching), Edges: []CountryEdge{}, Nodes: []models.Country{}}
+	for _, country := range matching {
+		id := strings.ToLower(country.Name)
+		if id <= after {
+			continue
+		}
+		if first >= 0 && len(connection.Edges) == first {
+			connection.PageInfo.HasNextPage = true
+			break
+		}
+		cursor := base64.StdEncoding.EncodeToString([]byte(id))
+		connection.Edges = append(connection.Edges, CountryEdge{Cursor: cursor, Node: country})
+		connection.Nodes = append(connection.Nodes, country)
+		connection.PageInfo.EndCursor = cursor
+	}
+	return connection, nil
+}
+
+func (s *Server) resolveCurrencies(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	all, err := s.Actions.GetAllCountries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byCode := map[string]models.Currency{}
+	for _, country := range *all {
+		for _, currency := range country.Currencies {
+			byCode[currency.Code] = currency
+		}
+	}
+	currencies := make([]models.Currency, 0, len(byCode))
+	for _, currency := range byCode {
+		currencies = append(currencies, currency)
+	}
+	sort.Slice(currencies, func(i, j int) bool {
+		return currencies[i].Code < currencies[j].Code
+	})
+	return currencies, nil
+}
+
+func (s *Server) resolveAddCountry(request *http.Request, arguments map[string]interface{}) (interface{}, error) {
+	if err := s.canMutate(request); err != nil {
+		return nil, err
+	}
+	country, err := countryArgument(arguments["country"])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := reservedCountryId(country.Name); err != nil {
+		return nil, graphQLError("BAD_USER_INPUT", err.Error())
+	}
+	id := strings.ToLower(country.Name)
+	added, err := s.insertCountry(request.Context(), *country)
+	if errors.Is(err, store.ErrCountryExists) {
+		return nil, graphQLError("CONFLICT", "Country already exists")
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.recordAudit(request, id, nil, added)
+	return added, nil
+}
+
+func (s *Server) resolveUpdateCountry(request *http.Request, arguments map[string]interface{}) (interface{}, error) {
+	if err := s.canMutate(request); err != nil {
+		return nil, err
+	}
+	id, err := stringArgument(arguments, "id")
+	if err != nil {
+		return nil, err
+	}
+	before, err := s.Actions.GetCountryById(request.Context(), id)
+	if errors.Is(err, store.ErrCountryNotFound) {
+		return nil, graphQLError("NOT_FOUND", "Country not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var document interface{}
+	beforeBytes, _ := json.Marshal(before)
+	json.Unmarshal(beforeBytes, &document)
+	country, err := countryArgument(mergePatch(withoutNulls(document), arguments["country"]))
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(country.Name) != strings.ToLower(id) {
+		return nil, graphQLError("BAD_USER_INPUT", "The name of the country does not match the id")
+	}
+
+	updated, err := s.updateCountryIf(request.Context(), *before, *country)
+	if errors.Is(err, store.ErrCountryChanged) {
+		return nil, graphQLError("CONFLICT", "Country was changed concurrently, read it again")
+	}
+	if errors.Is(err, store.ErrCountryNotFound) {
+		return nil, graphQLError("NOT_FOUND", "Country not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.recordAudit(request, strings.ToLower(id), before, updated)
+	return updated, nil
+}
+
+func (s *Server) resolveDeleteCountry(request *http.Request, arguments map[string]interface{}) (interface{}, error) {
+	if err := s.canMutate(request); err != nil {
+		return nil, err
+	}
+	id, err := stringArgument(arguments, "id")
+	if err != nil {
+		return nil, err
+	}
+	before, err := s.takeCountry(request.Context(), id)
+	if errors.Is(err, store.ErrCountryNotFound) {
+		return nil, graphQLError("NOT_FOUND", "Country not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.recordAudit(request, strings.ToLower(id), before, nil)
+	return before, nil
+}
+
+func (s *Server) canMutate(request *http.Request) error {
+	if !s.allowsWrites(request) {
+		return graphQLError("FORBIDDEN", "role 'editor' is required to run mutations")
+	}
+	return nil
+}
+
+func graphQLError(code string, message string) *graphql.Error {
+	return &graphql.Error{Message: message, Extensions: map[string]interface{}{"code": code}}
+}
+
+func stringArgument(arguments map[string]interface{}, name string) (string, error) {
+	value, ok := arguments[name].(string)
+	if !ok || value == "" {
+		return "", graphQLError("BAD_USER_INPUT", name+" must be a non empty string")
+	}
+	return value, nil
+}
+
+/**
+Decode an argument into a Go value, rejecting the fields the value does not have
+*/
+func decodeArgument(argument interface{}, target interface{}) error {
+	if argument == nil {
+		return nil
+	}
+	jsonBytes, _ := json.Marshal(argument)
+	decoder := json.NewDecoder(strings.NewReader(string(jsonBytes)))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(target)
+}
+
+/**
+Validate a country input against the schema of the countries, the violations are
+listed in the extensions of the error
+*/
+func countryArgument(argument interface{}) (*models.Country, error) {
+	if violations := validate(reflect.TypeOf(models.Country{}), argument); len(violations) > 0 {
+		err := graphQLError("BAD_USER_INPUT", "country does not match its schema")
+		err.Extensions["violations"] = violations
+		return nil, err
+	}
+	var country models.Country
+	if err := decodeArgument(argument, &country); err != nil {
+		return nil, graphQLError("BAD_USER_INPUT", "country: "+err.Error())
+	}
+	return &country, nil
+}
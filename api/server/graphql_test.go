@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/audit"
+	"go-countries-rest-api/api/auth"
+	"go-countries-rest-api/api/graphql"
+	"go-countries-rest-api/api/store"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func graphQLRequest(query string, variables map[string]interface{}) *http.Request {
+	body, _ := json.Marshal(graphql.Request{Query: query, Variables: variables})
+	return jsonRequest("POST", "/graphql", "application/json", string(body))
+}
+
+func postGraphQL(handler http.Handler, request *http.Request) (*httptest.ResponseRecorder, map[string]interface{}) {
+	recorder := newRequestRecorder(request, handler)
+	response := map[string]interface{}{}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	return recorder, response
+}
+
+func initializeGraphQLCountries() http.Handler {
+	handler := initializeHandlers()
+	for _, body := range []string{greeceBody, spainBody, japanBody, jerseyBody, franceBody} {
+		addCountry(handler, body)
+	}
+	return handler
+}
+
+func TestGraphQLCountry(t *testing.T) {
+	handler := initializeGraphQLCountries()
+	recorder := newRequestRecorder(graphQLRequest(`{ country(id: "Greece") { name currencies { code symbol } } missing: country(id: "atlantis") { name } }`, nil), handler)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `{"data":{"country":{"name":"Greece","currencies":[{"code":"EUR","symbol":"E"}]},"missing":null}}`, recorder.Body.String())
+}
+
+func TestGraphQLCountriesPages(t *testing.T) {
+	handler := initializeGraphQLCountries()
+	query := `query Page($after: String) {
+		countries(filter: {region: "Europe"}, first: 2, after: $after) {
+			totalCount
+			nodes { name }
+			pageInfo { hasNextPage endCursor }
+		}
+	}`
+
+	_, first := postGraphQL(handler, graphQLRequest(query, nil))
+	page := first["data"].(map[string]interface{})["countries"].(map[string]interface{})
+	assert.Equal(t, 3.0, page["totalCount"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "France"}, map[string]interface{}{"name": "Jersey"}}, page["nodes"])
+	pageInfo := page["pageInfo"].(map[string]interface{})
+	assert.Equal(t, true, pageInfo["hasNextPage"])
+
+	_, second := postGraphQL(handler, graphQLRequest(query, map[string]interface{}{"after": pageInfo["endCursor"]}))
+	page = second["data"].(map[string]interface{})["countries"].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "Spain"}}, page["nodes"])
+	assert.Equal(t, false, page["pageInfo"].(map[string]interface{})["hasNextPage"])
+
+	recorder := newRequestRecorder(graphQLRequest(`{ countries(filter: {currency: "EUR"}) { edges { cursor node { alpha2Code } } } }`, nil), handler)
+	assert.Equal(t, `{"data":{"countries":{"edges":[{"cursor":"ZnJhbmNl","node":{"alpha2Code":"FR"}},{"cursor":"Z3JlZWNl","node":{"alpha2Code":"GR"}},{"cursor":"c3BhaW4=","node":{"alpha2Code":"ES"}}]}}}`, recorder.Body.String())
+
+	_, invalid := postGraphQL(handler, graphQLRequest(`{ countries(filter: {continent: "Europe"}, first: -1) { totalCount } }`, nil))
+	assert.Nil(t, invalid["data"].(map[string]interface{})["countries"])
+	assert.Len(t, invalid["errors"], 1)
+}
+
+func TestGraphQLCurrencies(t *testing.T) {
+	handler := initializeGraphQLCountries()
+	recorder := newRequestRecorder(graphQLRequest(`{ currencies { code } }`, nil), handler)
+	assert.Equal(t, `{"data":{"currencies":[{"code":"EUR"},{"code":"GBP"},{"code":"JPY"}]}}`, recorder.Body.String())
+}
+
+func TestGraphQLMutations(t *testing.T) {
+	server := initializeServer()
+	server.Audit = audit.NewLog()
+	handler := server.handler()
+
+	add := `mutation { addCountry(country: {name: "Italy", alpha2Code: "IT", currencies: [{code: "EUR"}]}) { name alpha2Code } }`
+	recorder := newRequestRecorder(graphQLRequest(add, nil), handler)
+	assert.Equal(t, `{"data":{"addCountry":{"name":"Italy","alpha2Code":"IT"}}}`, recorder.Body.String())
+
+	_, again := postGraphQL(handler, graphQLRequest(add, nil))
+	assert.Equal(t, "Country already exists", again["errors"].([]interface{})[0].(map[string]interface{})["message"])
+
+	update := `mutation ($capital: String) { updateCountry(id: "italy", country: {capital: $capital}) { name capital currencies { code } } }`
+	recorder = newRequestRecorder(graphQLRequest(update, map[string]interface{}{"capital": "Rome"}), handler)
+	assert.Equal(t, `{"data":{"updateCountry":{"name":"Italy","capital":"Rome","currencies":[{"code":"EUR"}]}}}`, recorder.Body.String())
+
+	recorder = newRequestRecorder(graphQLRequest(`mutation { deleteCountry(id: "italy") { name } }`, nil), handler)
+	assert.Equal(t, `{"data":{"deleteCountry":{"name":"Italy"}}}`, recorder.Body.String())
+
+	_, missing := postGraphQL(handler, graphQLRequest(`mutation { deleteCountry(id: "italy") { name } }`, nil))
+	assert.Equal(t, map[string]interface{}{"code": "NOT_FOUND"}, missing["errors"].([]interface{})[0].(map[string]interface{})["extensions"])
+
+	assert.Len(t, server.Audit.Query(audit.Query{CountryId: "italy"}), 3)
+}
+
+func TestGraphQLUpdateFailsOnConcurrentChanges(t *testing.T) {
+	server := initializeServer()
+	handler := server.handler()
+	addCountry(handler, greeceBody)
+	server.Actions = &racingStorage{CountriesStorage: server.Actions.(*store.CountriesStorage)}
+
+	update := `mutation { updateCountry(id: "greece", country: {region: "Europe"}) { name } }`
+	_, response := postGraphQL(handler, graphQLRequest(update, nil))
+	assert.Equal(t, map[string]interface{}{"code": "CONFLICT"}, response["errors"].([]interface{})[0].(map[string]interface{})["extensions"])
+
+	country, _ := server.Actions.GetCountryById(context.Background(), "greece")
+	assert.Equal(t, "Thessaloniki", country.Capital)
+	assert.Equal(t, "", country.Region)
+}
+
+func TestGraphQLMutationValidatesCountries(t *testing.T) {
+	handler := initializeHandlers()
+	_, response := postGraphQL(handler, graphQLRequest(`mutation { addCountry(country: {name: "Italy", alpha2Code: "it"}) { name } }`, nil))
+
+	graphqlError := response["errors"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{"addCountry"}, graphqlError["path"])
+	assert.Equal(t, "BAD_USER_INPUT", graphqlError["extensions"].(map[string]interface{})["code"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"pointer": "/alpha2Code", "message": "must match the pattern ^[A-Z]{2}$"}},
+		graphqlError["extensions"].(map[string]interface{})["violations"])
+}
+
+func TestGraphQLMutationsNeedAnEditor(t *testing.T) {
+	handler := initializeServerWithAuth(auth.RoleNone).handler()
+
+	queryReq := graphQLRequest(`{ countries { totalCount } }`, nil)
+	queryReq.Header.Add("X-API-Key", "reader-key")
+	assert.Equal(t, `{"data":{"countries":{"totalCount":0}}}`, newRequestRecorder(queryReq, handler).Body.String())
+
+	mutation := `mutation { addCountry(country: {name: "Italy", alpha2Code: "IT"}) { name } }`
+	readerReq := graphQLRequest(mutation, nil)
+	readerReq.Header.Add("X-API-Key", "reader-key")
+	_, forbidden := postGraphQL(handler, readerReq)
+	assert.Equal(t, map[string]interface{}{"code": "FORBIDDEN"}, forbidden["errors"].([]interface{})[0].(map[string]interface{})["extensions"])
+
+	editorReq := graphQLRequest(mutation, nil)
+	editorReq.Header.Add("X-API-Key", "editor-key")
+	assert.Equal(t, `{"data":{"addCountry":{"name":"Italy"}}}`, newRequestRecorder(editorReq, handler).Body.String())
+
+	anonymousReq := graphQLRequest(`{ countries { totalCount } }`, nil)
+	assert.Equal(t, http.StatusUnauthorized, newRequestRecorder(anonymousReq, handler).Code)
+}
+
+func TestGraphQLRejectsInvalidRequests(t *testing.T) {
+	handler := initializeHandlers()
+
+	syntaxRecorder, syntax := postGraphQL(handler, graphQLRequest(`{ country(id: "greece") { name }`, nil))
+	assert.Equal(t, http.StatusBadRequest, syntaxRecorder.Code)
+	assert.Nil(t, syntax["data"])
+	assert.Len(t, syntax["errors"], 1)
+
+	emptyRecorder := newRequestRecorder(graphQLRequest(" ", nil), handler)
+	assert.Equal(t, http.StatusBadRequest, emptyRecorder.Code)
+
+	textRecorder := newRequestRecorder(jsonRequest("POST", "/graphql", "text/plain", `{"query": "{ currencies { code } }"}`), handler)
+	assert.Equal(t, http.StatusUnsupportedMediaType, textRecorder.Code)
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go-countries-rest-api/api/store"
+	utils "go-countries-rest-api/api/utils"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/**
+Respond with 501 when the store does not keep revisions
+*/
+func (s *Server) versioned(writer http.ResponseWriter) (store.Versioned, bool) {
+	versioned, ok := s.Actions.(store.Versioned)
+	if !ok {
+		utils.ConstructErrorResponse(writer, "Revision history is not supported by the store", http.StatusNotImplemented)
+	}
+	return versioned, ok
+}
+
+/**
+Handle requests like
+GET /countries/{id}/history
+*/
+func (s *Server) getCountryHistory(writer http.ResponseWriter, request *http.Request) {
+	versioned, ok := s.versioned(writer)
+	if !ok {
+		return
+	}
+
+	revisions, err := versioned.GetCountryHistory(pathParam(request, "id"))
+	s.writeRevisionResult(writer, revisions, err)
+}
+
+/**
+Handle requests like
+GET /countries/{id}?asOf=2021-03-01T10:00:00Z
+*/
+func (s *Server) getCountryAsOf(writer http.ResponseWriter, request *http.Request) {
+	versioned, ok := s.versioned(writer)
+	if !ok {
+		return
+	}
+
+	asOf, err := time.Parse(time.RFC3339, request.URL.Query().Get("asOf"))
+	if err != nil {
+		utils.ConstructErrorResponse(writer, "'asOf' must be a RFC 3339 timestamp", http.StatusBadRequest)
+		return
+	}
+
+	country, err := versioned.GetCountryAsOf(pathParam(request, "id"), asOf)
+	s.writeRevisionResult(writer, country, err)
+}
+
+/**
+Handle requests like
+POST /countries/{id}/revisions/{revision}:restore
+The restored revision becomes the newest revision of the country.
+*/
+func (s *Server) restoreRevision(writer http.ResponseWriter, request *http.Request) {
+	versioned, ok := s.versioned(writer)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(pathParam(request, "revision"))
+	if err != nil {
+		utils.ConstructErrorResponse(writer, fmt.Sprintf("'%s' is not a revision number", pathParam(request, "revision")), http.StatusBadRequest)
+		return
+	}
+
+	id := pathParam(request, "id")
+	before, restored, err := versioned.RestoreRevision(id, number)
+	if err == nil {
+		s.recordAudit(request, id, before, restored)
+	}
+	s.writeRevisionResult(writer, restored, err)
+}
+
+func (s *Server) writeRevisionResult(writer http.ResponseWriter, result interface{}, err error) {
+	switch {
+	case err == nil:
+		s.writeJson(writer, http.StatusOK, result)
+	case errors.Is(err, store.ErrCountryNotFound), errors.Is(err, store.ErrRevisionNotFound):
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusNotFound)
+	default:
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
+	}
+}
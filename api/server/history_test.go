@@ -0,0 +1,76 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/audit"
+	"go-countries-rest-api/api/store"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetCountryHistory(t *testing.T) {
+	handler := initializeHandlers()
+	addCountry(handler, greeceBody)
+	addCountry(handler, strings.Replace(greeceBody, "Athens", "Nafplio", 1))
+
+	historyReq, _ := http.NewRequest("GET", "/countries/greece/history", nil)
+	historyReqRecorder := newRequestRecorder(historyReq, handler)
+	assert.Equal(t, http.StatusOK, historyReqRecorder.Code)
+
+	var revisions []store.Revision
+	json.Unmarshal(historyReqRecorder.Body.Bytes(), &revisions)
+	assert.Equal(t, 2, len(revisions))
+	assert.Equal(t, 2, revisions[1].Number)
+	assert.Equal(t, "Nafplio", revisions[1].Country.Capital)
+
+	missingReq, _ := http.NewRequest("GET", "/countries/spain/history", nil)
+	assert.Equal(t, http.StatusNotFound, newRequestRecorder(missingReq, handler).Code)
+}
+
+func TestGetCountryAsOf(t *testing.T) {
+	handler := initializeHandlers()
+	addCountry(handler, greeceBody)
+	asOf := time.Now().UTC().Format(time.RFC3339Nano)
+	time.Sleep(time.Millisecond)
+	addCountry(handler, strings.Replace(greeceBody, "Athens", "Nafplio", 1))
+
+	asOfReq, _ := http.NewRequest("GET", "/countries/greece?asOf="+asOf, nil)
+	asOfReqRecorder := newRequestRecorder(asOfReq, handler)
+	assert.Equal(t, http.StatusOK, asOfReqRecorder.Code)
+	assert.Equal(t, "Athens", constructCountryFromJson(asOfReqRecorder.Body.String()).Capital)
+
+	beforeReq, _ := http.NewRequest("GET", "/countries/greece?asOf=2000-01-01T00:00:00Z", nil)
+	assert.Equal(t, http.StatusNotFound, newRequestRecorder(beforeReq, handler).Code)
+
+	malformedReq, _ := http.NewRequest("GET", "/countries/greece?asOf=yesterday", nil)
+	assert.Equal(t, http.StatusBadRequest, newRequestRecorder(malformedReq, handler).Code)
+}
+
+func TestRestoreRevision(t *testing.T) {
+	server := initializeServer()
+	server.Audit = audit.NewLog()
+	handler := server.handler()
+	addCountry(handler, greeceBody)
+	addCountry(handler, strings.Replace(greeceBody, "Athens", "Nafplio", 1))
+
+	restoreReq, _ := http.NewRequest("POST", "/countries/greece/revisions/1:restore", nil)
+	restoreReqRecorder := newRequestRecorder(restoreReq, handler)
+	assert.Equal(t, http.StatusOK, restoreReqRecorder.Code)
+	assert.Equal(t, "Athens", constructCountryFromJson(restoreReqRecorder.Body.String()).Capital)
+
+	getReq, _ := http.NewRequest("GET", "/countries/greece", nil)
+	assert.Equal(t, "Athens", constructCountryFromJson(newRequestRecorder(getReq, handler).Body.String()).Capital)
+
+	entries := server.Audit.Query(audit.Query{})
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, audit.Update, entries[2].Operation)
+
+	missingReq, _ := http.NewRequest("POST", "/countries/greece/revisions/7:restore", nil)
+	assert.Equal(t, http.StatusNotFound, newRequestRecorder(missingReq, handler).Code)
+
+	malformedReq, _ := http.NewRequest("POST", "/countries/greece/revisions/first:restore", nil)
+	assert.Equal(t, http.StatusBadRequest, newRequestRecorder(malformedReq, handler).Code)
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"go-countries-rest-api/api/idempotency"
+	utils "go-countries-rest-api/api/utils"
+	"io/ioutil"
+	"net/http"
+)
+
+const maxIdempotencyKeyLength = 255
+
+/**
+Middleware honoring the Idempotency-Key header of POST requests. The first response
+for a key is saved with a fingerprint of the request and replayed, with an
+Idempotent-Replayed header, to the retries. Reusing a key for a different request gets
+a 422 and retrying while the first request is processed gets a 409. Server errors are
+not saved so the request can be retried.
+Keys are scoped by client, see clientKey.
+*/
+func (s *Server) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		key := request.Header.Get("Idempotency-Key")
+		if request.Method != "POST" || key == "" {
+			next.ServeHTTP(writer, request)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			utils.ConstructProblemResponse(writer, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters long")
+			return
+		}
+
+		body, err := ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		scopedKey := clientKey(request) + "|" + key
+		saved, err := s.Idempotency.Begin(scopedKey, idempotency.Fingerprint(request.Method, request.URL.RequestURI(), body))
+		switch err {
+		case idempotency.ErrFingerprintMismatch:
+			utils.ConstructProblemResponse(writer, http.StatusUnprocessableEntity, err.Error())
+			return
+		case idempotency.ErrInProgress:
+			utils.ConstructProblemResponse(writer, http.StatusConflict, err.Error())
+			return
+		}
+		if saved != nil {
+			writer.Header().Set("Idempotent-Replayed", "true")
+			writeSavedResponse(writer, *saved)
+			return
+		}
+
+		captured := &capturedResponse{header: http.Header{}}
+		completed := false
+		defer func() {
+			if !completed {
+				s.Idempotency.Abandon(scopedKey)
+			}
+		}()
+		next.ServeHTTP(captured, request)
+
+		response := captured.response()
+		if response.StatusCode < 500 {
+			s.Idempotency.Complete(scopedKey, response)
+			completed = true
+		}
+		writeSavedResponse(writer, response)
+	})
+}
+
+func writeSavedResponse(writer http.ResponseWriter, response idempotency.Response) {
+	for name, values := range response.Header {
+		writer.Header()[name] = append([]string{}, values...)
+	}
+	writer.WriteHeader(response.StatusCode)
+	writer.Write(response.Body)
+}
+
+/**
+Buffers a response so it can be saved before being written
+*/
+type capturedResponse struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (c *capturedResponse) Header() http.Header {
+	return c.header
+}
+
+func (c *capturedResponse) WriteHeader(statusCode int) {
+	if c.statusCode == 0 {
+		c.statusCode = statusCode
+	}
+}
+
+func (c *capturedResponse) Write(bytes []byte) (int, error) {
+	c.WriteHeader(http.StatusOK)
+	return c.body.Write(bytes)
+}
+
+func (c *capturedResponse) response() idempotency.Response {
+	statusCode := c.statusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	return idempotency.Response{StatusCode: statusCode, Header: c.header, Body: c.body.Bytes()}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/audit"
+	"go-countries-rest-api/api/idempotency"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotentPostIsReplayed(t *testing.T) {
+	server := initializeServerWithIdempotency()
+	handler := server.handler()
+
+	firstReqRecorder := newRequestRecorder(idempotentPost("/countries", greeceBody, "retry-1"), handler)
+	assert.Equal(t, http.StatusOK, firstReqRecorder.Code)
+	assert.Equal(t, "", firstReqRecorder.Header().Get("Idempotent-Replayed"))
+
+	retryReqRecorder := newRequestRecorder(idempotentPost("/countries", greeceBody, "retry-1"), handler)
+	assert.Equal(t, http.StatusOK, retryReqRecorder.Code)
+	assert.Equal(t, "true", retryReqRecorder.Header().Get("Idempotent-Replayed"))
+
+	// the retry did not add the country a second time
+	assert.Equal(t, 1, len(server.Audit.Query(audit.Query{})))
+}
+
+func TestIdempotencyKeyReusedWithDifferentBody(t *testing.T) {
+	handler := initializeServerWithIdempotency().handler()
+	newRequestRecorder(idempotentPost("/countries", greeceBody, "retry-1"), handler)
+
+	otherBody := strings.Replace(greeceBody, "Athens", "Nafplio", 1)
+	reusedReqRecorder := newRequestRecorder(idempotentPost("/countries", otherBody, "retry-1"), handler)
+	problem := constructProblemFromJson(reusedReqRecorder.Body.String())
+	assert.Equal(t, http.StatusUnprocessableEntity, reusedReqRecorder.Code)
+	assert.Equal(t, "Idempotency-Key was already used with a different request.", problem.Detail)
+}
+
+func TestIdempotencyKeysAreScopedByClient(t *testing.T) {
+	handler := initializeServerWithIdempotency().handler()
+	first := idempotentPost("/countries", greeceBody, "retry-1")
+	first.Header.Add("X-API-Key", "one")
+	newRequestRecorder(first, handler)
+
+	second := idempotentPost("/countries", strings.Replace(greeceBody, "Athens", "Nafplio", 1), "retry-1")
+	second.Header.Add("X-API-Key", "two")
+	assert.Equal(t, http.StatusOK, newRequestRecorder(second, handler).Code)
+}
+
+func TestFailedTransactionIsReplayedWithIdempotencyKey(t *testing.T) {
+	handler := initializeServerWithIdempotency().handler()
+	body := `{"operations": [{"op": "delete", "id": "atlantis"}]}`
+
+	firstReqRecorder := newRequestRecorder(idempotentPost("/transactions", body, "tx-1"), handler)
+	assert.Equal(t, http.StatusConflict, firstReqRecorder.Code)
+
+	retryReqRecorder := newRequestRecorder(idempotentPost("/transactions", body, "tx-1"), handler)
+	assert.Equal(t, http.StatusConflict, retryReqRecorder.Code)
+	assert.Equal(t, firstReqRecorder.Body.String(), retryReqRecorder.Body.String())
+	assert.Equal(t, "true", retryReqRecorder.Header().Get("Idempotent-Replayed"))
+}
+
+func idempotentPost(path string, body string, key string) *http.Request {
+	request, _ := http.NewRequest("POST", path, strings.NewReader(body))
+	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("Idempotency-Key", key)
+	return request
+}
+
+func initializeServerWithIdempotency() *Server {
+	server := initializeServer()
+	server.Audit = audit.NewLog()
+	server.Idempotency = idempotency.NewStore(time.Hour)
+	return server
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-countries-rest-api/api/jsonrpc"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/store"
+	utils "go-countries-rest-api/api/utils"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+/**
+The codes of the errors of the store, in the range the specification leaves to the applications
+*/
+const (
+	rpcCountryNotFound = -32001
+	rpcNoCountries     = -32002
+	rpcForbidden       = -32003
+	rpcTimeout         = -32004
+	rpcCancelled       = -32005
+)
+
+/**
+Handle requests like
+POST /rpc
+{"jsonrpc": "2.0", "method": "GetCountryById", "params": ["greece"], "id": 1}
+The methods are the ones of store.Actions, their params are given by position or by name:
+* AddCountry(country), which needs an editor when authentication is enabled
+* DeleteCountry(countryId), which needs an editor when authentication is enabled
+* GetCountryById(countryId)
+* GetAllCountries()
+* GetRandomCountryId()
+A batch is an array of calls, run in order. Notifications, the calls without an id, get
+no response: 204 is returned when there is nothing to respond.
+*/
+func (s *Server) postJsonRpc(writer http.ResponseWriter, request *http.Request) {
+	bodyBytes, err := ioutil.ReadAll(request.Body)
+	defer request.Body.Close()
+	if err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ct := request.Header.Get("content-type")
+	if ct != "application/json" {
+		utils.ConstructErrorResponse(writer, fmt.Sprintf("need content-type 'application/json', but got '%s'", ct), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	responseBytes := s.jsonRpcServer(request).Handle(request.Context(), bodyBytes)
+	if responseBytes == nil {
+		writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+	utils.ConstructSuccessfulResponse(writer, http.StatusOK, responseBytes)
+}
+
+/**
+The methods calling the store, bound to the request for the audit log
+*/
+func (s *Server) jsonRpcServer(request *http.Request) *jsonrpc.Server {
+	return &jsonrpc.Server{Methods: map[string]jsonrpc.Method{
+		"AddCountry": func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			return s.rpcAddCountry(request, params)
+		},
+		"DeleteCountry": func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			return s.rpcDeleteCountry(request, params)
+		},
+		"GetCountryById": func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			var countryId string
+			if err := jsonrpc.DecodeParams(params, []string{"countryId"}, &countryId); err != nil {
+				return nil, err
+			}
+			country, err := s.Actions.GetCountryById(ctx, countryId)
+			return country, rpcStoreError(err)
+		},
+		"GetAllCountries": func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			if err := jsonrpc.DecodeParams(params, nil); err != nil {
+				return nil, err
+			}
+			countries, err := s.Actions.GetAllCountries(ctx)
+			return countries, rpcStoreError(err)
+		},
+		"GetRandomCountryId": func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			if err := jsonrpc.DecodeParams(params, nil); err != nil {
+				return nil, err
+			}
+			id, err := s.Actions.GetRandomCountryId(ctx)
+			return id, rpcStoreError(err)
+		},
+	}}
+}
+
+func (s *Server) rpcAddCountry(request *http.Request, params json.RawMessage) (interface{}, error) {
+	if !s.allowsWrites(request) {
+		return nil, &jsonrpc.Error{Code: rpcForbidden, Message: "role 'editor' is required"}
+	}
+	var document interface{}
+	if err := jsonrpc.DecodeParams(params, []string{"country"}, &document); err != nil {
+		return nil, err
+	}
+	if violations := validate(reflect.TypeOf(models.Country{}), document); len(violations) > 0 {
+		return nil, &jsonrpc.Error{Code: jsonrpc.InvalidParams, Message: "Invalid params", Data: violations}
+	}
+	var country models.Country
+	if err := jsonrpc.DecodeParams(params, []string{"country"}, &country); err != nil {
+		return nil, err
+	}
+	if err := reservedCountryId(country.Name); err != nil {
+		return nil, &jsonrpc.Error{Code: jsonrpc.InvalidParams, Message: err.Error()}
+	}
+
+	before, added, err := s.swapCountry(request.Context(), country)
+	if err != nil {
+		return nil, rpcStoreError(err)
+	}
+	s.recordAudit(request, strings.ToLower(country.Name), before, added)
+	return added, nil
+}
+
+func (s *Server) rpcDeleteCountry(request *http.Request, params json.RawMessage) (interface{}, error) {
+	if !s.allowsWrites(request) {
+		return nil, &jsonrpc.Error{Code: rpcForbidden, Message: "role 'editor' is required"}
+	}
+	var countryId string
+	if err := jsonrpc.DecodeParams(params, []string{"countryId"}, &countryId); err != nil {
+		return nil, err
+	}
+
+	before, err := s.takeCountry(request.Context(), countryId)
+	if err != nil {
+		return nil, rpcStoreError(err)
+	}
+	s.recordAudit(request, strings.ToLower(countryId), before, nil)
+	return nil, nil
+}
+
+/**
+Map the errors of the store to JSON-RPC errors, nil stays nil
+*/
+func rpcStoreError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, store.ErrCountryNotFound):
+		return &jsonrpc.Error{Code: rpcCountryNotFound, Message: "Country not found"}
+	case errors.Is(err, store.ErrNoCountries):
+		return &jsonrpc.Error{Code: rpcNoCountries, Message: "No countries"}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &jsonrpc.Error{Code: rpcTimeout, Message: "Store did not respond in time"}
+	case errors.Is(err, context.Canceled):
+		return &jsonrpc.Error{Code: rpcCancelled, Message: "Request cancelled"}
+	default:
+		return &jsonrpc.Error{Code: jsonrpc.InternalError, Message: err.Error()}
+	}
+}
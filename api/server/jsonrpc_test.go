@@ -0,0 +1,83 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/audit"
+	"go-countries-rest-api/api/auth"
+	"net/http"
+	"testing"
+)
+
+func rpcRequest(body string) *http.Request {
+	return jsonRequest("POST", "/rpc", "application/json", body)
+}
+
+func TestJsonRpcMirrorsActions(t *testing.T) {
+	server := initializeServer()
+	server.Audit = audit.NewLog()
+	handler := server.handler()
+
+	addRecorder := newRequestRecorder(rpcRequest(`{"jsonrpc": "2.0", "method": "AddCountry", "params": {"country": `+greeceBody+`}, "id": 1}`), handler)
+	assert.Equal(t, http.StatusOK, addRecorder.Code)
+	assert.Equal(t, "application/json", addRecorder.Header().Get("content-type"))
+	assert.Equal(t, `{"jsonrpc":"2.0","result":{"name":"Greece","alpha2Code":"GR","capital":"Athens","region":"","currencies":[{"code":"EUR","name":"Euro","symbol":"E"}]},"id":1}`, addRecorder.Body.String())
+
+	batch := `[
+		{"jsonrpc": "2.0", "method": "GetCountryById", "params": ["greece"], "id": "get"},
+		{"jsonrpc": "2.0", "method": "GetRandomCountryId", "id": "random"},
+		{"jsonrpc": "2.0", "method": "GetAllCountries", "params": [], "id": "all"},
+		{"jsonrpc": "2.0", "method": "DeleteCountry", "params": {"countryId": "greece"}},
+		{"jsonrpc": "2.0", "method": "GetCountryById", "params": ["greece"], "id": "gone"},
+		{"jsonrpc": "2.0", "method": "GetRandomCountryId", "id": "none"}
+	]`
+	batchRecorder := newRequestRecorder(rpcRequest(batch), handler)
+	assert.Equal(t, `[`+
+		`{"jsonrpc":"2.0","result":{"name":"Greece","alpha2Code":"GR","capital":"Athens","region":"","currencies":[{"code":"EUR","name":"Euro","symbol":"E"}]},"id":"get"},`+
+		`{"jsonrpc":"2.0","result":"greece","id":"random"},`+
+		`{"jsonrpc":"2.0","result":[{"name":"Greece","alpha2Code":"GR","capital":"Athens","region":"","currencies":[{"code":"EUR","name":"Euro","symbol":"E"}]}],"id":"all"},`+
+		`{"jsonrpc":"2.0","error":{"code":-32001,"message":"Country not found"},"id":"gone"},`+
+		`{"jsonrpc":"2.0","error":{"code":-32002,"message":"No countries"},"id":"none"}`+
+		`]`, batchRecorder.Body.String())
+
+	assert.Len(t, server.Audit.Query(audit.Query{CountryId: "greece"}), 2)
+}
+
+func TestJsonRpcNotificationsGetNoContent(t *testing.T) {
+	handler := initializeHandlers()
+	recorder := newRequestRecorder(rpcRequest(`{"jsonrpc": "2.0", "method": "AddCountry", "params": [`+greeceBody+`]}`), handler)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "", recorder.Body.String())
+
+	getReq, _ := http.NewRequest("GET", "/countries/greece", nil)
+	assert.Equal(t, http.StatusOK, newRequestRecorder(getReq, handler).Code)
+}
+
+func TestJsonRpcValidatesCountries(t *testing.T) {
+	handler := initializeHandlers()
+	recorder := newRequestRecorder(rpcRequest(`{"jsonrpc": "2.0", "method": "AddCountry", "params": [{"name": "Greece"}], "id": 1}`), handler)
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":[{"pointer":"/alpha2Code","message":"is required"}]},"id":1}`, recorder.Body.String())
+
+	missingRecorder := newRequestRecorder(rpcRequest(`{"jsonrpc": "2.0", "method": "DeleteCountry", "params": {}, "id": 2}`), handler)
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"missing param \"countryId\""},"id":2}`, missingRecorder.Body.String())
+
+	parseRecorder := newRequestRecorder(rpcRequest(`{"jsonrpc": "2.0", "method"`), handler)
+	assert.Equal(t, http.StatusOK, parseRecorder.Code)
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`, parseRecorder.Body.String())
+
+	textRecorder := newRequestRecorder(jsonRequest("POST", "/rpc", "text/plain", `{}`), handler)
+	assert.Equal(t, http.StatusUnsupportedMediaType, textRecorder.Code)
+}
+
+func TestJsonRpcWritesNeedAnEditor(t *testing.T) {
+	handler := initializeServerWithAuth(auth.RoleNone).handler()
+
+	readerReq := rpcRequest(`[{"jsonrpc": "2.0", "method": "GetAllCountries", "id": 1}, {"jsonrpc": "2.0", "method": "DeleteCountry", "params": ["greece"], "id": 2}]`)
+	readerReq.Header.Add("X-API-Key", "reader-key")
+	assert.Equal(t, `[{"jsonrpc":"2.0","result":[],"id":1},{"jsonrpc":"2.0","error":{"code":-32003,"message":"role 'editor' is required"},"id":2}]`, newRequestRecorder(readerReq, handler).Body.String())
+
+	editorReq := rpcRequest(`{"jsonrpc": "2.0", "method": "DeleteCountry", "params": ["greece"], "id": 1}`)
+	editorReq.Header.Add("X-API-Key", "editor-key")
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32001,"message":"Country not found"},"id":1}`, newRequestRecorder(editorReq, handler).Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, newRequestRecorder(rpcRequest(`{}`), handler).Code)
+}
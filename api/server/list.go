@@ -0,0 +1,89 @@
+package server
+
+import (
+	"fmt"
+	"go-countries-rest-api/api/models"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const maxListLimit = 250
+
+/**
+The parameters of GET /countries
+* name, alpha2Code, capital, region, currency  only list countries matching them, see models.CountryFilter
+* limit                                        maximum number of countries, all of them by default
+* offset                                       number of countries skipped, 0 by default
+The countries are sorted by id so the pages are stable. X-Total-Count is the number of
+countries matching the filter, and a Link header points to the next page when there is one.
+*/
+type listQuery struct {
+	filter models.CountryFilter
+	limit  int
+	offset int
+}
+
+func parseListQuery(values url.Values) (listQuery, error) {
+	query := listQuery{filter: models.CountryFilter{
+		Name:       values.Get("name"),
+		Alpha2Code: values.Get("alpha2Code"),
+		Capital:    values.Get("capital"),
+		Region:     values.Get("region"),
+		Currency:   values.Get("currency"),
+	}}
+
+	if limit := values.Get("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 || parsed > maxListLimit {
+			return query, fmt.Errorf("'limit' must be an integer between 1 and %d", maxListLimit)
+		}
+		query.limit = parsed
+	}
+
+	if offset := values.Get("offset"); offset != "" {
+		parsed, err := strconv.Atoi(offset)
+		if err != nil || parsed < 0 {
+			return query, fmt.Errorf("'offset' must be a positive integer")
+		}
+		query.offset = parsed
+	}
+	return query, nil
+}
+
+/**
+The page of the countries matching the query, and the number of matching countries
+*/
+func (q listQuery) page(countries []models.Country) ([]models.Country, int) {
+	matching := []models.Country{}
+	for _, country := range countries {
+		if q.filter.Matches(country) {
+			matching = append(matching, country)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		return strings.ToLower(matching[i].Name) < strings.ToLower(matching[j].Name)
+	})
+
+	if q.offset >= len(matching) {
+		return []models.Country{}, len(matching)
+	}
+	end := len(matching)
+	if q.limit > 0 && q.offset+q.limit < end {
+		end = q.offset + q.limit
+	}
+	return matching[q.offset:end], len(matching)
+}
+
+/**
+The link to the page following the one of the query, empty for the last page
+*/
+func (q listQuery) next(requestURL *url.URL, total int) string {
+	if q.limit == 0 || q.offset+q.limit >= total {
+		return ""
+	}
+	values := requestURL.Query()
+	values.Set("offset", strconv.Itoa(q.offset+q.limit))
+	return fmt.Sprintf("<%s?%s>; rel=\"next\"", requestURL.Path, values.Encode())
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestGetCountriesPages(t *testing.T) {
+	handler := initializeRandomCountries()
+
+	firstReq, _ := http.NewRequest("GET", "/countries?region=europe&limit=2", nil)
+	firstRecorder := newRequestRecorder(firstReq, handler)
+	assert.Equal(t, http.StatusOK, firstRecorder.Code)
+	assert.Equal(t, "3", firstRecorder.Header().Get("X-Total-Count"))
+	assert.Equal(t, `</countries?limit=2&offset=2&region=europe>; rel="next"`, firstRecorder.Header().Get("Link"))
+	first := constructCountriesFromJson(firstRecorder.Body.String())
+	assert.Equal(t, []string{"France", "Jersey"}, []string{(*first)[0].Name, (*first)[1].Name})
+
+	secondReq, _ := http.NewRequest("GET", "/countries?region=europe&limit=2&offset=2", nil)
+	secondRecorder := newRequestRecorder(secondReq, handler)
+	assert.Equal(t, "", secondRecorder.Header().Get("Link"))
+	second := constructCountriesFromJson(secondRecorder.Body.String())
+	assert.Equal(t, 1, len(*second))
+	assert.Equal(t, "Spain", (*second)[0].Name)
+
+	beyondReq, _ := http.NewRequest("GET", "/countries?offset=10", nil)
+	beyondRecorder := newRequestRecorder(beyondReq, handler)
+	assert.Equal(t, "[]", beyondRecorder.Body.String())
+	assert.Equal(t, "5", beyondRecorder.Header().Get("X-Total-Count"))
+}
+
+func TestGetCountriesFilters(t *testing.T) {
+	handler := initializeRandomCountries()
+
+	currencyReq, _ := http.NewRequest("GET", "/countries?currency=eur", nil)
+	currencyCountries := constructCountriesFromJson(newRequestRecorder(currencyReq, handler).Body.String())
+	assert.Equal(t, 3, len(*currencyCountries))
+	assert.Equal(t, "France", (*currencyCountries)[0].Name)
+
+	codeReq, _ := http.NewRequest("GET", "/countries?alpha2Code=JP&capital=tokyo", nil)
+	codeCountries := constructCountriesFromJson(newRequestRecorder(codeReq, handler).Body.String())
+	assert.Equal(t, 1, len(*codeCountries))
+	assert.Equal(t, "Japan", (*codeCountries)[0].Name)
+}
+
+func TestGetCountriesRejectsMalformedPages(t *testing.T) {
+	handler := initializeHandlers()
+	for _, query := range []string{"limit=0", "limit=251", "limit=ten", "offset=-1"} {
+		req, _ := http.NewRequest("GET", "/countries?"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, newRequestRecorder(req, handler).Code, query)
+	}
+}
@@ -0,0 +1,459 @@
+package server
+
+import (
+	"go-countries-rest-api/api/audit"
+	"go-countries-rest-api/api/events"
+	"go-countries-rest-api/api/graphql"
+	"go-countries-rest-api/api/jsonrpc"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/openapi"
+	"go-countries-rest-api/api/router"
+	"go-countries-rest-api/api/schema"
+	"go-countries-rest-api/api/snapshot"
+	"go-countries-rest-api/api/store"
+	"go-countries-rest-api/api/upstream"
+	utils "go-countries-rest-api/api/utils"
+	"go-countries-rest-api/api/webhooks"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+/**
+Documentation of the routes, keyed by "METHOD pattern" like they are registered
+in initializeRoutes. TestOpenAPIDocumentsEveryRoute fails when a route is missing.
+Path parameters are documented from the patterns, and the 401, 403 and 429
+problem responses of the middlewares are added to every operation.
+*/
+func routeDocs(g *schema.Generator) map[string]openapi.Operation {
+	country := g.Schema(reflect.TypeOf(models.Country{}))
+	countries := &schema.Schema{Type: "array", Items: country}
+	countryBody := &openapi.RequestBody{Required: true, Content: jsonContent(country)}
+	countryNotFound := textResponse("Country not found")
+	invalidCountry := invalidBody("Malformed JSON, or a country not matching /schemas/country", g)
+	webhooksDisabled := textResponse("Webhooks are not enabled")
+	webhookNotFound := textResponse("Webhook subscription not found")
+
+	return map[string]openapi.Operation{
+		"GET /countries": {
+			Summary: "List the countries sorted by id, or a page of them",
+			Tags:    []string{"countries"},
+			Parameters: []openapi.Parameter{
+				query("name", "Only list the country with this name"),
+				query("alpha2Code", "Only list the country with this code"),
+				query("capital", "Only list the countries with this capital"),
+				query("region", "Only list the countries of this region"),
+				query("currency", "Only list the countries using this currency code"),
+				query("limit", "Maximum number of countries, from 1 to 250, all of them by default"),
+				query("offset", "Number of countries skipped, 0 by default"),
+			},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "The countries", Content: jsonContent(countries), Headers: map[string]openapi.Header{
+					"X-Total-Count": {Description: "Number of countries matching the filters", Schema: &schema.Schema{Type: "integer"}},
+					"Link":          {Description: "Link to the next page, when there is one", Schema: &schema.Schema{Type: "string"}},
+				}},
+				"304": {Description: "Not modified since If-Modified-Since"},
+				"400": textResponse("Malformed limit or offset"),
+			},
+		},
+		"POST /countries": {
+			Summary:     "Add a country, or replace the country with the same name",
+			Tags:        []string{"countries"},
+			Parameters:  []openapi.Parameter{header("Idempotency-Key", "Replays the saved response when the request is retried")},
+			RequestBody: countryBody,
+			Responses: map[string]openapi.Response{
+				"200": {Description: "Country saved"},
+				"400": invalidCountry,
+				"415": textResponse("Content-Type is not application/json"),
+				"422": problemResponse("Idempotency-Key reused with a different body", g),
+			},
+		},
+		"GET /countries/random": {
+			Summary: "Pick random countries",
+			Tags:    []string{"countries"},
+			Parameters: []openapi.Parameter{
+				query("currency", "Only pick countries using this currency code"),
+				query("region", "Only pick countries of this region"),
+				query("exclude", "Comma separated ids never picked"),
+				query("count", "Number of distinct countries picked, 1 by default"),
+				query("seed", "Integer making the picks reproducible"),
+				query("mode", "'redirect' (default) or 'body'"),
+			},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The picked country, or the list when count is more than 1", country),
+				"302": {Description: "Redirect to the picked country"},
+				"400": textResponse("Invalid parameter"),
+				"404": textResponse("No countries available to choose randomly"),
+			},
+		},
+		"GET /countries/events": {
+			Summary:    "Stream the country changes as Server-Sent Events",
+			Tags:       []string{"changes"},
+			Parameters: []openapi.Parameter{header("Last-Event-ID", "Resume after this event"), query("lastEventId", "Resume after this event")},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "text/event-stream of events", Content: map[string]openapi.MediaType{"text/event-stream": {Schema: g.Schema(reflect.TypeOf(events.Event{}))}}},
+			},
+			Description: "The data of every event is an Event. A 'reset' event tells the client that missed events are no longer available.",
+		},
+		"GET /countries/ws": {
+			Summary:     "Subscribe to the country changes over a WebSocket",
+			Description: `Clients send {"type": "subscribe", "id": "eurozone", "filter": {...}} and receive {"type": "change", "subscriptions": [...], "event": {...}}`,
+			Tags:        []string{"changes"},
+			Responses: map[string]openapi.Response{
+				"101": {Description: "Switching to the WebSocket protocol"},
+				"400": textResponse("Not a WebSocket handshake"),
+			},
+		},
+		"GET /countries/trash": {
+			Summary: "List the deleted countries, most recently deleted first",
+			Tags:    []string{"trash"},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The deleted countries", arrayOf(g, store.TrashedCountry{})),
+			},
+		},
+		"DELETE /countries/trash/{id}": {
+			Summary: "Delete a country of the trash permanently, with its history",
+			Tags:    []string{"trash"},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "Country purged"},
+				"404": textResponse("Country not found in trash"),
+			},
+		},
+		"GET /countries/{id}": {
+			Summary:    "Get a country",
+			Tags:       []string{"countries"},
+			Parameters: []openapi.Parameter{query("asOf", "RFC 3339 timestamp, returns the country as it was at that time")},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The country", country),
+				"304": {Description: "Not modified since If-Modified-Since"},
+				"400": textResponse("Malformed asOf"),
+				"404": countryNotFound,
+			},
+		},
+		"DELETE /countries/{id}": {
+			Summary: "Move a country to the trash",
+			Tags:    []string{"countries"},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "Country deleted"},
+				"404": countryNotFound,
+			},
+		},
+		"PUT /countries/{id}": {
+			Summary:     "Replace a country, or add it when missing",
+			Tags:        []string{"countries"},
+			RequestBody: countryBody,
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The replaced country", country),
+				"201": jsonResponse("The added country", country),
+				"400": invalidBody("Malformed country, or its name does not match the id", g),
+				"415": textResponse("Content-Type is not application/json"),
+			},
+		},
+		"PATCH /countries/{id}": {
+			Summary: "Update some properties of a country with a JSON merge patch, null removes a property",
+			Tags:    []string{"countries"},
+			RequestBody: &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{
+				"application/merge-patch+json": {Schema: &schema.Schema{Type: "object"}},
+			}},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The patched country", country),
+				"400": invalidBody("Malformed patch, or a patched country not matching /schemas/country", g),
+				"404": countryNotFound,
+				"415": textResponse("Content-Type is not application/merge-patch+json or application/json"),
+			},
+		},
+		"POST /countries/{id}:restore": {
+			Summary: "Restore a country from the trash",
+			Tags:    []string{"trash"},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The restored country", country),
+				"404": textResponse("Country not found in trash"),
+			},
+		},
+		"GET /countries/{id}/history": {
+			Summary: "List the revisions of a country, oldest first",
+			Tags:    []string{"history"},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The revisions", arrayOf(g, store.Revision{})),
+				"404": countryNotFound,
+			},
+		},
+		"POST /countries/{id}/revisions/{revision}:restore": {
+			Summary: "Make a previous revision of a country current again",
+			Tags:    []string{"history"},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The restored country", country),
+				"400": textResponse("Malformed revision number"),
+				"404": textResponse("Revision not found"),
+			},
+		},
+		"POST /transactions": {
+			Summary:     "Apply add, update and delete operations all or nothing",
+			Tags:        []string{"transactions"},
+			Parameters:  []openapi.Parameter{header("Idempotency-Key", "Replays the saved response when the request is retried")},
+			RequestBody: &openapi.RequestBody{Required: true, Content: jsonContent(g.Schema(reflect.TypeOf(transactionRequest{})))},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The applied operations", arrayOf(g, transactionOperation{})),
+				"400": invalidBody("Malformed operation, or a country not matching /schemas/country", g),
+				"409": textResponse("An operation could not be applied, nothing was changed"),
+			},
+		},
+		"POST /graphql": {
+			Summary:     "Run a GraphQL query or mutation over the countries, mutations need an editor",
+			Description: "Queries: country(id), countries(filter, first, after) and currencies. Mutations: addCountry(country), updateCountry(id, country) and deleteCountry(id).",
+			Tags:        []string{"graphql"},
+			RequestBody: &openapi.RequestBody{Required: true, Content: jsonContent(g.Schema(reflect.TypeOf(graphql.Request{})))},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The data, with the errors of the fields that failed", g.Schema(reflect.TypeOf(graphql.Response{}))),
+				"400": {Description: "Malformed body, or a query which can not be executed", Content: map[string]openapi.MediaType{
+					"text/plain":       {Schema: &schema.Schema{Type: "string"}},
+					"application/json": {Schema: g.Schema(reflect.TypeOf(graphql.Response{}))},
+				}},
+				"415": textResponse("Content-Type is not application/json"),
+			},
+		},
+		"POST /rpc": {
+			Summary:     "Call the store.Actions methods with JSON-RPC 2.0, writes need an editor",
+			Description: "Methods: AddCountry(country), DeleteCountry(countryId), GetCountryById(countryId), GetAllCountries() and GetRandomCountryId(). The body is a call or a batch of calls.",
+			Tags:        []string{"json-rpc"},
+			RequestBody: &openapi.RequestBody{Required: true, Content: jsonContent(g.Schema(reflect.TypeOf(jsonrpc.Request{})))},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The response, or the array of the responses of a batch", g.Schema(reflect.TypeOf(jsonrpc.Response{}))),
+				"204": {Description: "Only notifications were sent"},
+				"415": textResponse("Content-Type is not application/json"),
+			},
+		},
+		"GET /webhooks": {
+			Summary: "List the webhook subscriptions",
+			Tags:    []string{"webhooks"},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The subscriptions, without their secrets", arrayOf(g, webhooks.Subscription{})),
+				"501": webhooksDisabled,
+			},
+		},
+		"POST /webhooks": {
+			Summary:     "Subscribe a partner URL to the country changes",
+			Tags:        []string{"webhooks"},
+			RequestBody: &openapi.RequestBody{Required: true, Content: jsonContent(g.Schema(reflect.TypeOf(webhooks.Subscription{})))},
+			Responses: map[string]openapi.Response{
+				"201": jsonResponse("The subscription, with its secret", g.Schema(reflect.TypeOf(webhooks.Subscription{}))),
+				"400": textResponse("Invalid subscription"),
+				"501": webhooksDisabled,
+			},
+		},
+		"GET /webhooks/dead-letters": {
+			Summary: "List the deliveries that failed every attempt, newest first",
+			Tags:    []string{"webhooks"},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The failed deliveries", arrayOf(g, webhooks.Delivery{})),
+				"501": webhooksDisabled,
+			},
+		},
+		"GET /webhooks/{id}": {
+			Summary: "Get a webhook subscription",
+			Tags:    []string{"webhooks"},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The subscription, without its secret", g.Schema(reflect.TypeOf(webhooks.Subscription{}))),
+				"404": webhookNotFound,
+				"501": webhooksDisabled,
+			},
+		},
+		"DELETE /webhooks/{id}": {
+			Summary: "Delete a webhook subscription",
+			Tags:    []string{"webhooks"},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "Subscription deleted"},
+				"404": webhookNotFound,
+				"501": webhooksDisabled,
+			},
+		},
+		"GET /webhooks/{id}/deliveries": {
+			Summary: "List the latest deliveries of a subscription, newest first",
+			Tags:    []string{"webhooks"},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The deliveries with their attempts", arrayOf(g, webhooks.Delivery{})),
+				"404": webhookNotFound,
+				"501": webhooksDisabled,
+			},
+		},
+		"GET /audit": {
+			Summary: "List the audit log entries",
+			Tags:    []string{"audit"},
+			Parameters: []openapi.Parameter{
+				query("countryId", "Only the entries of this country"),
+				query("actor", "Only the entries of this actor"),
+				query("from", "RFC 3339 timestamp, inclusive"),
+				query("to", "RFC 3339 timestamp, inclusive"),
+			},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The entries, oldest first", arrayOf(g, audit.Entry{})),
+				"400": textResponse("Malformed timestamp"),
+				"501": textResponse("Audit log is not enabled"),
+			},
+		},
+		"GET /admin/sync": {
+			Summary: "The status of the sync with the upstream dataset and the changes of the last one",
+			Tags:    []string{"admin"},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The sync status", g.Schema(reflect.TypeOf(upstream.Status{}))),
+				"501": textResponse("Sync is not enabled"),
+			},
+		},
+		"GET /admin/snapshots": {
+			Summary: "List the snapshots of the store, most recent first",
+			Tags:    []string{"admin"},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The snapshots, without their countries", arrayOf(g, snapshot.Info{})),
+				"501": textResponse("Snapshots are not enabled"),
+			},
+		},
+		"POST /admin/snapshots": {
+			Summary: "Capture a point-in-time snapshot of the countries",
+			Tags:    []string{"admin"},
+			Responses: map[string]openapi.Response{
+				"201": jsonResponse("The snapshot, its URL is in the Location header", g.Schema(reflect.TypeOf(snapshot.Info{}))),
+				"501": textResponse("Snapshots are not enabled"),
+			},
+		},
+		"GET /admin/snapshots/{id}": {
+			Summary: "Download a snapshot with its countries",
+			Tags:    []string{"admin"},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The snapshot", g.Schema(reflect.TypeOf(snapshot.Snapshot{}))),
+				"404": textResponse("Snapshot not found"),
+				"500": textResponse("Snapshot is corrupted, its content does not match its checksum"),
+			},
+		},
+		"POST /admin/snapshots/{id}:restore": {
+			Summary:     "Replace the countries of the store by the ones of a snapshot, atomically",
+			Tags:        []string{"admin"},
+			RequestBody: &openapi.RequestBody{Content: jsonContent(g.Schema(reflect.TypeOf(restoreRequest{})))},
+			Responses: map[string]openapi.Response{
+				"200": jsonResponse("The restored snapshot and the ids of the countries it changed", g.Schema(reflect.TypeOf(restoreResult{}))),
+				"400": textResponse("Malformed body"),
+				"404": textResponse("Snapshot not found"),
+				"409": textResponse("Checksum does not match the snapshot"),
+				"500": textResponse("Snapshot is corrupted, its content does not match its checksum"),
+				"501": textResponse("Snapshots are not enabled, or not supported by the store"),
+			},
+		},
+		"GET /openapi.json": {
+			Summary: "This document",
+			Tags:    []string{"documentation"},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "The OpenAPI document", Content: map[string]openapi.MediaType{"application/json": {Schema: &schema.Schema{Type: "object"}}}},
+			},
+		},
+		"GET /schemas/{name}": {
+			Summary: "The JSON Schema validating a model, country or currency",
+			Tags:    []string{"documentation"},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "The JSON Schema document", Content: map[string]openapi.MediaType{"application/json": {Schema: &schema.Schema{Type: "object"}}}},
+				"404": textResponse("Schema not found"),
+			},
+		},
+	}
+}
+
+/**
+Build the OpenAPI document of the registered routes
+*/
+func (s *Server) openAPIDocument() openapi.Document {
+	g := schema.NewGenerator("#/components/schemas/")
+	docs := routeDocs(g)
+	problem := problemResponse("", g).Content
+
+	document := openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       "Countries REST API",
+			Version:     "1.0.0",
+			Description: "Countries and their currencies. Errors are plain text, except the authentication, authorization and rate limiting ones which are RFC 7807 problems.",
+		},
+		Paths: map[string]map[string]openapi.Operation{},
+		Components: openapi.Components{
+			Schemas: g.Definitions,
+			SecuritySchemes: map[string]openapi.SecurityScheme{
+				"apiKey":     {Type: "apiKey", In: "header", Name: "X-API-Key"},
+				"bearerAuth": {Type: "http", Scheme: "bearer"},
+			},
+		},
+		Security: []map[string][]string{{"apiKey": {}}, {"bearerAuth": {}}},
+	}
+
+	for _, route := range s.router.Routes() {
+		operation, ok := docs[route.Method+" "+route.Pattern]
+		if !ok {
+			continue
+		}
+		operation.Parameters = append(pathParameters(route), operation.Parameters...)
+		for status, description := range map[string]string{
+			"401": "Missing or invalid credentials, when authentication is enabled",
+			"403": "The role of the client is not enough",
+			"429": "Rate limit exceeded, retry after the Retry-After header",
+		} {
+			operation.Responses[status] = openapi.Response{Description: description, Content: problem}
+		}
+
+		if document.Paths[route.Pattern] == nil {
+			document.Paths[route.Pattern] = map[string]openapi.Operation{}
+		}
+		document.Paths[route.Pattern][strings.ToLower(route.Method)] = operation
+	}
+	return document
+}
+
+/**
+Handle requests like
+GET /openapi.json
+*/
+func (s *Server) getOpenAPIDocument(writer http.ResponseWriter, request *http.Request) {
+	s.writeJson(writer, http.StatusOK, s.openAPIDocument())
+}
+
+func pathParameters(route router.Route) []openapi.Parameter {
+	parameters := []openapi.Parameter{}
+	for _, name := range route.Params() {
+		parameters = append(parameters, openapi.Parameter{Name: name, In: "path", Required: true, Schema: &schema.Schema{Type: "string"}})
+	}
+	return parameters
+}
+
+func jsonContent(body *schema.Schema) map[string]openapi.MediaType {
+	return map[string]openapi.MediaType{"application/json": {Schema: body}}
+}
+
+func jsonResponse(description string, body *schema.Schema) openapi.Response {
+	return openapi.Response{Description: description, Content: jsonContent(body)}
+}
+
+func textResponse(description string) openapi.Response {
+	return openapi.Response{Description: description, Content: map[string]openapi.MediaType{"text/plain": {Schema: &schema.Schema{Type: "string"}}}}
+}
+
+func problemResponse(description string, g *schema.Generator) openapi.Response {
+	return openapi.Response{Description: description, Content: map[string]openapi.MediaType{"application/problem+json": {Schema: g.Schema(reflect.TypeOf(utils.Problem{}))}}}
+}
+
+/**
+A 400 response, plain text when the body is not JSON and a problem listing the violations
+when it does not match the schema
+*/
+func invalidBody(description string, g *schema.Generator) openapi.Response {
+	return openapi.Response{Description: description, Content: map[string]openapi.MediaType{
+		"text/plain":               {Schema: &schema.Schema{Type: "string"}},
+		"application/problem+json": {Schema: g.Schema(reflect.TypeOf(validationProblem{}))},
+	}}
+}
+
+func arrayOf(g *schema.Generator, value interface{}) *schema.Schema {
+	return &schema.Schema{Type: "array", Items: g.Schema(reflect.TypeOf(value))}
+}
+
+func query(name string, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: &schema.Schema{Type: "string"}}
+}
+
+func header(name string, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "header", Description: description, Schema: &schema.Schema{Type: "string"}}
+}
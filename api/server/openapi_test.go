@@ -0,0 +1,62 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/auth"
+	"go-countries-rest-api/api/openapi"
+	"go-countries-rest-api/api/schema"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	server := initializeServer()
+	docs := routeDocs(schema.NewGenerator(""))
+	document := server.openAPIDocument()
+
+	for _, route := range server.router.Routes() {
+		_, documented := docs[route.Method+" "+route.Pattern]
+		assert.True(t, documented, "route '%s %s' is missing from routeDocs", route.Method, route.Pattern)
+		_, ok := document.Paths[route.Pattern][strings.ToLower(route.Method)]
+		assert.True(t, ok, "route '%s %s' is missing from the OpenAPI document", route.Method, route.Pattern)
+	}
+	for key := range docs {
+		parts := strings.SplitN(key, " ", 2)
+		_, ok := document.Paths[parts[1]][strings.ToLower(parts[0])]
+		assert.True(t, ok, "'%s' is documented but not registered", key)
+	}
+}
+
+func TestGetOpenAPIDocument(t *testing.T) {
+	handler := initializeHandlers()
+	docReq, _ := http.NewRequest("GET", "/openapi.json", nil)
+	docReqRecorder := newRequestRecorder(docReq, handler)
+	assert.Equal(t, http.StatusOK, docReqRecorder.Code)
+
+	var document openapi.Document
+	json.Unmarshal(docReqRecorder.Body.Bytes(), &document)
+	assert.Equal(t, "3.0.3", document.OpenAPI)
+
+	country := document.Components.Schemas["Country"]
+	assert.Equal(t, "object", country.Type)
+	assert.Equal(t, "string", country.Properties["alpha2Code"].Type)
+	assert.Equal(t, "#/components/schemas/Currency", country.Properties["currencies"].Items.Ref)
+	assert.Equal(t, "ISO 4217 code", document.Components.Schemas["Currency"].Properties["code"].Description)
+
+	getCountry := document.Paths["/countries/{id}"]["get"]
+	assert.Equal(t, "id", getCountry.Parameters[0].Name)
+	assert.Equal(t, "path", getCountry.Parameters[0].In)
+	assert.Equal(t, "#/components/schemas/Country", getCountry.Responses["200"].Content["application/json"].Schema.Ref)
+	assert.Equal(t, "#/components/schemas/Problem", getCountry.Responses["401"].Content["application/problem+json"].Schema.Ref)
+}
+
+func TestOpenAPIDocumentIsPublic(t *testing.T) {
+	handler := initializeServerWithAuth(auth.RoleNone).handler()
+	docReq, _ := http.NewRequest("GET", "/openapi.json", nil)
+	assert.Equal(t, http.StatusOK, newRequestRecorder(docReq, handler).Code)
+
+	countriesReq, _ := http.NewRequest("GET", "/countries", nil)
+	assert.Equal(t, http.StatusUnauthorized, newRequestRecorder(countriesReq, handler).Code)
+}
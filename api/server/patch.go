@@ -0,0 +1,133 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/store"
+	utils "go-countries-rest-api/api/utils"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+/**
+Handle requests with path "/countries/{id}" like
+PATCH /countries/{id}
+{"capital": "Athens", "region": null}
+The body is a JSON merge patch (RFC 7396): its members replace the ones of the country,
+null removes them. The patched country is validated as a whole, so a patch can not drop
+a required property, and it can not rename the country. Responds 409 when the country
+changes between its read and the write of the patched one.
+*/
+func (s *Server) patchCountry(writer http.ResponseWriter, request *http.Request) {
+	bodyBytes, err := ioutil.ReadAll(request.Body)
+	defer request.Body.Close()
+	if err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ct := request.Header.Get("content-type")
+	if ct != "application/merge-patch+json" && ct != "application/json" {
+		utils.ConstructErrorResponse(writer, fmt.Sprintf("need content-type 'application/merge-patch+json', but got '%s'", ct), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var patch interface{}
+	if err := json.Unmarshal(bodyBytes, &patch); err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	current, err := s.Actions.GetCountryById(request.Context(), pathParam(request, "id"))
+	if errors.Is(err, store.ErrCountryNotFound) {
+		utils.ConstructErrorResponse(writer, "Country not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeStoreError(writer, err)
+		return
+	}
+
+	var document interface{}
+	currentBytes, _ := json.Marshal(current)
+	json.Unmarshal(currentBytes, &document)
+	merged := mergePatch(withoutNulls(document), patch)
+	if !validDocument(writer, reflect.TypeOf(models.Country{}), merged) {
+		return
+	}
+
+	var country models.Country
+	mergedBytes, _ := json.Marshal(merged)
+	if err := json.Unmarshal(mergedBytes, &country); err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id := strings.ToLower(pathParam(request, "id"))
+	if strings.ToLower(country.Name) != id {
+		utils.ConstructErrorResponse(writer, "The name of the country does not match the id", http.StatusBadRequest)
+		return
+	}
+
+	patched, err := s.updateCountryIf(request.Context(), *current, country)
+	switch {
+	case errors.Is(err, store.ErrCountryNotFound):
+		utils.ConstructErrorResponse(writer, "Country not found", http.StatusNotFound)
+		return
+	case errors.Is(err, store.ErrCountryChanged):
+		utils.ConstructErrorResponse(writer, "Country was changed concurrently, retry the patch", http.StatusConflict)
+		return
+	case err != nil:
+		writeStoreError(writer, err)
+		return
+	}
+	s.recordAudit(request, id, current, patched)
+	s.writeJson(writer, http.StatusOK, patched)
+}
+
+/**
+Apply a JSON merge patch to a decoded document, see RFC 7396
+*/
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	merged := make(map[string]interface{}, len(targetObject))
+	for name, value := range targetObject {
+		merged[name] = value
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(merged, name)
+		} else {
+			merged[name] = mergePatch(merged[name], value)
+		}
+	}
+	return merged
+}
+
+/**
+Drop the null members of an object, encoding/json writes nil slices as null
+while the schemas expect them to be absent
+*/
+func withoutNulls(document interface{}) interface{} {
+	object, ok := document.(map[string]interface{})
+	if !ok {
+		return document
+	}
+	for name, value := range object {
+		if value == nil {
+			delete(object, name)
+		}
+	}
+	return object
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"fmt"
+	"go-countries-rest-api/api/models"
+	"math/rand"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxRandomCount = 250
+
+/**
+The parameters of GET /countries/random
+* currency, region  only pick countries matching them, see models.CountryFilter
+* exclude           comma separated ids never picked
+* count             number of distinct countries picked, 1 by default
+* seed              integer making the picks reproducible for the same countries
+* mode              "redirect" (default) answers with a 302 to the picked country, "body" with the country itself
+Picking more than one country always answers with the list of countries.
+*/
+type randomQuery struct {
+	filter  models.CountryFilter
+	exclude []string
+	count   int
+	seed    *int64
+	body    bool
+}
+
+func parseRandomQuery(values url.Values) (randomQuery, error) {
+	query := randomQuery{
+		filter: models.CountryFilter{Currency: values.Get("currency"), Region: values.Get("region")},
+		count:  1,
+	}
+
+	for _, id := range strings.Split(values.Get("exclude"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			query.exclude = append(query.exclude, strings.ToLower(id))
+		}
+	}
+
+	if count := values.Get("count"); count != "" {
+		parsed, err := strconv.Atoi(count)
+		if err != nil || parsed < 1 || parsed > maxRandomCount {
+			return query, fmt.Errorf("'count' must be an integer between 1 and %d", maxRandomCount)
+		}
+		query.count = parsed
+	}
+
+	if seed := values.Get("seed"); seed != "" {
+		parsed, err := strconv.ParseInt(seed, 10, 64)
+		if err != nil {
+			return query, fmt.Errorf("'seed' must be an integer")
+		}
+		query.seed = &parsed
+	}
+
+	switch values.Get("mode") {
+	case "", "redirect":
+	case "body":
+		query.body = true
+	default:
+		return query, fmt.Errorf("'mode' must be 'redirect' or 'body'")
+	}
+	return query, nil
+}
+
+/**
+A query picking a single country among all of them, which the store can do on its own
+*/
+func (q randomQuery) plain() bool {
+	return q.filter == (models.CountryFilter{}) && len(q.exclude) == 0 && q.count == 1 && q.seed == nil
+}
+
+/**
+Pick up to count distinct countries matching the query. Candidates are sorted by id
+before shuffling so a seed gives the same picks whatever the order of the store.
+*/
+func (q randomQuery) pick(countries []models.Country) []models.Country {
+	candidates := []models.Country{}
+	for _, country := range countries {
+		if q.filter.Matches(country) && !containsString(q.exclude, strings.ToLower(country.Name)) {
+			candidates = append(candidates, country)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return strings.ToLower(candidates[i].Name) < strings.ToLower(candidates[j].Name)
+	})
+
+	seed := time.Now().UnixNano()
+	if q.seed != nil {
+		seed = *q.seed
+	}
+	random := rand.New(rand.NewSource(seed))
+
+	count := q.count
+	if count > len(candidates) {
+		count = len(candidates)
+	}
+	// partial Fisher-Yates shuffle
+	for i := 0; i < count; i++ {
+		j := i + random.Intn(len(candidates)-i)
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	}
+	return candidates[:count]
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	model "go-countries-rest-api/api/models"
+	"net/http"
+	"testing"
+)
+
+const (
+	spainBody  = `{"name": "Spain", "alpha2Code": "ES", "capital": "Madrid", "region": "Europe", "currencies": [{"code": "EUR"}]}`
+	japanBody  = `{"name": "Japan", "alpha2Code": "JP", "capital": "Tokyo", "region": "Asia", "currencies": [{"code": "JPY"}]}`
+	jerseyBody = `{"name": "Jersey", "alpha2Code": "JE", "capital": "Saint Helier", "region": "Europe", "currencies": [{"code": "GBP"}]}`
+	franceBody = `{"name": "France", "alpha2Code": "FR", "capital": "Paris", "region": "Europe", "currencies": [{"code": "EUR"}]}`
+)
+
+func TestRandomCountryWithFilters(t *testing.T) {
+	handler := initializeRandomCountries()
+
+	randomReq, _ := http.NewRequest("GET", "/countries/random?currency=eur&region=Europe&exclude=greece,France", nil)
+	randomReqRecorder := newRequestRecorder(randomReq, handler)
+	assert.Equal(t, http.StatusFound, randomReqRecorder.Code)
+	assert.Equal(t, "/countries/spain", randomReqRecorder.Header().Get("location"))
+
+	noneReq, _ := http.NewRequest("GET", "/countries/random?currency=USD", nil)
+	noneReqRecorder := newRequestRecorder(noneReq, handler)
+	assert.Equal(t, http.StatusNotFound, noneReqRecorder.Code)
+	assert.Equal(t, "No countries available to choose randomly", noneReqRecorder.Body.String())
+}
+
+func TestRandomCountryBodyMode(t *testing.T) {
+	handler := initializeRandomCountries()
+	randomReq, _ := http.NewRequest("GET", "/countries/random?mode=body&region=asia", nil)
+	randomReqRecorder := newRequestRecorder(randomReq, handler)
+	assert.Equal(t, http.StatusOK, randomReqRecorder.Code)
+	assert.Equal(t, "Tokyo", constructCountryFromJson(randomReqRecorder.Body.String()).Capital)
+
+	anyReq, _ := http.NewRequest("GET", "/countries/random?mode=body", nil)
+	assert.Equal(t, http.StatusOK, newRequestRecorder(anyReq, handler).Code)
+}
+
+func TestRandomCountriesAreDistinct(t *testing.T) {
+	handler := initializeRandomCountries()
+	randomReq, _ := http.NewRequest("GET", "/countries/random?count=4", nil)
+	randomReqRecorder := newRequestRecorder(randomReq, handler)
+	assert.Equal(t, http.StatusOK, randomReqRecorder.Code)
+
+	names := map[string]bool{}
+	for _, country := range *constructCountriesFromJson(randomReqRecorder.Body.String()) {
+		names[country.Name] = true
+	}
+	assert.Equal(t, 4, len(names))
+
+	// asking for more countries than available returns all of them
+	allReq, _ := http.NewRequest("GET", "/countries/random?count=10&region=Europe", nil)
+	assert.Equal(t, 3, len(*constructCountriesFromJson(newRequestRecorder(allReq, handler).Body.String())))
+}
+
+func TestRandomCountriesAreReproducibleWithSeed(t *testing.T) {
+	first := pickWithSeed(initializeRandomCountries())
+	for i := 0; i < 5; i++ {
+		// a new store has another map order, the picks must not depend on it
+		assert.Equal(t, first, pickWithSeed(initializeRandomCountries()))
+	}
+}
+
+func TestRandomCountryWithInvalidParameters(t *testing.T) {
+	handler := initializeRandomCountries()
+	for query, expected := range map[string]string{
+		"count=0":     "'count' must be an integer between 1 and 250",
+		"count=many":  "'count' must be an integer between 1 and 250",
+		"seed=lucky":  "'seed' must be an integer",
+		"mode=iframe": "'mode' must be 'redirect' or 'body'",
+	} {
+		randomReq, _ := http.NewRequest("GET", "/countries/random?"+query, nil)
+		randomReqRecorder := newRequestRecorder(randomReq, handler)
+		assert.Equal(t, http.StatusBadRequest, randomReqRecorder.Code)
+		assert.Equal(t, expected, randomReqRecorder.Body.String())
+	}
+}
+
+func pickWithSeed(handler http.Handler) []string {
+	randomReq, _ := http.NewRequest("GET", "/countries/random?count=3&seed=42", nil)
+	recorder := newRequestRecorder(randomReq, handler)
+
+	var countries []model.Country
+	json.Unmarshal(recorder.Body.Bytes(), &countries)
+	names := []string{}
+	for _, country := range countries {
+		names = append(names, country.Name)
+	}
+	return names
+}
+
+func initializeRandomCountries() http.Handler {
+	handler := initializeHandlers()
+	for _, body := range []string{greeceBody, spainBody, japanBody, jerseyBody, franceBody} {
+		addCountry(handler, body)
+	}
+	return handler
+}
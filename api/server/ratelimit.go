@@ -0,0 +1,97 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	utils "go-countries-rest-api/api/utils"
+)
+
+/**
+Middleware applying the token bucket limits of the server. Every response carries the
+RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers, and requests over
+the limit get a 429 with a Retry-After header.
+*/
+func (s *Server) rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		bucket, limit, limited := s.RateLimits.LimitFor(request.Method, request.URL.Path)
+		if !limited {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		request, key := s.rateLimitKey(request)
+		result, err := s.RateLimits.Limiter.Take(key+"|"+bucket, limit)
+		if err != nil {
+			// do not turn a broken limiter backend into an outage
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		writer.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		writer.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		writer.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			writer.Header().Set("Retry-After", strconv.Itoa(int(math.Max(1, float64(ceilSeconds(result.RetryAfter))))))
+			utils.ConstructProblemResponse(writer, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+
+		next.ServeHTTP(writer, request)
+	})
+}
+
+/**
+Rate limits run before authentication, so a credential only gets its own bucket once it
+verifies. Made up keys and tokens share the bucket of their IP instead of each getting a
+fresh one. The returned request carries the authentication result, so the authenticate
+middleware does not verify the credentials again.
+*/
+func (s *Server) rateLimitKey(request *http.Request) (*http.Request, string) {
+	if s.Auth == nil || !hasCredentials(request) {
+		return request, clientIP(request)
+	}
+	request, principal, err := s.authenticateOnce(request)
+	if err != nil || principal.Credential == "" {
+		return request, clientIP(request)
+	}
+	return request, principal.Credential
+}
+
+/**
+Idempotency keys are scoped to the client sending them: its API key or bearer token when it
+sends one, otherwise its IP. Credentials are hashed so they are never kept by the store.
+*/
+func clientKey(request *http.Request) string {
+	credentials := request.Header.Get("X-API-Key")
+	if credentials == "" {
+		credentials = request.Header.Get("Authorization")
+	}
+	if credentials != "" {
+		sum := sha256.Sum256([]byte(credentials))
+		return "key:" + hex.EncodeToString(sum[:8])
+	}
+	return clientIP(request)
+}
+
+func hasCredentials(request *http.Request) bool {
+	return request.Header.Get("X-API-Key") != "" || request.Header.Get("Authorization") != ""
+}
+
+func clientIP(request *http.Request) string {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		host = request.RemoteAddr
+	}
+	return "ip:" + strings.TrimSpace(host)
+}
+
+func ceilSeconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
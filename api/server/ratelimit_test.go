@@ -0,0 +1,114 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/auth"
+	"go-countries-rest-api/api/ratelimit"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRequestsOverTheLimitAreRejected(t *testing.T) {
+	server := initializeServer()
+	server.RateLimits = &ratelimit.Config{
+		Limiter: ratelimit.NewMemoryLimiter(),
+		Routes:  []ratelimit.RouteLimit{{Method: "GET", Path: "/countries", Limit: ratelimit.Limit{Rate: 0.5, Burst: 2}}},
+	}
+	handler := server.handler()
+
+	getAllReq, _ := http.NewRequest("GET", "/countries", nil)
+	getAllReq.RemoteAddr = "10.0.0.1:5000"
+	firstRecorder := newRequestRecorder(getAllReq, handler)
+	secondRecorder := newRequestRecorder(getAllReq, handler)
+	thirdRecorder := newRequestRecorder(getAllReq, handler)
+
+	assert.Equal(t, http.StatusOK, firstRecorder.Code)
+	assert.Equal(t, "2", firstRecorder.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", firstRecorder.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", firstRecorder.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, http.StatusOK, secondRecorder.Code)
+	assert.Equal(t, "0", secondRecorder.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, http.StatusTooManyRequests, thirdRecorder.Code)
+	assert.Equal(t, "2", thirdRecorder.Header().Get("Retry-After"))
+	assert.Equal(t, "application/problem+json", thirdRecorder.Header().Get("content-type"))
+}
+
+func TestRateLimitsAreKeyedByClient(t *testing.T) {
+	server := initializeServerWithAuth(auth.RoleReader)
+	server.RateLimits = &ratelimit.Config{
+		Limiter: ratelimit.NewMemoryLimiter(),
+		Default: ratelimit.Limit{Rate: 1, Burst: 1},
+	}
+	handler := server.handler()
+
+	firstClientReq, _ := http.NewRequest("GET", "/countries", nil)
+	firstClientReq.RemoteAddr = "10.0.0.1:5000"
+	secondClientReq, _ := http.NewRequest("GET", "/countries", nil)
+	secondClientReq.RemoteAddr = "10.0.0.1:5000"
+	secondClientReq.Header.Add("X-API-Key", "reader-key")
+
+	assert.Equal(t, http.StatusOK, newRequestRecorder(firstClientReq, handler).Code)
+	assert.Equal(t, http.StatusTooManyRequests, newRequestRecorder(firstClientReq, handler).Code)
+	assert.Equal(t, http.StatusOK, newRequestRecorder(secondClientReq, handler).Code)
+}
+
+func TestTokensDoNotShareTheBucketOfAnAPIKeyWithTheirSubject(t *testing.T) {
+	server := initializeServerWithAuth(auth.RoleNone)
+	server.RateLimits = &ratelimit.Config{
+		Limiter: ratelimit.NewMemoryLimiter(),
+		Default: ratelimit.Limit{Rate: 1, Burst: 1},
+	}
+	verifications := 0
+	server.Auth.Now = func() time.Time {
+		verifications++
+		return time.Now()
+	}
+	handler := server.handler()
+
+	keyReq, _ := http.NewRequest("GET", "/countries", nil)
+	keyReq.Header.Add("X-API-Key", "reader-key")
+	token, _ := auth.SignToken(auth.Claims{Subject: "dashboard", Role: "reader", ExpiresAt: time.Now().Add(time.Hour).Unix()}, []byte("secret"))
+	tokenReq, _ := http.NewRequest("GET", "/countries", nil)
+	tokenReq.Header.Add("Authorization", "Bearer "+token)
+
+	assert.Equal(t, http.StatusOK, newRequestRecorder(keyReq, handler).Code)
+	assert.Equal(t, http.StatusTooManyRequests, newRequestRecorder(keyReq, handler).Code)
+	assert.Equal(t, http.StatusOK, newRequestRecorder(tokenReq, handler).Code)
+	assert.Equal(t, 1, verifications)
+}
+
+func TestUnverifiedCredentialsShareTheBucketOfTheirIP(t *testing.T) {
+	server := initializeServerWithAuth(auth.RoleReader)
+	server.RateLimits = &ratelimit.Config{
+		Limiter: ratelimit.NewMemoryLimiter(),
+		Default: ratelimit.Limit{Rate: 1, Burst: 1},
+	}
+	handler := server.handler()
+
+	anonymousReq, _ := http.NewRequest("GET", "/countries", nil)
+	anonymousReq.RemoteAddr = "10.0.0.1:5000"
+	madeUpKeyReq, _ := http.NewRequest("GET", "/countries", nil)
+	madeUpKeyReq.RemoteAddr = "10.0.0.1:5000"
+	madeUpKeyReq.Header.Add("X-API-Key", "made-up-key")
+	madeUpTokenReq, _ := http.NewRequest("GET", "/countries", nil)
+	madeUpTokenReq.RemoteAddr = "10.0.0.1:5000"
+	madeUpTokenReq.Header.Add("Authorization", "Bearer made-up-token")
+
+	assert.Equal(t, http.StatusOK, newRequestRecorder(anonymousReq, handler).Code)
+	assert.Equal(t, http.StatusTooManyRequests, newRequestRecorder(madeUpKeyReq, handler).Code)
+	assert.Equal(t, http.StatusTooManyRequests, newRequestRecorder(madeUpTokenReq, handler).Code)
+}
+
+func TestRoutesWithoutLimitAreNotLimited(t *testing.T) {
+	server := initializeServer()
+	server.RateLimits = &ratelimit.Config{
+		Limiter: ratelimit.NewMemoryLimiter(),
+		Routes:  []ratelimit.RouteLimit{{Method: "DELETE", Path: "/countries/", Limit: ratelimit.Limit{Rate: 1, Burst: 1}}},
+	}
+	getAllReq, _ := http.NewRequest("GET", "/countries", nil)
+	getAllReqRecorder := newRequestRecorder(getAllReq, server.handler())
+
+	assert.Equal(t, http.StatusOK, getAllReqRecorder.Code)
+	assert.Equal(t, "", getAllReqRecorder.Header().Get("RateLimit-Limit"))
+}
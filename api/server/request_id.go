@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const requestIdHeader = "X-Request-ID"
+
+type requestIdKey struct{}
+
+/**
+Middleware giving every request an id, echoed in the X-Request-ID response header.
+The id sent by the client is kept when it looks sane, so calls can be traced across services.
+*/
+func requestId(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		id := request.Header.Get(requestIdHeader)
+		if !validRequestId(id) {
+			bytes := make([]byte, 8)
+			rand.Read(bytes)
+			id = hex.EncodeToString(bytes)
+		}
+
+		writer.Header().Set(requestIdHeader, id)
+		next.ServeHTTP(writer, request.WithContext(context.WithValue(request.Context(), requestIdKey{}, id)))
+	})
+}
+
+func requestIdFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+
+func validRequestId(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,11 +1,78 @@
 package server
 
+import (
+	"fmt"
+	"go-countries-rest-api/api/router"
+	"net/http"
+	"strings"
+)
+
 /**
 https://dev.to/bmf_san/introduction-to-url-router-from-scratch-with-golang-3p8j
 https://github.com/gsingharoy/httprouter-tutorial/tree/master/part4
 Check this about routing
 */
 func (s *Server) initializeRoutes() {
-	s.Mux.HandleFunc("/countries", s.countries)
-	s.Mux.HandleFunc("/countries/", s.countryById)
+	s.router = &router.Router{}
+	s.router.Handle("GET", "/countries", s.get)
+	s.router.Handle("POST", "/countries", s.post)
+	s.router.Handle("GET", "/countries/random", s.getRandomCountry)
+	s.router.Handle("GET", "/countries/events", s.streamEvents)
+	s.router.Handle("GET", "/countries/ws", s.subscribeToChanges)
+	s.router.Handle("GET", "/countries/trash", s.listTrash)
+	s.router.Handle("DELETE", "/countries/trash/{id}", s.purgeCountry)
+	s.router.Handle("GET", "/countries/{id}", s.getCountry)
+	s.router.Handle("DELETE", "/countries/{id}", s.deleteCountry)
+	s.router.Handle("PUT", "/countries/{id}", s.putCountry)
+	s.router.Handle("PATCH", "/countries/{id}", s.patchCountry)
+	s.router.Handle("POST", "/countries/{id}:restore", s.restoreCountry)
+	s.router.Handle("GET", "/countries/{id}/history", s.getCountryHistory)
+	s.router.Handle("POST", "/countries/{id}/revisions/{revision}:restore", s.restoreRevision)
+
+	s.router.Handle("POST", "/transactions", s.postTransaction)
+
+	s.router.Handle("POST", "/graphql", s.postGraphQL)
+	s.router.Handle("POST", "/rpc", s.postJsonRpc)
+
+	s.router.Handle("GET", "/webhooks", s.listWebhooks)
+	s.router.Handle("POST", "/webhooks", s.addWebhook)
+	s.router.Handle("GET", "/webhooks/dead-letters", s.webhookDeadLetters)
+	s.router.Handle("GET", "/webhooks/{id}", s.getWebhook)
+	s.router.Handle("DELETE", "/webhooks/{id}", s.deleteWebhook)
+	s.router.Handle("GET", "/webhooks/{id}/deliveries", s.webhookDeliveries)
+
+	s.router.Handle("GET", "/audit", s.auditEntries)
+
+	s.router.Handle("GET", "/admin/sync", s.getSyncStatus)
+	s.router.Handle("GET", "/admin/snapshots", s.listSnapshots)
+	s.router.Handle("POST", "/admin/snapshots", s.captureSnapshot)
+	s.router.Handle("GET", "/admin/snapshots/{id}", s.getSnapshot)
+	s.router.Handle("POST", "/admin/snapshots/{id}:restore", s.restoreSnapshot)
+
+	s.router.Handle("GET", "/openapi.json", s.getOpenAPIDocument)
+	s.router.Handle("GET", "/schemas/{name}", s.getSchema)
+
+	s.Mux.Handle("/", s.router)
+}
+
+/**
+The literal routes under /countries win over "/countries/{id}", so GET /countries/trash lists
+the trash instead of getting a country "trash". The writes reject countries with these ids,
+countries synced from upstream are not checked.
+*/
+var reservedCountryIds = []string{"events", "random", "trash", "ws"}
+
+func reservedCountryId(name string) error {
+	id := strings.ToLower(name)
+	if containsString(reservedCountryIds, id) {
+		return fmt.Errorf("The id '%s' is reserved by the route /countries/%s", id, id)
+	}
+	return nil
+}
+
+/**
+The value of a parameter of the route pattern, e.g. pathParam(request, "id") for "/countries/{id}"
+*/
+func pathParam(request *http.Request, name string) string {
+	return router.Param(request, name)
 }
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"encoding/json"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/schema"
+	utils "go-countries-rest-api/api/utils"
+	"net/http"
+	"reflect"
+)
+
+/**
+The models published at /schemas/{name}, and validating the bodies of the requests
+*/
+var modelTypes = map[string]reflect.Type{
+	"country":  reflect.TypeOf(models.Country{}),
+	"currency": reflect.TypeOf(models.Currency{}),
+}
+
+/**
+A 400 problem listing where the body does not match its schema
+*/
+type validationProblem struct {
+	utils.Problem
+	Errors []schema.Violation `json:"errors"`
+}
+
+/**
+Handle requests like
+GET /schemas/country
+*/
+func (s *Server) getSchema(writer http.ResponseWriter, request *http.Request) {
+	name := pathParam(request, "name")
+	t, ok := modelTypes[name]
+	if !ok {
+		utils.ConstructErrorResponse(writer, "Schema not found", http.StatusNotFound)
+		return
+	}
+	s.writeJson(writer, http.StatusOK, schema.Document(t, "/schemas/"+name))
+}
+
+/**
+Decode a JSON body into target once it matches the schema of the type of target.
+Responds 400 with the plain text error when the body is not JSON, or with a problem
+listing the violations when it does not match the schema. Returns whether target is set.
+*/
+func decodeValid(writer http.ResponseWriter, bodyBytes []byte, target interface{}) bool {
+	var document interface{}
+	if err := json.Unmarshal(bodyBytes, &document); err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if !validDocument(writer, reflect.TypeOf(target), document) {
+		return false
+	}
+	if err := json.Unmarshal(bodyBytes, target); err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+/**
+Validate a decoded document against the schema of a type, responding 400 with
+the violations when it does not match
+*/
+func validDocument(writer http.ResponseWriter, t reflect.Type, document interface{}) bool {
+	violations := validate(t, document)
+	if len(violations) == 0 {
+		return true
+	}
+
+	jsonBytes, _ := json.Marshal(validationProblem{
+		Problem: utils.Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusBadRequest),
+			Status: http.StatusBadRequest,
+			Detail: "The body does not match its schema",
+		},
+		Errors: violations,
+	})
+	writer.Header().Set("content-type", "application/problem+json")
+	writer.WriteHeader(http.StatusBadRequest)
+	writer.Write(jsonBytes)
+	return false
+}
+
+/**
+The violations of the schema of a type by a decoded document
+*/
+func validate(t reflect.Type, document interface{}) []schema.Violation {
+	g := schema.NewGenerator("#/definitions/")
+	return schema.Validate(g.Schema(t), g.Definitions, document)
+}
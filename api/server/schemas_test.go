@@ -0,0 +1,198 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/auth"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/schema"
+	"go-countries-rest-api/api/store"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func constructValidationProblemFromJson(jsonData string) *validationProblem {
+	problem := &validationProblem{}
+	json.Unmarshal([]byte(jsonData), problem)
+	return problem
+}
+
+func jsonRequest(method string, path string, contentType string, body string) *http.Request {
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Add("Content-Type", contentType)
+	return req
+}
+
+func TestGetCountrySchema(t *testing.T) {
+	handler := initializeServerWithAuth(auth.RoleNone).handler()
+	schemaReq, _ := http.NewRequest("GET", "/schemas/country", nil)
+	schemaReqRecorder := newRequestRecorder(schemaReq, handler)
+	assert.Equal(t, http.StatusOK, schemaReqRecorder.Code)
+
+	var document schema.Schema
+	json.Unmarshal(schemaReqRecorder.Body.Bytes(), &document)
+	assert.Equal(t, schema.Draft7, document.SchemaURI)
+	assert.Equal(t, "/schemas/country", document.ID)
+	assert.Equal(t, []string{"name", "alpha2Code"}, document.Required)
+	assert.Equal(t, "^[A-Z]{2}$", document.Properties["alpha2Code"].Pattern)
+	assert.Equal(t, "#/definitions/Currency", document.Properties["currencies"].Items.Ref)
+	assert.Equal(t, "^[A-Z]{3}$", document.Definitions["Currency"].Properties["code"].Pattern)
+
+	currencyReq, _ := http.NewRequest("GET", "/schemas/currency", nil)
+	currencyReqRecorder := newRequestRecorder(currencyReq, handler)
+	assert.Equal(t, http.StatusOK, currencyReqRecorder.Code)
+	assert.Contains(t, currencyReqRecorder.Body.String(), `"$id":"/schemas/currency"`)
+
+	unknownReq, _ := http.NewRequest("GET", "/schemas/city", nil)
+	assert.Equal(t, http.StatusNotFound, newRequestRecorder(unknownReq, handler).Code)
+}
+
+func TestPostInvalidCountryReportsViolations(t *testing.T) {
+	handler := initializeHandlers()
+	body := `{"name": "Greece", "alpha2Code": "gr", "currencies": [{"code": "EUR"}, {"name": "Drachma"}]}`
+	addReqRecorder := newRequestRecorder(jsonRequest("POST", "/countries", "application/json", body), handler)
+
+	assert.Equal(t, http.StatusBadRequest, addReqRecorder.Code)
+	assert.Equal(t, "application/problem+json", addReqRecorder.Header().Get("content-type"))
+	problem := constructValidationProblemFromJson(addReqRecorder.Body.String())
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, []schema.Violation{
+		{Pointer: "/alpha2Code", Message: "must match the pattern ^[A-Z]{2}$"},
+		{Pointer: "/currencies/1/code", Message: "is required"},
+	}, problem.Errors)
+
+	getReq, _ := http.NewRequest("GET", "/countries/greece", nil)
+	assert.Equal(t, http.StatusNotFound, newRequestRecorder(getReq, handler).Code)
+
+	malformedRecorder := newRequestRecorder(jsonRequest("POST", "/countries", "application/json", `{"name": `), handler)
+	assert.Equal(t, http.StatusBadRequest, malformedRecorder.Code)
+}
+
+func TestPostTransactionWithInvalidCountry(t *testing.T) {
+	handler := initializeHandlers()
+	body := `{"operations": [{"op": "add", "country": ` + greeceBody + `}, {"op": "add", "country": {"name": "Spain"}}]}`
+	txReqRecorder := newRequestRecorder(jsonRequest("POST", "/transactions", "application/json", body), handler)
+
+	assert.Equal(t, http.StatusBadRequest, txReqRecorder.Code)
+	problem := constructValidationProblemFromJson(txReqRecorder.Body.String())
+	assert.Equal(t, []schema.Violation{{Pointer: "/operations/1/country/alpha2Code", Message: "is required"}}, problem.Errors)
+}
+
+func TestPutCountry(t *testing.T) {
+	handler := initializeHandlers()
+	addReqRecorder := newRequestRecorder(jsonRequest("PUT", "/countries/greece", "application/json", greeceBody), handler)
+	assert.Equal(t, http.StatusCreated, addReqRecorder.Code)
+	assert.Equal(t, "Athens", constructCountryFromJson(addReqRecorder.Body.String()).Capital)
+
+	replaced := strings.Replace(greeceBody, "Athens", "Nafplio", 1)
+	replaceReqRecorder := newRequestRecorder(jsonRequest("PUT", "/countries/Greece", "application/json", replaced), handler)
+	assert.Equal(t, http.StatusOK, replaceReqRecorder.Code)
+
+	getReq, _ := http.NewRequest("GET", "/countries/greece", nil)
+	assert.Equal(t, "Nafplio", constructCountryFromJson(newRequestRecorder(getReq, handler).Body.String()).Capital)
+
+	mismatchRecorder := newRequestRecorder(jsonRequest("PUT", "/countries/spain", "application/json", greeceBody), handler)
+	assert.Equal(t, http.StatusBadRequest, mismatchRecorder.Code)
+	assert.Equal(t, "The name of the country does not match the id", mismatchRecorder.Body.String())
+
+	invalidRecorder := newRequestRecorder(jsonRequest("PUT", "/countries/greece", "application/json", `{"name": "Greece"}`), handler)
+	assert.Equal(t, http.StatusBadRequest, invalidRecorder.Code)
+	assert.Equal(t, "/alpha2Code", constructValidationProblemFromJson(invalidRecorder.Body.String()).Errors[0].Pointer)
+
+	textRecorder := newRequestRecorder(jsonRequest("PUT", "/countries/greece", "text/plain", greeceBody), handler)
+	assert.Equal(t, http.StatusUnsupportedMediaType, textRecorder.Code)
+}
+
+func TestPatchCountry(t *testing.T) {
+	handler := initializeHandlers()
+	addCountry(handler, greeceBody)
+
+	patch := `{"capital": "Nafplio", "region": "Europe", "currencies": [{"code": "GRD", "name": "Drachma"}]}`
+	patchReqRecorder := newRequestRecorder(jsonRequest("PATCH", "/countries/greece", "application/merge-patch+json", patch), handler)
+	assert.Equal(t, http.StatusOK, patchReqRecorder.Code)
+
+	patched := constructCountryFromJson(patchReqRecorder.Body.String())
+	assert.Equal(t, "Greece", patched.Name)
+	assert.Equal(t, "GR", patched.Alpha2Code)
+	assert.Equal(t, "Nafplio", patched.Capital)
+	assert.Equal(t, "Europe", patched.Region)
+	assert.Equal(t, "GRD", patched.Currencies[0].Code)
+
+	removeReqRecorder := newRequestRecorder(jsonRequest("PATCH", "/countries/greece", "application/json", `{"region": null}`), handler)
+	assert.Equal(t, http.StatusOK, removeReqRecorder.Code)
+	assert.Equal(t, "", constructCountryFromJson(removeReqRecorder.Body.String()).Region)
+}
+
+func TestPostCountryWithNullCurrencies(t *testing.T) {
+	handler := initializeHandlers()
+	recorder := newRequestRecorder(jsonRequest("POST", "/countries", "application/json", `{"name": "Greece", "alpha2Code": "GR", "currencies": null}`), handler)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	getReqRecorder := newRequestRecorder(jsonRequest("GET", "/countries/greece", "", ""), handler)
+	assert.Equal(t, "GR", constructCountryFromJson(getReqRecorder.Body.String()).Alpha2Code)
+}
+
+func TestPatchCountryValidatesTheResult(t *testing.T) {
+	handler := initializeHandlers()
+	addCountry(handler, `{"name": "Greece", "alpha2Code": "GR"}`)
+
+	requiredRecorder := newRequestRecorder(jsonRequest("PATCH", "/countries/greece", "application/merge-patch+json", `{"alpha2Code": null}`), handler)
+	assert.Equal(t, http.StatusBadRequest, requiredRecorder.Code)
+	assert.Equal(t, []schema.Violation{{Pointer: "/alpha2Code", Message: "is required"}}, constructValidationProblemFromJson(requiredRecorder.Body.String()).Errors)
+
+	currencyRecorder := newRequestRecorder(jsonRequest("PATCH", "/countries/greece", "application/merge-patch+json", `{"currencies": [{"code": "euro"}]}`), handler)
+	assert.Equal(t, http.StatusBadRequest, currencyRecorder.Code)
+	assert.Equal(t, "/currencies/0/code", constructValidationProblemFromJson(currencyRecorder.Body.String()).Errors[0].Pointer)
+
+	renameRecorder := newRequestRecorder(jsonRequest("PATCH", "/countries/greece", "application/merge-patch+json", `{"name": "Hellas"}`), handler)
+	assert.Equal(t, http.StatusBadRequest, renameRecorder.Code)
+
+	missingRecorder := newRequestRecorder(jsonRequest("PATCH", "/countries/spain", "application/merge-patch+json", `{"capital": "Madrid"}`), handler)
+	assert.Equal(t, http.StatusNotFound, missingRecorder.Code)
+
+	getReq, _ := http.NewRequest("GET", "/countries/greece", nil)
+	assert.Equal(t, "GR", constructCountryFromJson(newRequestRecorder(getReq, handler).Body.String()).Alpha2Code)
+}
+
+/**
+A store where another writer changes a country right after the first read of it.
+*/
+type racingStorage struct {
+	*store.CountriesStorage
+	raced bool
+}
+
+func (r *racingStorage) GetCountryById(ctx context.Context, id string) (*models.Country, error) {
+	country, err := r.CountriesStorage.GetCountryById(ctx, id)
+	if err == nil && !r.raced {
+		r.raced = true
+		changed := *country
+		changed.Capital = "Thessaloniki"
+		r.CountriesStorage.AddCountry(ctx, changed)
+	}
+	return country, err
+}
+
+func TestPatchCountryFailsOnConcurrentChanges(t *testing.T) {
+	server := initializeServer()
+	handler := server.handler()
+	addCountry(handler, greeceBody)
+	server.Actions = &racingStorage{CountriesStorage: server.Actions.(*store.CountriesStorage)}
+
+	patchReqRecorder := newRequestRecorder(jsonRequest("PATCH", "/countries/greece", "application/merge-patch+json", `{"region": "Europe"}`), handler)
+	assert.Equal(t, http.StatusConflict, patchReqRecorder.Code)
+
+	getReqRecorder := newRequestRecorder(jsonRequest("GET", "/countries/greece", "", ""), handler)
+	assert.Equal(t, "Thessaloniki", constructCountryFromJson(getReqRecorder.Body.String()).Capital)
+	assert.Equal(t, "", constructCountryFromJson(getReqRecorder.Body.String()).Region)
+}
+
+func TestMergePatch(t *testing.T) {
+	target := map[string]interface{}{"a": "b", "c": map[string]interface{}{"d": "e", "f": "g"}}
+	patch := map[string]interface{}{"a": "z", "c": map[string]interface{}{"f": nil}, "h": []interface{}{"i"}}
+	assert.Equal(t, map[string]interface{}{"a": "z", "c": map[string]interface{}{"d": "e"}, "h": []interface{}{"i"}}, mergePatch(target, patch))
+	assert.Equal(t, "b", target["a"])
+	assert.Equal(t, []interface{}{"x"}, mergePatch(target, []interface{}{"x"}))
+}
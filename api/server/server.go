@@ -1,14 +1,28 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"go-countries-rest-api/api/audit"
+	"go-countries-rest-api/api/auth"
+	"go-countries-rest-api/api/compression"
+	"go-countries-rest-api/api/cors"
+	"go-countries-rest-api/api/idempotency"
+	"go-countries-rest-api/api/ratelimit"
+	"go-countries-rest-api/api/router"
+	"go-countries-rest-api/api/snapshot"
 	"go-countries-rest-api/api/store"
 	model "go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/upstream"
 	utils "go-countries-rest-api/api/utils"
+	"go-countries-rest-api/api/webhooks"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 /**
@@ -20,16 +34,83 @@ use your own locally-scoped ServeMux, like we have been so far.
 Check section "The DefaultServeMux" on article.
 */
 func (s *Server) Initialize(port string) {
-	s.initializeRoutes()
-	err := http.ListenAndServe(port, s.Mux)
+	err := http.ListenAndServe(port, s.Handler())
 	if err != nil {
 		panic(err)
 	}
 }
 
+/**
+Register the routes and return them wrapped with the middlewares, to serve them
+with another http.Server or an httptest.Server. The routes are registered once,
+later calls only wrap them again.
+*/
+func (s *Server) Handler() http.Handler {
+	if s.router == nil {
+		s.initializeRoutes()
+	}
+	return s.handler()
+}
+
 type Server struct {
 	Mux     *http.ServeMux
 	Actions store.Actions
+
+	// Auth is optional. When nil, authentication and authorization are disabled
+	Auth *auth.Authenticator
+
+	// RateLimits is optional. When nil, requests are not rate limited
+	RateLimits *ratelimit.Config
+
+	// CORS is optional. When nil, no CORS headers are emitted
+	CORS *cors.Config
+
+	// Compression is optional. When nil, responses are never compressed
+	Compression *compression.Config
+
+	// max-age of the Cache-Control header on GET responses, zero means "no-cache"
+	CacheMaxAge time.Duration
+
+	// Webhooks is optional. When nil, the /webhooks routes respond with 501
+	Webhooks *webhooks.Dispatcher
+
+	// Audit is optional. When nil, mutations are not audited and /audit responds with 501
+	Audit *audit.Log
+
+	// Idempotency is optional. When nil, the Idempotency-Key header is ignored
+	Idempotency *idempotency.Store
+
+	// Sync is optional. When nil, the store does not track an upstream and /admin/sync responds with 501
+	Sync *upstream.Syncer
+
+	// Snapshots is optional. When nil, the /admin/snapshots routes respond with 501
+	Snapshots *snapshot.Store
+
+	router *router.Router
+}
+
+/**
+Wrap the routes with the middlewares configured on the server.
+The first middleware applied is the innermost one.
+*/
+func (s *Server) handler() http.Handler {
+	var handler http.Handler = s.Mux
+	if s.Idempotency != nil {
+		handler = s.idempotent(handler)
+	}
+	if s.Compression != nil {
+		handler = s.compress(handler)
+	}
+	if s.Auth != nil {
+		handler = s.authenticate(handler)
+	}
+	if s.RateLimits != nil {
+		handler = s.rateLimit(handler)
+	}
+	if s.CORS != nil {
+		handler = s.cors(handler)
+	}
+	return requestId(handler)
 }
 
 /*
@@ -37,9 +118,29 @@ Method pointer receiver to countriesHandler
 https://tour.golang.org/methods/4
 */
 func (s *Server) get(writer http.ResponseWriter, request *http.Request) {
-	countries, _ := s.Actions.GetAllCountries()
+	query, err := parseListQuery(request.URL.Query())
+	if err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if s.notModified(writer, request) {
+		return
+	}
+
+	countries, err := s.Actions.GetAllCountries(request.Context())
+	if err != nil {
+		writeStoreError(writer, err)
+		return
+	}
+
+	page, total := query.page(*countries)
+	writer.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if next := query.next(request.URL, total); next != "" {
+		writer.Header().Set("Link", next)
+	}
 
-	jsonBytes, err := json.Marshal(countries)
+	jsonBytes, err := json.Marshal(page)
 	if err != nil {
 		utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
 		return
@@ -48,16 +149,56 @@ func (s *Server) get(writer http.ResponseWriter, request *http.Request) {
 	utils.ConstructSuccessfulResponse(writer, http.StatusOK, jsonBytes)
 }
 
+/**
+Handle requests like
+GET /countries/random?currency=EUR&region=Europe&exclude=greece,spain&count=3&seed=42&mode=body
+see randomQuery for the parameters
+*/
 func (s *Server) getRandomCountry(writer http.ResponseWriter, request *http.Request) {
-	target, err := s.Actions.GetRandomCountryId()
-	if err!=nil {
+	query, err := parseRandomQuery(request.URL.Query())
+	if err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if query.plain() && !query.body {
+		target, err := s.Actions.GetRandomCountryId(request.Context())
+		if err != nil && !errors.Is(err, store.ErrNoCountries) {
+			writeStoreError(writer, err)
+			return
+		}
+		if err != nil {
+			utils.ConstructErrorResponse(writer, "No countries available to choose randomly", http.StatusNotFound)
+			return
+		}
+
+		//redirect
+		writer.Header().Add("location", fmt.Sprintf("/countries/%s", *target))
+		writer.WriteHeader(http.StatusFound)
+		return
+	}
+
+	countries, err := s.Actions.GetAllCountries(request.Context())
+	if err != nil {
+		writeStoreError(writer, err)
+		return
+	}
+
+	picked := query.pick(*countries)
+	if len(picked) == 0 {
 		utils.ConstructErrorResponse(writer, "No countries available to choose randomly", http.StatusNotFound)
 		return
 	}
 
-	//redirect
-	writer.Header().Add("location", fmt.Sprintf("/countries/%s", *target))
-	writer.WriteHeader(http.StatusFound)
+	switch {
+	case query.count > 1:
+		s.writeJson(writer, http.StatusOK, picked)
+	case query.body:
+		s.writeJson(writer, http.StatusOK, picked[0])
+	default:
+		writer.Header().Add("location", fmt.Sprintf("/countries/%s", strings.ToLower(picked[0].Name)))
+		writer.WriteHeader(http.StatusFound)
+	}
 }
 
 /**
@@ -65,23 +206,25 @@ Handle requests with path "/countries/{id}" like
 GET /countries/{id}
  */
 func (s *Server) getCountry(writer http.ResponseWriter, request *http.Request) {
-	parts := strings.Split(request.URL.String(), "/")
-	if len(parts) != 3 {
-		utils.ConstructErrorResponse(writer, "Wrong number of parts on URL path", http.StatusNotFound)
+	if request.URL.Query().Get("asOf") != "" {
+		s.getCountryAsOf(writer, request)
 		return
 	}
 
-	if parts[2] == "random" {
-		s.getRandomCountry(writer, request)
+	country, notFoundError := s.Actions.GetCountryById(request.Context(), pathParam(request, "id"))
+	if notFoundError != nil && !errors.Is(notFoundError, store.ErrCountryNotFound) {
+		writeStoreError(writer, notFoundError)
 		return
 	}
-
-	country, notFoundError := s.Actions.GetCountryById(parts[2])
 	if notFoundError!=nil {
 		utils.ConstructErrorResponse(writer, "Country not found", http.StatusNotFound)
 		return
 	}
 
+	if s.notModified(writer, request) {
+		return
+	}
+
 	jsonBytes, err := json.Marshal(country)
 	if err != nil {
 		utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
@@ -106,65 +249,109 @@ func (s *Server) post(writer http.ResponseWriter, request *http.Request) {
 	}
 
 	var country model.Country
-	err = json.Unmarshal(bodyBytes, &country)
-	if err != nil {
+	if !decodeValid(writer, bodyBytes, &country) {
+		return
+	}
+	if err := reservedCountryId(country.Name); err != nil {
 		utils.ConstructErrorResponse(writer, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	s.Actions.AddCountry(country)
+	before, added, err := s.swapCountry(request.Context(), country)
+	if err != nil {
+		writeStoreError(writer, err)
+		return
+	}
+	s.recordAudit(request, country.Name, before, added)
 }
 
 /**
-Handle (delete) requests with path "/countries/{id}" like
-DELETE /countries/{id}
+Handle requests with path "/countries/{id}" like
+PUT /countries/{id}
+Replaces the country, or adds it when missing. The name of the country must be the id.
 */
-func (s *Server) deleteCountry(writer http.ResponseWriter, request *http.Request) {
-	parts := strings.Split(request.URL.String(), "/")
-	if len(parts) != 3 {
-		utils.ConstructErrorResponse(writer, "Wrong number of parts on URL path", http.StatusNotFound)
+func (s *Server) putCountry(writer http.ResponseWriter, request *http.Request) {
+	bodyBytes, err := ioutil.ReadAll(request.Body)
+	defer request.Body.Close()
+	if err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	s.Actions.DeleteCountry(parts[2])
+	ct := request.Header.Get("content-type")
+	if ct != "application/json" {
+		utils.ConstructErrorResponse(writer, fmt.Sprintf("need content-type 'application/json', but got '%s'", ct), http.StatusUnsupportedMediaType)
+		return
+	}
 
-	utils.ConstructSuccessfulResponse(writer, http.StatusOK, nil)
+	var country model.Country
+	if !decodeValid(writer, bodyBytes, &country) {
+		return
+	}
+	s.saveCountry(writer, request, country)
 }
 
 /**
-Handle requests with path "/countries" like
-GET /countries
-POST /countries
- */
-func (s *Server) countries(writer http.ResponseWriter, request *http.Request) {
-	switch request.Method {
-	case "GET":
-		s.get(writer, request)
+Save the country replacing the one of the id of the request, responding 201 when
+it is added and 200 when it is replaced
+*/
+func (s *Server) saveCountry(writer http.ResponseWriter, request *http.Request, country model.Country) {
+	id := strings.ToLower(pathParam(request, "id"))
+	if strings.ToLower(country.Name) != id {
+		utils.ConstructErrorResponse(writer, "The name of the country does not match the id", http.StatusBadRequest)
 		return
-	case "POST":
-		s.post(writer, request)
+	}
+	if err := reservedCountryId(id); err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusBadRequest)
 		return
-	default:
-		utils.ConstructErrorResponse(writer, "method not allowed", http.StatusMethodNotAllowed)
+	}
+
+	before, saved, err := s.swapCountry(request.Context(), country)
+	if err != nil {
+		writeStoreError(writer, err)
 		return
 	}
+	s.recordAudit(request, id, before, saved)
+
+	statusCode := http.StatusOK
+	if before == nil {
+		statusCode = http.StatusCreated
+	}
+	s.writeJson(writer, statusCode, saved)
 }
 
 /**
-Handle requests with path "/countries" like
-GET /countries
-POST /countries
+Handle (delete) requests with path "/countries/{id}" like
+DELETE /countries/{id}
+Stores implementing store.Trash keep the country in their trash.
 */
-func (s *Server) countryById(writer http.ResponseWriter, request *http.Request) {
-	switch request.Method {
-	case "GET":
-		s.getCountry(writer, request)
+func (s *Server) deleteCountry(writer http.ResponseWriter, request *http.Request) {
+	id := pathParam(request, "id")
+	before, err := s.takeCountry(request.Context(), id)
+	if errors.Is(err, store.ErrCountryNotFound) {
+		utils.ConstructErrorResponse(writer, "Country not found", http.StatusNotFound)
 		return
-	case "DELETE":
-		s.deleteCountry(writer, request)
+	}
+	if err != nil {
+		writeStoreError(writer, err)
 		return
+	}
+	s.recordAudit(request, id, before, nil)
+
+	utils.ConstructSuccessfulResponse(writer, http.StatusOK, nil)
+}
+
+/**
+Respond to an unexpected error of the store. A deadline exceeded gets a 504,
+a request cancelled by the client a 503 it will most likely never read.
+*/
+func writeStoreError(writer http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		utils.ConstructErrorResponse(writer, "Store did not respond in time", http.StatusGatewayTimeout)
+	case errors.Is(err, context.Canceled):
+		utils.ConstructErrorResponse(writer, "Request cancelled", http.StatusServiceUnavailable)
 	default:
-		utils.ConstructErrorResponse(writer, "method not allowed", http.StatusMethodNotAllowed)
-		return
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
 	}
 }
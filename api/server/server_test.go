@@ -150,9 +150,9 @@ func TestAddTwoCountriesAndDeleteSpecificCountry(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, getSpainReqRecorder.Code)
 }
 
-func TestPatchVerbIsNotSupportedForCountryByIdPath(t *testing.T) {
+func TestPostVerbIsNotSupportedForCountryByIdPath(t *testing.T) {
 	mux := initializeHandlers()
-	getAllReq, _ := http.NewRequest("PATCH", "/countries/greece", nil)
+	getAllReq, _ := http.NewRequest("POST", "/countries/greece", nil)
 	getAllReqRecorder := newRequestRecorder(getAllReq, mux)
 	assert.Equal(t, http.StatusMethodNotAllowed, getAllReqRecorder.Code)
 	assert.Equal(t, "method not allowed", getAllReqRecorder.Body.String())
@@ -204,6 +204,40 @@ func TestNoCountryAddedAndGetRandomCountry(t *testing.T) {
 	assert.Equal(t, "No countries available to choose randomly", getRandomReqRecorder.Body.String())
 }
 
+func TestHandlerRegistersTheRoutesOnce(t *testing.T) {
+	server := &Server{Mux: http.NewServeMux(), Actions: store.NewCountriesStorage()}
+	server.Handler()
+	handler := server.Handler()
+
+	getAllReq, _ := http.NewRequest("GET", "/countries", nil)
+	assert.Equal(t, http.StatusOK, newRequestRecorder(getAllReq, handler).Code)
+}
+
+func TestCountriesShadowedByRoutesAreRejected(t *testing.T) {
+	mux := initializeHandlers()
+	for _, name := range []string{"Trash", "random"} {
+		postReq := jsonRequest("POST", "/countries", "application/json", `{"name": "`+name+`", "alpha2Code": "TR"}`)
+		postReqRecorder := newRequestRecorder(postReq, mux)
+		assert.Equal(t, http.StatusBadRequest, postReqRecorder.Code)
+		assert.Equal(t, "The id '"+strings.ToLower(name)+"' is reserved by the route /countries/"+strings.ToLower(name), postReqRecorder.Body.String())
+	}
+
+	getAllReq, _ := http.NewRequest("GET", "/countries", nil)
+	assert.Equal(t, "[]", newRequestRecorder(getAllReq, mux).Body.String())
+}
+
+func TestReservedCountryIdsAreTheLiteralRoutesOfCountries(t *testing.T) {
+	server := initializeServer()
+	literals := []string{}
+	for _, route := range server.router.Routes() {
+		segments := strings.Split(strings.Trim(route.Pattern, "/"), "/")
+		if len(segments) == 2 && segments[0] == "countries" && !strings.Contains(segments[1], "{") && !containsString(literals, segments[1]) {
+			literals = append(literals, segments[1])
+		}
+	}
+	assert.ElementsMatch(t, reservedCountryIds, literals)
+}
+
 func constructCountryFromJson(jsonData string) *model.Country {
 	country := &model.Country{}
 	json.Unmarshal([]byte(jsonData), country)
@@ -226,7 +260,7 @@ func getIndexForCountry(countries *[]model.Country, countryName string) (int, er
 }
 
 // Mocks a handler and returns a httptest.ResponseRecorder
-func newRequestRecorder(req *http.Request, mux *http.ServeMux) *httptest.ResponseRecorder {
+func newRequestRecorder(req *http.Request, mux http.Handler) *httptest.ResponseRecorder {
 	// We create a ResponseRecorder (which satisfies http.ResponseWriter) to record the response.
 	rr := httptest.NewRecorder()
 	// Our handlers satisfy http.Handler, so we can call their ServeHTTP method
@@ -236,13 +270,16 @@ func newRequestRecorder(req *http.Request, mux *http.ServeMux) *httptest.Respons
 }
 
 func initializeHandlers() *http.ServeMux {
+	return initializeServer().Mux
+}
+
+func initializeServer() *Server {
 	mux := http.NewServeMux()
 	countriesStorage := store.NewCountriesStorage()
-	server := Server{
+	server := &Server{
 		Mux:     mux,
 		Actions: countriesStorage,
 	}
-	mux.HandleFunc("/countries", server.countries)
-	mux.HandleFunc("/countries/", server.countryById)
-	return mux
+	server.initializeRoutes()
+	return server
 }
\ No newline at end of file
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-countries-rest-api/api/dataset"
+	"go-countries-rest-api/api/snapshot"
+	utils "go-countries-rest-api/api/utils"
+	"io/ioutil"
+	"net/http"
+)
+
+/**
+The response to a restore, the ids in the summary are the countries it changed
+*/
+type restoreResult struct {
+	Snapshot snapshot.Info   `json:"snapshot"`
+	Summary  dataset.Summary `json:"summary"`
+}
+
+/**
+The optional body of a restore
+*/
+type restoreRequest struct {
+	Checksum string `json:"checksum" description:"The checksum the snapshot must have, e.g. sha256:9f86d0..."`
+}
+
+/**
+Respond with 501 when no snapshot store is configured
+*/
+func (s *Server) snapshotsEnabled(writer http.ResponseWriter) bool {
+	if s.Snapshots == nil {
+		utils.ConstructErrorResponse(writer, "Snapshots are not enabled", http.StatusNotImplemented)
+		return false
+	}
+	return true
+}
+
+/**
+Handle requests like
+POST /admin/snapshots
+*/
+func (s *Server) captureSnapshot(writer http.ResponseWriter, request *http.Request) {
+	if !s.snapshotsEnabled(writer) {
+		return
+	}
+	info, err := s.Snapshots.Capture(request.Context(), s.Actions)
+	if err != nil {
+		writeStoreError(writer, err)
+		return
+	}
+	writer.Header().Set("Location", fmt.Sprintf("/admin/snapshots/%s", info.ID))
+	s.writeJson(writer, http.StatusCreated, info)
+}
+
+/**
+Handle requests like
+GET /admin/snapshots
+*/
+func (s *Server) listSnapshots(writer http.ResponseWriter, request *http.Request) {
+	if s.snapshotsEnabled(writer) {
+		s.writeJson(writer, http.StatusOK, s.Snapshots.List())
+	}
+}
+
+/**
+Handle requests like
+GET /admin/snapshots/{id}
+The snapshot with its countries, as a file to download.
+*/
+func (s *Server) getSnapshot(writer http.ResponseWriter, request *http.Request) {
+	if !s.snapshotsEnabled(writer) {
+		return
+	}
+	found, err := s.Snapshots.Get(pathParam(request, "id"))
+	if err != nil {
+		writeSnapshotError(writer, err)
+		return
+	}
+	writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="snapshot-%s.json"`, found.ID))
+	s.writeJson(writer, http.StatusOK, found)
+}
+
+/**
+Handle requests like
+POST /admin/snapshots/{id}:restore
+with an optional body {"checksum": "sha256:..."} the checksum of the snapshot must match.
+Every country changed by the restore is audited.
+*/
+func (s *Server) restoreSnapshot(writer http.ResponseWriter, request *http.Request) {
+	if !s.snapshotsEnabled(writer) {
+		return
+	}
+	bodyBytes, err := ioutil.ReadAll(request.Body)
+	defer request.Body.Close()
+	if err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var body restoreRequest
+	if len(bodyBytes) > 0 {
+		if err := json.Unmarshal(bodyBytes, &body); err != nil {
+			utils.ConstructErrorResponse(writer, fmt.Sprintf("Malformed body: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	restored, replacements, err := s.Snapshots.Restore(request.Context(), s.Actions, pathParam(request, "id"), body.Checksum)
+	if err != nil {
+		writeSnapshotError(writer, err)
+		return
+	}
+
+	for _, replacement := range replacements {
+		s.recordAudit(request, replacement.Id, replacement.Before, replacement.After)
+	}
+	s.writeJson(writer, http.StatusOK, restoreResult{Snapshot: restored.Info, Summary: dataset.Summarize(replacements)})
+}
+
+func writeSnapshotError(writer http.ResponseWriter, err error) {
+	switch err {
+	case snapshot.ErrSnapshotNotFound:
+		utils.ConstructErrorResponse(writer, "Snapshot not found", http.StatusNotFound)
+	case snapshot.ErrChecksumMismatch:
+		utils.ConstructErrorResponse(writer, "Checksum does not match the snapshot", http.StatusConflict)
+	case snapshot.ErrNotReplaceable:
+		utils.ConstructErrorResponse(writer, "Restoring snapshots is not supported by the store", http.StatusNotImplemented)
+	default:
+		writeStoreError(writer, err)
+	}
+}
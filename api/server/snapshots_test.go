@@ -0,0 +1,123 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/audit"
+	"go-countries-rest-api/api/auth"
+	"go-countries-rest-api/api/dataset"
+	"go-countries-rest-api/api/snapshot"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func initializeServerWithSnapshots() (*Server, http.Handler) {
+	server := initializeServer()
+	server.Snapshots = snapshot.NewStore(0)
+	server.Audit = audit.NewLog()
+	return server, server.handler()
+}
+
+func captureSnapshot(handler http.Handler) (*httptest.ResponseRecorder, snapshot.Info) {
+	response := newRequestRecorder(httptest.NewRequest("POST", "/admin/snapshots", nil), handler)
+	var info snapshot.Info
+	json.Unmarshal(response.Body.Bytes(), &info)
+	return response, info
+}
+
+func TestCaptureListAndDownloadSnapshots(t *testing.T) {
+	_, handler := initializeServerWithSnapshots()
+	addCountry(handler, spainBody)
+	addCountry(handler, greeceBody)
+
+	response, info := captureSnapshot(handler)
+	assert.Equal(t, http.StatusCreated, response.Code)
+	assert.Equal(t, "/admin/snapshots/"+info.ID, response.Header().Get("Location"))
+	assert.Equal(t, 2, info.Count)
+
+	response = newRequestRecorder(httptest.NewRequest("GET", "/admin/snapshots", nil), handler)
+	var infos []snapshot.Info
+	json.Unmarshal(response.Body.Bytes(), &infos)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, []string{info.ID}, []string{infos[0].ID})
+	assert.NotContains(t, response.Body.String(), "countries")
+
+	response = newRequestRecorder(httptest.NewRequest("GET", "/admin/snapshots/"+info.ID, nil), handler)
+	var downloaded snapshot.Snapshot
+	json.Unmarshal(response.Body.Bytes(), &downloaded)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, `attachment; filename="snapshot-`+info.ID+`.json"`, response.Header().Get("Content-Disposition"))
+	assert.Equal(t, info.Checksum, downloaded.Checksum)
+	assert.Equal(t, []string{"Greece", "Spain"}, []string{downloaded.Countries[0].Name, downloaded.Countries[1].Name})
+
+	response = newRequestRecorder(httptest.NewRequest("GET", "/admin/snapshots/missing", nil), handler)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.Equal(t, "Snapshot not found", response.Body.String())
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	server, handler := initializeServerWithSnapshots()
+	addCountry(handler, spainBody)
+	addCountry(handler, greeceBody)
+	_, info := captureSnapshot(handler)
+
+	newRequestRecorder(httptest.NewRequest("DELETE", "/countries/spain", nil), handler)
+	addCountry(handler, japanBody)
+	addCountry(handler, strings.Replace(greeceBody, "Athens", "Nafplio", 1))
+
+	request := httptest.NewRequest("POST", "/admin/snapshots/"+info.ID+":restore", strings.NewReader(`{"checksum": "`+info.Checksum+`"}`))
+	response := newRequestRecorder(request, handler)
+	var result restoreResult
+	json.Unmarshal(response.Body.Bytes(), &result)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, info.ID, result.Snapshot.ID)
+	assert.Equal(t, dataset.Summary{Added: []string{"spain"}, Removed: []string{"japan"}, Changed: []string{"greece"}}, result.Summary)
+
+	response = newRequestRecorder(httptest.NewRequest("GET", "/countries", nil), handler)
+	countries := *constructCountriesFromJson(response.Body.String())
+	assert.Equal(t, 2, len(countries))
+	assert.Equal(t, "Athens", countries[0].Capital)
+
+	entries := server.Audit.Query(audit.Query{CountryId: "japan"})
+	assert.Equal(t, audit.Delete, entries[len(entries)-1].Operation)
+}
+
+func TestRestoreSnapshotVerifiesTheChecksum(t *testing.T) {
+	_, handler := initializeServerWithSnapshots()
+	addCountry(handler, greeceBody)
+	_, info := captureSnapshot(handler)
+	addCountry(handler, spainBody)
+
+	request := httptest.NewRequest("POST", "/admin/snapshots/"+info.ID+":restore", strings.NewReader(`{"checksum": "sha256:0000"}`))
+	response := newRequestRecorder(request, handler)
+	assert.Equal(t, http.StatusConflict, response.Code)
+	assert.Equal(t, "Checksum does not match the snapshot", response.Body.String())
+
+	request = httptest.NewRequest("POST", "/admin/snapshots/"+info.ID+":restore", strings.NewReader(`sha256`))
+	response = newRequestRecorder(request, handler)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	response = newRequestRecorder(httptest.NewRequest("GET", "/countries", nil), handler)
+	assert.Equal(t, 2, len(*constructCountriesFromJson(response.Body.String())))
+
+	response = newRequestRecorder(httptest.NewRequest("POST", "/admin/snapshots/"+info.ID+":restore", nil), handler)
+	assert.Equal(t, http.StatusOK, response.Code)
+}
+
+func TestSnapshotsWhenDisabled(t *testing.T) {
+	response := newRequestRecorder(httptest.NewRequest("POST", "/admin/snapshots", nil), initializeHandlers())
+	assert.Equal(t, http.StatusNotImplemented, response.Code)
+	assert.Equal(t, "Snapshots are not enabled", response.Body.String())
+}
+
+func TestSnapshotsAreReservedToAdmins(t *testing.T) {
+	server := initializeServerWithAuth(auth.RoleNone)
+	server.Snapshots = snapshot.NewStore(0)
+	request := httptest.NewRequest("POST", "/admin/snapshots", nil)
+	request.Header.Set("X-API-Key", "editor-key")
+
+	response := newRequestRecorder(request, server.handler())
+	assert.Equal(t, http.StatusForbidden, response.Code)
+}
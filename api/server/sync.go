@@ -0,0 +1,19 @@
+package server
+
+import (
+	utils "go-countries-rest-api/api/utils"
+	"net/http"
+)
+
+/**
+Handle requests with path "/admin/sync" like
+GET /admin/sync
+Responds with the status of the sync and the ids changed by the last one.
+*/
+func (s *Server) getSyncStatus(writer http.ResponseWriter, request *http.Request) {
+	if s.Sync == nil {
+		utils.ConstructErrorResponse(writer, "Sync is not enabled", http.StatusNotImplemented)
+		return
+	}
+	s.writeJson(writer, http.StatusOK, s.Sync.Status())
+}
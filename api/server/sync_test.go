@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/audit"
+	"go-countries-rest-api/api/auth"
+	"go-countries-rest-api/api/upstream"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestGetSyncStatus(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(`[` + spainBody + `, {"name": "Greece", "alpha2Code": "GR", "capital": "Athens"}]`))
+	}))
+	defer source.Close()
+	server := initializeServer()
+	handler := server.handler()
+	addCountry(handler, greeceBody)
+	server.Sync = upstream.NewSyncer(upstream.Config{Source: source.URL}, server.Actions)
+
+	response := newRequestRecorder(httptest.NewRequest("GET", "/admin/sync", nil), handler)
+	var status upstream.Status
+	json.Unmarshal(response.Body.Bytes(), &status)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, 0, status.Syncs)
+	assert.Nil(t, status.Summary)
+
+	server.Sync.Sync(context.Background())
+	response = newRequestRecorder(httptest.NewRequest("GET", "/admin/sync", nil), handler)
+	json.Unmarshal(response.Body.Bytes(), &status)
+	assert.Equal(t, 1, status.Syncs)
+	assert.Equal(t, source.URL, status.Source)
+	assert.Equal(t, &upstream.Summary{Added: []string{"spain"}, Removed: []string{}, Changed: []string{"greece"}}, status.Summary)
+
+	response = newRequestRecorder(httptest.NewRequest("GET", "/countries/greece", nil), handler)
+	assert.Equal(t, "Athens", constructCountryFromJson(response.Body.String()).Capital)
+}
+
+func TestGetSyncStatusWhenSyncIsDisabled(t *testing.T) {
+	response := newRequestRecorder(httptest.NewRequest("GET", "/admin/sync", nil), initializeHandlers())
+	assert.Equal(t, http.StatusNotImplemented, response.Code)
+	assert.Equal(t, "Sync is not enabled", response.Body.String())
+}
+
+func TestGetSyncStatusIsReservedToAdmins(t *testing.T) {
+	handler := initializeServerWithAuth(auth.RoleNone).handler()
+	request := httptest.NewRequest("GET", "/admin/sync", nil)
+	request.Header.Set("X-API-Key", "editor-key")
+
+	response := newRequestRecorder(request, handler)
+	assert.Equal(t, http.StatusForbidden, response.Code)
+}
+
+func TestSyncedChangesAreAudited(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(`[` + spainBody + `, {"name": "Greece", "alpha2Code": "GR", "capital": "Athens"}]`))
+	}))
+	defer source.Close()
+	server := initializeServer()
+	server.Audit = audit.NewLog()
+	handler := server.handler()
+	addCountry(handler, greeceBody)
+	addCountry(handler, japanBody)
+	server.Sync = upstream.NewSyncer(upstream.Config{Source: source.URL, OnApplied: server.AuditSync(source.URL)}, server.Actions)
+	server.Sync.Sync(context.Background())
+
+	response := newRequestRecorder(httptest.NewRequest("GET", "/audit?actor="+url.QueryEscape("sync:"+source.URL), nil), handler)
+	var entries []audit.Entry
+	json.Unmarshal(response.Body.Bytes(), &entries)
+	assert.Equal(t, http.StatusOK, response.Code)
+	operations := map[string]audit.Operation{}
+	for _, entry := range entries {
+		operations[entry.CountryId] = entry.Operation
+	}
+	assert.Equal(t, map[string]audit.Operation{"spain": audit.Add, "greece": audit.Update, "japan": audit.Delete}, operations)
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/store"
+	utils "go-countries-rest-api/api/utils"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+/**
+One operation of a POST /transactions body. "add" and "update" carry a country,
+"delete" carries the id of the country.
+*/
+type transactionOperation struct {
+	Op      string          `json:"op"`
+	ID      string          `json:"id,omitempty"`
+	Country *models.Country `json:"country,omitempty"`
+}
+
+type transactionRequest struct {
+	Operations []transactionOperation `json:"operations"`
+}
+
+/**
+Handle requests like
+POST /transactions
+{"operations": [{"op": "add", "country": {...}}, {"op": "update", "country": {...}}, {"op": "delete", "id": "spain"}]}
+The operations are applied all or nothing. When one of them can not be applied the response
+is 409 and the store is left unchanged.
+*/
+func (s *Server) postTransaction(writer http.ResponseWriter, request *http.Request) {
+	transactional, ok := s.Actions.(store.Transactional)
+	if !ok {
+		utils.ConstructErrorResponse(writer, "Transactions are not supported by the store", http.StatusNotImplemented)
+		return
+	}
+
+	bodyBytes, err := ioutil.ReadAll(request.Body)
+	defer request.Body.Close()
+	if err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ct := request.Header.Get("content-type")
+	if ct != "application/json" {
+		utils.ConstructErrorResponse(writer, fmt.Sprintf("need content-type 'application/json', but got '%s'", ct), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var body transactionRequest
+	if !decodeValid(writer, bodyBytes, &body) {
+		return
+	}
+	if len(body.Operations) == 0 {
+		utils.ConstructErrorResponse(writer, "A transaction needs at least one operation", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := transactional.Begin()
+	if err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for i := range body.Operations {
+		if err := stageOperation(tx, &body.Operations[i]); err != nil {
+			tx.Rollback()
+			utils.ConstructErrorResponse(writer, fmt.Sprintf("operation %d: %s", i, err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	replacements, err := tx.Commit()
+	var operationError *store.OperationError
+	if errors.As(err, &operationError) {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, replacement := range replacements {
+		s.recordAudit(request, replacement.Id, replacement.Before, replacement.After)
+	}
+	s.writeJson(writer, http.StatusOK, body.Operations)
+}
+
+/**
+Stage an operation and set its id
+*/
+func stageOperation(tx store.Transaction, operation *transactionOperation) error {
+	switch operation.Op {
+	case "add", "update":
+		if operation.Country == nil || operation.Country.Name == "" {
+			return errors.New("a country with a name is required")
+		}
+		if err := reservedCountryId(operation.Country.Name); err != nil {
+			return err
+		}
+		operation.ID = strings.ToLower(operation.Country.Name)
+	case "delete":
+		if operation.ID == "" {
+			return errors.New("an id is required")
+		}
+		operation.ID = strings.ToLower(operation.ID)
+		operation.Country = nil
+	default:
+		return fmt.Errorf("unknown op '%s', expected 'add', 'update' or 'delete'", operation.Op)
+	}
+
+	switch operation.Op {
+	case "add":
+		return tx.AddCountry(*operation.Country)
+	case "update":
+		return tx.UpdateCountry(*operation.Country)
+	default:
+		return tx.DeleteCountry(operation.ID)
+	}
+}
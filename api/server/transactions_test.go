@@ -0,0 +1,70 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/audit"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPostTransactionAppliesAllOperations(t *testing.T) {
+	server := initializeServer()
+	server.Audit = audit.NewLog()
+	handler := server.handler()
+	addCountry(handler, greeceBody)
+
+	body := `{"operations": [
+		{"op": "update", "country": {"name": "Greece", "alpha2Code": "GR", "capital": "Nafplio"}},
+		{"op": "add", "country": {"name": "Spain", "alpha2Code": "ES", "capital": "Madrid"}},
+		{"op": "delete", "id": "Greece"}
+	]}`
+	txReq, _ := http.NewRequest("POST", "/transactions", strings.NewReader(body))
+	txReq.Header.Add("Content-Type", "application/json")
+	txReqRecorder := newRequestRecorder(txReq, handler)
+	assert.Equal(t, http.StatusOK, txReqRecorder.Code)
+
+	getAllReq, _ := http.NewRequest("GET", "/countries", nil)
+	countries := constructCountriesFromJson(newRequestRecorder(getAllReq, handler).Body.String())
+	assert.Equal(t, 1, len(*countries))
+	assert.Equal(t, "Spain", (*countries)[0].Name)
+
+	entries := server.Audit.Query(audit.Query{})
+	assert.Equal(t, 4, len(entries))
+	assert.Equal(t, audit.Update, entries[1].Operation)
+	assert.Equal(t, audit.Add, entries[2].Operation)
+	assert.Equal(t, audit.Delete, entries[3].Operation)
+	assert.Equal(t, "Nafplio", entries[3].Changes[2].Before)
+}
+
+func TestPostTransactionIsAllOrNothing(t *testing.T) {
+	handler := initializeHandlers()
+	body := `{"operations": [
+		{"op": "add", "country": {"name": "Spain", "alpha2Code": "ES", "capital": "Madrid"}},
+		{"op": "delete", "id": "atlantis"}
+	]}`
+	txReq, _ := http.NewRequest("POST", "/transactions", strings.NewReader(body))
+	txReq.Header.Add("Content-Type", "application/json")
+	txReqRecorder := newRequestRecorder(txReq, handler)
+	assert.Equal(t, http.StatusConflict, txReqRecorder.Code)
+	assert.Equal(t, "operation 1: Country not found.", txReqRecorder.Body.String())
+
+	getReq, _ := http.NewRequest("GET", "/countries/spain", nil)
+	assert.Equal(t, http.StatusNotFound, newRequestRecorder(getReq, handler).Code)
+}
+
+func TestPostTransactionWithInvalidOperation(t *testing.T) {
+	handler := initializeHandlers()
+	for body, expected := range map[string]string{
+		`{"operations": []}`: "A transaction needs at least one operation",
+		`{"operations": [{"op": "rename", "id": "greece"}]}`: "operation 0: unknown op 'rename', expected 'add', 'update' or 'delete'",
+		`{"operations": [{"op": "add"}]}`:                    "operation 0: a country with a name is required",
+		`{"operations": [{"op": "delete"}]}`:                 "operation 0: an id is required",
+	} {
+		txReq, _ := http.NewRequest("POST", "/transactions", strings.NewReader(body))
+		txReq.Header.Add("Content-Type", "application/json")
+		txReqRecorder := newRequestRecorder(txReq, handler)
+		assert.Equal(t, http.StatusBadRequest, txReqRecorder.Code)
+		assert.Equal(t, expected, txReqRecorder.Body.String())
+	}
+}
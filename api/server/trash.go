@@ -0,0 +1,83 @@
+package server
+
+import (
+	"errors"
+	"go-countries-rest-api/api/store"
+	utils "go-countries-rest-api/api/utils"
+	"net/http"
+)
+
+/**
+Respond with 501 when the store deletes permanently
+*/
+func (s *Server) trash(writer http.ResponseWriter) (store.Trash, bool) {
+	trash, ok := s.Actions.(store.Trash)
+	if !ok {
+		utils.ConstructErrorResponse(writer, "Trash is not supported by the store", http.StatusNotImplemented)
+	}
+	return trash, ok
+}
+
+/**
+Handle requests like
+GET /countries/trash
+*/
+func (s *Server) listTrash(writer http.ResponseWriter, request *http.Request) {
+	trash, ok := s.trash(writer)
+	if !ok {
+		return
+	}
+
+	trashed, err := trash.GetTrash()
+	s.writeTrashResult(writer, trashed, err)
+}
+
+/**
+Handle requests like
+POST /countries/{id}:restore
+*/
+func (s *Server) restoreCountry(writer http.ResponseWriter, request *http.Request) {
+	trash, ok := s.trash(writer)
+	if !ok {
+		return
+	}
+
+	id := pathParam(request, "id")
+	restored, err := trash.RestoreCountry(id)
+	if err == nil {
+		s.recordAudit(request, id, nil, restored)
+	}
+	s.writeTrashResult(writer, restored, err)
+}
+
+/**
+Handle requests like
+DELETE /countries/trash/{id}
+The country can not be restored afterwards, its revision history is dropped too.
+*/
+func (s *Server) purgeCountry(writer http.ResponseWriter, request *http.Request) {
+	trash, ok := s.trash(writer)
+	if !ok {
+		return
+	}
+
+	id := pathParam(request, "id")
+	purged, err := trash.PurgeCountry(id)
+	if err == nil {
+		s.recordPurge(request, id, purged)
+		utils.ConstructSuccessfulResponse(writer, http.StatusOK, nil)
+		return
+	}
+	s.writeTrashResult(writer, nil, err)
+}
+
+func (s *Server) writeTrashResult(writer http.ResponseWriter, result interface{}, err error) {
+	switch {
+	case err == nil:
+		s.writeJson(writer, http.StatusOK, result)
+	case errors.Is(err, store.ErrCountryNotFound):
+		utils.ConstructErrorResponse(writer, "Country not found in trash", http.StatusNotFound)
+	default:
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/audit"
+	"go-countries-rest-api/api/store"
+	"net/http"
+	"testing"
+)
+
+func TestDeletingMissingCountryRespondsNotFound(t *testing.T) {
+	handler := initializeHandlers()
+	deleteReq, _ := http.NewRequest("DELETE", "/countries/atlantis", nil)
+	deleteReqRecorder := newRequestRecorder(deleteReq, handler)
+	assert.Equal(t, http.StatusNotFound, deleteReqRecorder.Code)
+	assert.Equal(t, "Country not found", deleteReqRecorder.Body.String())
+}
+
+func TestDeletedCountryIsInTrashAndCanBeRestored(t *testing.T) {
+	server := initializeServer()
+	server.Audit = audit.NewLog()
+	handler := server.handler()
+	addCountry(handler, greeceBody)
+
+	deleteReq, _ := http.NewRequest("DELETE", "/countries/greece", nil)
+	assert.Equal(t, http.StatusOK, newRequestRecorder(deleteReq, handler).Code)
+
+	getReq, _ := http.NewRequest("GET", "/countries/greece", nil)
+	assert.Equal(t, http.StatusNotFound, newRequestRecorder(getReq, handler).Code)
+
+	trashReq, _ := http.NewRequest("GET", "/countries/trash", nil)
+	trashReqRecorder := newRequestRecorder(trashReq, handler)
+	assert.Equal(t, http.StatusOK, trashReqRecorder.Code)
+	var trashed []store.TrashedCountry
+	json.Unmarshal(trashReqRecorder.Body.Bytes(), &trashed)
+	assert.Equal(t, 1, len(trashed))
+	assert.Equal(t, "Greece", trashed[0].Country.Name)
+
+	restoreReq, _ := http.NewRequest("POST", "/countries/greece:restore", nil)
+	restoreReqRecorder := newRequestRecorder(restoreReq, handler)
+	assert.Equal(t, http.StatusOK, restoreReqRecorder.Code)
+	assert.Equal(t, "Athens", constructCountryFromJson(restoreReqRecorder.Body.String()).Capital)
+	assert.Equal(t, http.StatusOK, newRequestRecorder(getReq, handler).Code)
+
+	assert.Equal(t, http.StatusNotFound, newRequestRecorder(restoreReq, handler).Code)
+
+	entries := server.Audit.Query(audit.Query{})
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, audit.Add, entries[2].Operation)
+}
+
+func TestPurgeCountryFromTrash(t *testing.T) {
+	server := initializeServer()
+	server.Audit = audit.NewLog()
+	handler := server.handler()
+	addCountry(handler, greeceBody)
+
+	purgeReq, _ := http.NewRequest("DELETE", "/countries/trash/greece", nil)
+	assert.Equal(t, http.StatusNotFound, newRequestRecorder(purgeReq, handler).Code)
+
+	deleteReq, _ := http.NewRequest("DELETE", "/countries/greece", nil)
+	newRequestRecorder(deleteReq, handler)
+	assert.Equal(t, http.StatusOK, newRequestRecorder(purgeReq, handler).Code)
+
+	restoreReq, _ := http.NewRequest("POST", "/countries/greece:restore", nil)
+	assert.Equal(t, http.StatusNotFound, newRequestRecorder(restoreReq, handler).Code)
+
+	entries := server.Audit.Query(audit.Query{})
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, audit.Delete, entries[1].Operation)
+	assert.Equal(t, audit.Purge, entries[2].Operation)
+	assert.Equal(t, "greece", entries[2].CountryId)
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	utils "go-countries-rest-api/api/utils"
+	"go-countries-rest-api/api/webhooks"
+	"io/ioutil"
+	"net/http"
+)
+
+/**
+Respond with 501 when no dispatcher is configured
+*/
+func (s *Server) webhooksEnabled(writer http.ResponseWriter) bool {
+	if s.Webhooks == nil {
+		utils.ConstructErrorResponse(writer, "Webhooks are not enabled", http.StatusNotImplemented)
+		return false
+	}
+	return true
+}
+
+func (s *Server) listWebhooks(writer http.ResponseWriter, request *http.Request) {
+	if s.webhooksEnabled(writer) {
+		s.writeJson(writer, http.StatusOK, s.Webhooks.Subscriptions())
+	}
+}
+
+func (s *Server) webhookDeadLetters(writer http.ResponseWriter, request *http.Request) {
+	if s.webhooksEnabled(writer) {
+		s.writeJson(writer, http.StatusOK, s.Webhooks.DeadLetters())
+	}
+}
+
+func (s *Server) getWebhook(writer http.ResponseWriter, request *http.Request) {
+	if s.webhooksEnabled(writer) {
+		subscription, err := s.Webhooks.Subscription(pathParam(request, "id"))
+		s.writeWebhookResult(writer, http.StatusOK, subscription, err)
+	}
+}
+
+func (s *Server) deleteWebhook(writer http.ResponseWriter, request *http.Request) {
+	if s.webhooksEnabled(writer) {
+		err := s.Webhooks.DeleteSubscription(pathParam(request, "id"))
+		s.writeWebhookResult(writer, http.StatusOK, nil, err)
+	}
+}
+
+func (s *Server) webhookDeliveries(writer http.ResponseWriter, request *http.Request) {
+	if s.webhooksEnabled(writer) {
+		deliveries, err := s.Webhooks.Deliveries(pathParam(request, "id"))
+		s.writeWebhookResult(writer, http.StatusOK, deliveries, err)
+	}
+}
+
+func (s *Server) addWebhook(writer http.ResponseWriter, request *http.Request) {
+	if !s.webhooksEnabled(writer) {
+		return
+	}
+
+	bodyBytes, err := ioutil.ReadAll(request.Body)
+	defer request.Body.Close()
+	if err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var subscription webhooks.Subscription
+	err = json.Unmarshal(bodyBytes, &subscription)
+	if err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	created, err := s.Webhooks.AddSubscription(subscription)
+	if err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writer.Header().Add("location", "/webhooks/"+created.ID)
+	s.writeJson(writer, http.StatusCreated, created)
+}
+
+func (s *Server) writeWebhookResult(writer http.ResponseWriter, statusCode int, result interface{}, err error) {
+	if err == webhooks.ErrSubscriptionNotFound {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if result == nil {
+		utils.ConstructSuccessfulResponse(writer, statusCode, nil)
+		return
+	}
+	s.writeJson(writer, statusCode, result)
+}
+
+func (s *Server) writeJson(writer http.ResponseWriter, statusCode int, value interface{}) {
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		utils.ConstructErrorResponse(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	utils.ConstructSuccessfulResponse(writer, statusCode, jsonBytes)
+}
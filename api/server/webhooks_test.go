@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/auth"
+	"go-countries-rest-api/api/webhooks"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAddGetAndDeleteWebhook(t *testing.T) {
+	server := initializeServerWithWebhooks()
+
+	addReq, _ := http.NewRequest("POST", "/webhooks", strings.NewReader(`{"url": "https://partner.example.com/hooks", "events": ["deleted"], "filter": {"currency": "EUR"}}`))
+	addReqRecorder := newRequestRecorder(addReq, server.Mux)
+	var created webhooks.Subscription
+	json.Unmarshal(addReqRecorder.Body.Bytes(), &created)
+	assert.Equal(t, http.StatusCreated, addReqRecorder.Code)
+	assert.Equal(t, "/webhooks/"+created.ID, addReqRecorder.Header().Get("location"))
+	assert.NotEmpty(t, created.Secret)
+
+	getReq, _ := http.NewRequest("GET", "/webhooks/"+created.ID, nil)
+	getReqRecorder := newRequestRecorder(getReq, server.Mux)
+	var fetched webhooks.Subscription
+	json.Unmarshal(getReqRecorder.Body.Bytes(), &fetched)
+	assert.Equal(t, http.StatusOK, getReqRecorder.Code)
+	assert.Equal(t, "https://partner.example.com/hooks", fetched.URL)
+	assert.Equal(t, "EUR", fetched.Filter.Currency)
+	assert.Equal(t, "", fetched.Secret)
+
+	deliveriesReq, _ := http.NewRequest("GET", "/webhooks/"+created.ID+"/deliveries", nil)
+	deliveriesReqRecorder := newRequestRecorder(deliveriesReq, server.Mux)
+	assert.Equal(t, http.StatusOK, deliveriesReqRecorder.Code)
+	assert.Equal(t, "[]", deliveriesReqRecorder.Body.String())
+
+	deleteReq, _ := http.NewRequest("DELETE", "/webhooks/"+created.ID, nil)
+	assert.Equal(t, http.StatusOK, newRequestRecorder(deleteReq, server.Mux).Code)
+	assert.Equal(t, http.StatusNotFound, newRequestRecorder(getReq, server.Mux).Code)
+}
+
+func TestAddWebhookWithInvalidURL(t *testing.T) {
+	server := initializeServerWithWebhooks()
+	addReq, _ := http.NewRequest("POST", "/webhooks", strings.NewReader(`{"url": "partner.example.com"}`))
+	addReqRecorder := newRequestRecorder(addReq, server.Mux)
+
+	assert.Equal(t, http.StatusBadRequest, addReqRecorder.Code)
+	assert.Equal(t, "Webhook URL must be an absolute http or https URL.", addReqRecorder.Body.String())
+}
+
+func TestListWebhooksAndDeadLetters(t *testing.T) {
+	server := initializeServerWithWebhooks()
+	server.Webhooks.AddSubscription(webhooks.Subscription{URL: "https://partner.example.com/hooks"})
+
+	listReq, _ := http.NewRequest("GET", "/webhooks", nil)
+	listReqRecorder := newRequestRecorder(listReq, server.Mux)
+	deadLettersReq, _ := http.NewRequest("GET", "/webhooks/dead-letters", nil)
+	deadLettersReqRecorder := newRequestRecorder(deadLettersReq, server.Mux)
+
+	var subscriptions []webhooks.Subscription
+	json.Unmarshal(listReqRecorder.Body.Bytes(), &subscriptions)
+	assert.Equal(t, http.StatusOK, listReqRecorder.Code)
+	assert.Equal(t, 1, len(subscriptions))
+	assert.Equal(t, http.StatusOK, deadLettersReqRecorder.Code)
+	assert.Equal(t, "[]", deadLettersReqRecorder.Body.String())
+}
+
+func TestWebhooksAreNotEnabled(t *testing.T) {
+	mux := initializeHandlers()
+	listReq, _ := http.NewRequest("GET", "/webhooks", nil)
+	assert.Equal(t, http.StatusNotImplemented, newRequestRecorder(listReq, mux).Code)
+}
+
+func TestWebhooksRequireAdmin(t *testing.T) {
+	server := initializeServerWithAuth(auth.RoleNone)
+	server.Webhooks = webhooks.NewDispatcher(webhooks.Config{})
+	listReq, _ := http.NewRequest("GET", "/webhooks", nil)
+	listReq.Header.Add("X-API-Key", "editor-key")
+	assert.Equal(t, http.StatusForbidden, newRequestRecorder(listReq, server.handler()).Code)
+}
+
+func initializeServerWithWebhooks() *Server {
+	server := initializeServer()
+	server.Webhooks = webhooks.NewDispatcher(webhooks.Config{})
+	return server
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"encoding/json"
+	"go-countries-rest-api/api/events"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/store"
+	utils "go-countries-rest-api/api/utils"
+	"go-countries-rest-api/api/websocket"
+	"net/http"
+	"sort"
+	"time"
+)
+
+var (
+	// a ping is sent every wsPingInterval, the connection is closed when nothing is read for twice as long
+	wsPingInterval = 30 * time.Second
+	wsWriteTimeout = 10 * time.Second
+)
+
+// events queued for a connection before it is considered too slow and closed
+const wsSendBuffer = 64
+
+/**
+Sent by the clients:
+{"type": "subscribe", "id": "eurozone", "filter": {"currency": "EUR"}}
+{"type": "unsubscribe", "id": "eurozone"}
+*/
+type subscriptionMessage struct {
+	Type   string               `json:"type"`
+	Id     string               `json:"id"`
+	Filter models.CountryFilter `json:"filter"`
+}
+
+/**
+Sent by the server. "change" notifications list the ids of the subscriptions matching the event
+*/
+type notificationMessage struct {
+	Type          string        `json:"type"`
+	Id            string        `json:"id,omitempty"`
+	Subscriptions []string      `json:"subscriptions,omitempty"`
+	Event         *events.Event `json:"event,omitempty"`
+	Message       string        `json:"message,omitempty"`
+}
+
+/**
+Handle requests with path "/countries/ws" like
+GET /countries/ws (WebSocket upgrade)
+Clients subscribe with filters over the country fields and receive the matching changes.
+*/
+func (s *Server) subscribeToChanges(writer http.ResponseWriter, request *http.Request) {
+	observable, ok := s.Actions.(store.Observable)
+	if !ok {
+		utils.ConstructErrorResponse(writer, "Change feed is not supported by the store", http.StatusNotImplemented)
+		return
+	}
+
+	conn, err := websocket.Upgrade(writer, request)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	subscription := observable.Events().Subscribe(wsSendBuffer)
+	defer subscription.Close()
+
+	pingInterval := wsPingInterval
+	conn.SetReadDeadline(time.Now().Add(2 * pingInterval))
+	conn.PongHandler = func(payload []byte) {
+		conn.SetReadDeadline(time.Now().Add(2 * pingInterval))
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	incoming := make(chan subscriptionMessage)
+	readErrors := make(chan error, 1)
+	go readSubscriptionMessages(conn, pingInterval, incoming, readErrors, done)
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	filters := map[string]models.CountryFilter{}
+	for {
+		var err error
+		select {
+		case message := <-incoming:
+			err = writeNotification(conn, handleSubscriptionMessage(filters, message))
+		case <-readErrors:
+			return
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			err = conn.WritePing(nil)
+		case event, open := <-subscription.Events():
+			if !open {
+				conn.WriteClose(websocket.CloseTryAgainLater, "client is too slow")
+				return
+			}
+			if matching := matchingSubscriptions(filters, event); len(matching) > 0 {
+				err = writeNotification(conn, notificationMessage{Type: "change", Subscriptions: matching, Event: &event})
+			}
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func readSubscriptionMessages(conn *websocket.Conn, pingInterval time.Duration, incoming chan<- subscriptionMessage, readErrors chan<- error, done <-chan struct{}) {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			readErrors <- err
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(2 * pingInterval))
+
+		var message subscriptionMessage
+		if json.Unmarshal(data, &message) != nil {
+			message = subscriptionMessage{Type: "malformed"}
+		}
+
+		select {
+		case incoming <- message:
+		case <-done:
+			return
+		}
+	}
+}
+
+func handleSubscriptionMessage(filters map[string]models.CountryFilter, message subscriptionMessage) notificationMessage {
+	switch message.Type {
+	case "subscribe":
+		if message.Id == "" {
+			return notificationMessage{Type: "error", Message: "subscription id is required"}
+		}
+		filters[message.Id] = message.Filter
+		return notificationMessage{Type: "subscribed", Id: message.Id}
+	case "unsubscribe":
+		if _, ok := filters[message.Id]; !ok {
+			return notificationMessage{Type: "error", Id: message.Id, Message: "unknown subscription"}
+		}
+		delete(filters, message.Id)
+		return notificationMessage{Type: "unsubscribed", Id: message.Id}
+	case "malformed":
+		return notificationMessage{Type: "error", Message: "malformed message"}
+	default:
+		return notificationMessage{Type: "error", Message: "unknown message type '" + message.Type + "'"}
+	}
+}
+
+func matchingSubscriptions(filters map[string]models.CountryFilter, event events.Event) []string {
+	matching := []string{}
+	for id, filter := range filters {
+		if event.Matches(filter) {
+			matching = append(matching, id)
+		}
+	}
+	sort.Strings(matching)
+	return matching
+}
+
+func writeNotification(conn *websocket.Conn, notification notificationMessage) error {
+	jsonBytes, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return conn.WriteMessage(websocket.TextMessage, jsonBytes)
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/websocket"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebSocketSubscriberReceivesMatchingChanges(t *testing.T) {
+	server := initializeServer()
+	httpServer := httptest.NewServer(server.handler())
+	defer httpServer.Close()
+
+	conn, err := websocket.Dial(httpServer.URL+"/countries/ws", nil)
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	subscribed := sendSubscriptionMessage(conn, `{"type": "subscribe", "id": "eurozone", "filter": {"currency": "EUR"}}`)
+	assert.Equal(t, "subscribed", subscribed.Type)
+	assert.Equal(t, "eurozone", subscribed.Id)
+
+	addCountry(server.Mux, strings.Replace(greeceBody, "EUR", "GBP", 1))
+	addCountry(server.Mux, greeceBody)
+
+	change := readNotification(conn)
+	assert.Equal(t, "change", change.Type)
+	assert.Equal(t, []string{"eurozone"}, change.Subscriptions)
+	assert.Equal(t, "updated", string(change.Event.Type))
+	assert.Equal(t, "EUR", change.Event.Country.Currencies[0].Code)
+}
+
+func TestWebSocketUnsubscribeStopsNotifications(t *testing.T) {
+	server := initializeServer()
+	httpServer := httptest.NewServer(server.handler())
+	defer httpServer.Close()
+
+	conn, _ := websocket.Dial(httpServer.URL+"/countries/ws", nil)
+	defer conn.Close()
+
+	sendSubscriptionMessage(conn, `{"type": "subscribe", "id": "europe", "filter": {"region": "europe"}}`)
+	sendSubscriptionMessage(conn, `{"type": "subscribe", "id": "all"}`)
+	unsubscribed := sendSubscriptionMessage(conn, `{"type": "unsubscribe", "id": "europe"}`)
+	assert.Equal(t, "unsubscribed", unsubscribed.Type)
+
+	addCountry(server.Mux, strings.Replace(greeceBody, "\"capital\"", "\"region\": \"Europe\",\"capital\"", 1))
+	change := readNotification(conn)
+	assert.Equal(t, []string{"all"}, change.Subscriptions)
+}
+
+func TestWebSocketInvalidMessagesAreReported(t *testing.T) {
+	server := initializeServer()
+	httpServer := httptest.NewServer(server.handler())
+	defer httpServer.Close()
+
+	conn, _ := websocket.Dial(httpServer.URL+"/countries/ws", nil)
+	defer conn.Close()
+
+	malformed := sendSubscriptionMessage(conn, `{not json`)
+	unknownType := sendSubscriptionMessage(conn, `{"type": "publish"}`)
+	unknownSubscription := sendSubscriptionMessage(conn, `{"type": "unsubscribe", "id": "nope"}`)
+	assert.Equal(t, "malformed message", malformed.Message)
+	assert.Equal(t, "unknown message type 'publish'", unknownType.Message)
+	assert.Equal(t, "unknown subscription", unknownSubscription.Message)
+}
+
+func TestWebSocketServerPingsIdleConnections(t *testing.T) {
+	wsPingInterval = 20 * time.Millisecond
+	defer func() { wsPingInterval = 30 * time.Second }()
+
+	server := initializeServer()
+	httpServer := httptest.NewServer(server.handler())
+	defer httpServer.Close()
+
+	conn, _ := websocket.Dial(httpServer.URL+"/countries/ws", nil)
+	defer conn.Close()
+
+	sendSubscriptionMessage(conn, `{"type": "subscribe", "id": "all"}`)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		addCountry(server.Mux, greeceBody)
+	}()
+
+	// pongs are written by ReadMessage while it waits for the notification, which keeps the connection open
+	change := readNotification(conn)
+	assert.Equal(t, "change", change.Type)
+}
+
+func TestWebSocketUnresponsiveClientIsDisconnected(t *testing.T) {
+	wsPingInterval = 20 * time.Millisecond
+	defer func() { wsPingInterval = 30 * time.Second }()
+
+	server := initializeServer()
+	httpServer := httptest.NewServer(server.handler())
+	defer httpServer.Close()
+
+	conn, _ := websocket.Dial(httpServer.URL+"/countries/ws", nil)
+	defer conn.Close()
+
+	// no pong is sent while the client is not reading
+	time.Sleep(100 * time.Millisecond)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var err error
+	for err == nil {
+		_, _, err = conn.ReadMessage()
+	}
+	netError, ok := err.(net.Error)
+	assert.False(t, ok && netError.Timeout())
+}
+
+func TestWebSocketEndpointRequiresUpgrade(t *testing.T) {
+	mux := initializeHandlers()
+	wsReq, _ := http.NewRequest("GET", "/countries/ws", nil)
+	wsReqRecorder := newRequestRecorder(wsReq, mux)
+	assert.Equal(t, http.StatusUpgradeRequired, wsReqRecorder.Code)
+}
+
+func sendSubscriptionMessage(conn *websocket.Conn, message string) notificationMessage {
+	conn.WriteMessage(websocket.TextMessage, []byte(message))
+	return readNotification(conn)
+}
+
+func readNotification(conn *websocket.Conn) notificationMessage {
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var notification notificationMessage
+	_, data, _ := conn.ReadMessage()
+	json.Unmarshal(data, &notification)
+	return notification
+}
@@ -0,0 +1,181 @@
+package snapshot
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/store"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultLimit = 20
+
+var (
+	ErrSnapshotNotFound = errors.New("Snapshot not found.")
+	ErrChecksumMismatch = errors.New("Checksum does not match the snapshot.")
+	ErrCorrupted        = errors.New("Snapshot is corrupted, its content does not match its checksum.")
+	ErrNotReplaceable   = errors.New("Store can not replace its countries atomically.")
+)
+
+/**
+What is known of a snapshot without its countries. Checksum is the SHA-256 of the
+JSON array of its countries sorted by id, like "sha256:9f86d0...".
+*/
+type Info struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Count     int       `json:"count"`
+	Checksum  string    `json:"checksum"`
+}
+
+type Snapshot struct {
+	Info
+	Countries []models.Country `json:"countries"`
+}
+
+/**
+Store keeps point-in-time copies of the countries of a store in memory, the most recent
+Limit ones. A snapshot is kept as the JSON its checksum was computed on, and restoring
+verifies it before replacing the countries.
+*/
+type Store struct {
+	sync.Mutex
+	limit     int
+	snapshots []*entry
+}
+
+type entry struct {
+	info Info
+	data []byte
+}
+
+/**
+Keep the most recent limit snapshots, DefaultLimit when not positive
+*/
+func NewStore(limit int) *Store {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	return &Store{limit: limit}
+}
+
+/**
+Snapshot the countries of the store. The countries are read at once, so a store reading
+them under one lock, like store.CountriesStorage, gives a consistent snapshot.
+*/
+func (s *Store) Capture(ctx context.Context, actions store.Actions) (*Info, error) {
+	countries, err := actions.GetAllCountries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sorted := append([]models.Country{}, *countries...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return strings.ToLower(sorted[i].Name) < strings.ToLower(sorted[j].Name)
+	})
+	data, err := json.Marshal(sorted)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &entry{
+		info: Info{ID: newId(), CreatedAt: time.Now().UTC(), Count: len(sorted), Checksum: checksum(data)},
+		data: data,
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.snapshots = append(s.snapshots, snapshot)
+	if len(s.snapshots) > s.limit {
+		s.snapshots = s.snapshots[len(s.snapshots)-s.limit:]
+	}
+	info := snapshot.info
+	return &info, nil
+}
+
+/**
+The snapshots, most recent first
+*/
+func (s *Store) List() []Info {
+	s.Lock()
+	defer s.Unlock()
+	infos := []Info{}
+	for i := len(s.snapshots) - 1; i >= 0; i-- {
+		infos = append(infos, s.snapshots[i].info)
+	}
+	return infos
+}
+
+/**
+The snapshot with its countries, ErrCorrupted when they do not match the checksum
+*/
+func (s *Store) Get(id string) (*Snapshot, error) {
+	snapshot := s.find(id)
+	if snapshot == nil {
+		return nil, ErrSnapshotNotFound
+	}
+	return snapshot.decode()
+}
+
+/**
+Replace the countries of the store by the ones of the snapshot, atomically. When
+expectedChecksum is not empty, it must be the checksum of the snapshot, so a restore
+only happens when the snapshot is the one the caller has checked. The replacements are
+the countries the restore changed.
+*/
+func (s *Store) Restore(ctx context.Context, actions store.Actions, id string, expectedChecksum string) (*Snapshot, []store.Replacement, error) {
+	replaceable, ok := actions.(store.Replaceable)
+	if !ok {
+		return nil, nil, ErrNotReplaceable
+	}
+	snapshot, err := s.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if expectedChecksum != "" && expectedChecksum != snapshot.Checksum {
+		return nil, nil, ErrChecksumMismatch
+	}
+	replacements, err := replaceable.ReplaceCountries(ctx, snapshot.Countries)
+	if err != nil {
+		return nil, nil, err
+	}
+	return snapshot, replacements, nil
+}
+
+func (s *Store) find(id string) *entry {
+	s.Lock()
+	defer s.Unlock()
+	for _, snapshot := range s.snapshots {
+		if snapshot.info.ID == id {
+			return snapshot
+		}
+	}
+	return nil
+}
+
+func (e *entry) decode() (*Snapshot, error) {
+	if checksum(e.data) != e.info.Checksum {
+		return nil, ErrCorrupted
+	}
+	snapshot := &Snapshot{Info: e.info}
+	if err := json.Unmarshal(e.data, &snapshot.Countries); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func newId() string {
+	bytes := make([]byte, 12)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
@@ -0,0 +1,91 @@
+package snapshot
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/store"
+	"testing"
+)
+
+var (
+	greece = models.Country{Name: "Greece", Alpha2Code: "GR", Capital: "Athens"}
+	spain  = models.Country{Name: "Spain", Alpha2Code: "ES", Capital: "Madrid"}
+)
+
+func TestCaptureAndRestore(t *testing.T) {
+	ctx := context.Background()
+	storage := store.NewCountriesStorage()
+	storage.AddCountry(ctx, spain)
+	storage.AddCountry(ctx, greece)
+	snapshots := NewStore(0)
+
+	info, err := snapshots.Capture(ctx, storage)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, info.Count)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", info.Checksum)
+
+	snapshot, err := snapshots.Get(info.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, *info, snapshot.Info)
+	assert.Equal(t, []models.Country{greece, spain}, snapshot.Countries)
+
+	storage.DeleteCountry(ctx, "spain")
+	storage.AddCountry(ctx, models.Country{Name: "Greece", Alpha2Code: "GR", Capital: "Nafplio"})
+	storage.AddCountry(ctx, models.Country{Name: "Japan", Alpha2Code: "JP"})
+
+	restored, replacements, err := snapshots.Restore(ctx, storage, info.ID, info.Checksum)
+	assert.Nil(t, err)
+	assert.Equal(t, info.ID, restored.ID)
+	assert.Equal(t, 3, len(replacements))
+	after, _ := snapshots.Capture(ctx, storage)
+	assert.Equal(t, info.Checksum, after.Checksum)
+}
+
+func TestListKeepsTheMostRecent(t *testing.T) {
+	ctx := context.Background()
+	storage := store.NewCountriesStorage()
+	snapshots := NewStore(2)
+
+	first, _ := snapshots.Capture(ctx, storage)
+	storage.AddCountry(ctx, greece)
+	second, _ := snapshots.Capture(ctx, storage)
+	storage.AddCountry(ctx, spain)
+	third, _ := snapshots.Capture(ctx, storage)
+
+	assert.Equal(t, []Info{*third, *second}, snapshots.List())
+	_, err := snapshots.Get(first.ID)
+	assert.Equal(t, ErrSnapshotNotFound, err)
+	assert.NotEqual(t, second.Checksum, third.Checksum)
+}
+
+func TestRestoreVerifiesChecksums(t *testing.T) {
+	ctx := context.Background()
+	storage := store.NewCountriesStorage()
+	storage.AddCountry(ctx, greece)
+	snapshots := NewStore(0)
+	info, _ := snapshots.Capture(ctx, storage)
+	storage.AddCountry(ctx, spain)
+
+	_, _, err := snapshots.Restore(ctx, storage, info.ID, "sha256:0000")
+	assert.Equal(t, ErrChecksumMismatch, err)
+
+	snapshots.find(info.ID).data = []byte(`[{"name": "Atlantis"}]`)
+	_, _, err = snapshots.Restore(ctx, storage, info.ID, "")
+	assert.Equal(t, ErrCorrupted, err)
+
+	countries, _ := storage.GetAllCountries(ctx)
+	assert.Equal(t, 2, len(*countries))
+}
+
+func TestRestoreNeedsAReplaceableStore(t *testing.T) {
+	ctx := context.Background()
+	storage := store.NewCountriesStorage()
+	snapshots := NewStore(0)
+	info, _ := snapshots.Capture(ctx, storage)
+
+	_, _, err := snapshots.Restore(ctx, struct{ store.Actions }{storage}, info.ID, "")
+	assert.Equal(t, ErrNotReplaceable, err)
+	_, _, err = snapshots.Restore(ctx, storage, "missing", "")
+	assert.Equal(t, ErrSnapshotNotFound, err)
+}
@@ -1,15 +1,23 @@
 package store
 
-import "go-countries-rest-api/api/models"
+import (
+	"context"
+	"go-countries-rest-api/api/models"
+)
 
+/**
+Every method takes the context of the request. Implementations return ctx.Err()
+when the context is cancelled or its deadline exceeded before they are done.
+Stores written against the previous methods can be wrapped with FromLegacy.
+*/
 type Actions interface {
-	AddCountry(country models.Country) (*models.Country, error)
-	DeleteCountry(countryId string) error
-	GetCountryById(countryId string) (*models.Country, error)
-	GetAllCountries() (*[]models.Country, error)
+	AddCountry(ctx context.Context, country models.Country) (*models.Country, error)
+	DeleteCountry(ctx context.Context, countryId string) error
+	GetCountryById(ctx context.Context, countryId string) (*models.Country, error)
+	GetAllCountries(ctx context.Context) (*[]models.Country, error)
 
 	/**
 	Get Random Country Id to use it to redirect the call to GetCountryById
 	 */
-	GetRandomCountryId() (*string, error)
+	GetRandomCountryId(ctx context.Context) (*string, error)
 }
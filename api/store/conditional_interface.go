@@ -0,0 +1,23 @@
+package store
+
+import (
+	"context"
+	"go-countries-rest-api/api/models"
+)
+
+/**
+Implemented by the stores able to make a write depend on the current country, checked
+under the same lock as the write
+*/
+type Conditional interface {
+	/**
+	Add a country only when no country has its id, ErrCountryExists otherwise
+	*/
+	InsertCountry(ctx context.Context, country models.Country) (*models.Country, error)
+
+	/**
+	Replace a country only when it is still the expected one, ErrCountryChanged otherwise.
+	ErrCountryNotFound when the country is missing.
+	*/
+	UpdateCountryIf(ctx context.Context, expected models.Country, country models.Country) (*models.Country, error)
+}
@@ -1,7 +1,8 @@
 package store
 
 import (
-	"errors"
+	"context"
+	"go-countries-rest-api/api/events"
 	"go-countries-rest-api/api/models"
 	"math/rand"
 	"strings"
@@ -9,73 +10,181 @@ import (
 	"time"
 )
 
+/**
+In-memory storage, safe for concurrent use. Reads share a read lock so they never wait
+for each other, only for the writes.
+*/
 type CountriesStorage struct {
-	sync.Mutex
-	store map[string]models.Country
+	sync.RWMutex
+	store        map[string]models.Country
+	history      map[string][]Revision
+	trash        map[string]TrashedCountry
+	lastModified time.Time
+	events       *events.Hub
+
+	// rand.Rand is not safe for concurrent use and reads only hold the read lock
+	randomLock sync.Mutex
+	random     *rand.Rand
 }
 
 func NewCountriesStorage() *CountriesStorage {
 	return &CountriesStorage{
-		store: map[string]models.Country{},
+		store:        map[string]models.Country{},
+		history:      map[string][]Revision{},
+		trash:        map[string]TrashedCountry{},
+		lastModified: time.Now(),
+		events:       events.NewHub(events.DefaultLogSize),
+		random:       rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
-func (storage *CountriesStorage) AddCountry(country models.Country) (*models.Country, error) {
+func (storage *CountriesStorage) AddCountry(ctx context.Context, country models.Country) (*models.Country, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	storage.Lock()
-	storage.store[strings.ToLower(country.Name)] = country
 	defer storage.Unlock()
+	storage.put(strings.ToLower(country.Name), country)
 	return &country,nil
 }
 
-func (storage *CountriesStorage) DeleteCountry(countryId string) error {
+/**
+Soft delete a country, moving it to the trash
+*/
+func (storage *CountriesStorage) DeleteCountry(ctx context.Context, countryId string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	storage.Lock()
-	delete(storage.store, strings.ToLower(countryId))
 	defer storage.Unlock()
+	if !storage.remove(strings.ToLower(countryId)) {
+		return ErrCountryNotFound
+	}
 	return nil
 }
 
-func (storage *CountriesStorage) GetAllCountries() (*[]models.Country, error) {
-	countries := make([]models.Country, len(storage.store))
+func (storage *CountriesStorage) SwapCountry(ctx context.Context, country models.Country) (*models.Country, *models.Country, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	storage.Lock()
+	defer storage.Unlock()
+	id := strings.ToLower(country.Name)
+	var previous *models.Country
+	if current, exists := storage.store[id]; exists {
+		previous = &current
+	}
+	storage.put(id, country)
+	return previous, &country, nil
+}
 
+func (storage *CountriesStorage) TakeCountry(ctx context.Context, countryId string) (*models.Country, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	storage.Lock()
-	i := 0
+	defer storage.Unlock()
+	id := strings.ToLower(countryId)
+	country, exists := storage.store[id]
+	if !exists {
+		return nil, ErrCountryNotFound
+	}
+	storage.remove(id)
+	return &country, nil
+}
+
+/**
+Write a country, recording a revision and publishing the change. The lock must be held.
+*/
+func (storage *CountriesStorage) put(id string, country models.Country) {
+	previous, exists := storage.store[id]
+	storage.store[id] = country
+	delete(storage.trash, id)
+	storage.lastModified = time.Now()
+	storage.addRevision(id, &country)
+
+	if exists {
+		storage.events.Publish(events.Updated, id, &country, &previous)
+	} else {
+		storage.events.Publish(events.Created, id, &country, nil)
+	}
+}
+
+/**
+Move a country to the trash, recording a revision and publishing the change. The lock must be held.
+*/
+func (storage *CountriesStorage) remove(id string) bool {
+	country, exists := storage.store[id]
+	if !exists {
+		return false
+	}
+	delete(storage.store, id)
+	storage.lastModified = time.Now()
+	storage.trash[id] = TrashedCountry{ID: id, DeletedAt: storage.lastModified.UTC(), Country: country}
+	storage.addRevision(id, nil)
+	storage.events.Publish(events.Deleted, id, &country, nil)
+	return true
+}
+
+/**
+The hub notified on every mutation of the storage
+*/
+func (storage *CountriesStorage) Events() *events.Hub {
+	return storage.events
+}
+
+func (storage *CountriesStorage) GetAllCountries(ctx context.Context) (*[]models.Country, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	storage.RLock()
+	countries := make([]models.Country, 0, len(storage.store))
 	for _, country := range storage.store {
-		countries[i] = country
-		i++
+		countries = append(countries, country)
 	}
-	storage.Unlock()
+	storage.RUnlock()
 	return &countries,nil
 }
 
-func (storage *CountriesStorage) GetCountryById(countryId string) (*models.Country, error) {
-	storage.Lock()
+func (storage *CountriesStorage) GetCountryById(ctx context.Context, countryId string) (*models.Country, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	storage.RLock()
 	country, ok := storage.store[strings.ToLower(countryId)]
+	storage.RUnlock()
 	if !ok {
-		storage.Unlock()
-		return nil,errors.New("Country not found.")
+		return nil,ErrCountryNotFound
 	}
-	storage.Unlock()
 	return &country,nil
 }
 
-func (storage *CountriesStorage) GetRandomCountryId() (*string, error) {
-	ids := make([]string, len(storage.store))
-	storage.Lock()
-	i := 0
+func (storage *CountriesStorage) GetRandomCountryId(ctx context.Context) (*string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	storage.RLock()
+	ids := make([]string, 0, len(storage.store))
 	for id := range storage.store {
-		ids[i] = id
-		i++
+		ids = append(ids, id)
 	}
-	storage.Unlock()
+	storage.RUnlock()
 
 	var target string
 	if len(ids) == 0 {
-		return nil,errors.New("No countries available to choose randomly.")
+		return nil,ErrNoCountries
 	} else if len(ids) == 1 {
 		target = ids[0]
 	} else {
-		rand.Seed(time.Now().UnixNano())
-		target = ids[rand.Intn(len(ids))]
+		storage.randomLock.Lock()
+		target = ids[storage.random.Intn(len(ids))]
+		storage.randomLock.Unlock()
 	}
 	return &target,nil
 }
+
+func (storage *CountriesStorage) LastModified() time.Time {
+	storage.RLock()
+	defer storage.RUnlock()
+	return storage.lastModified
+}
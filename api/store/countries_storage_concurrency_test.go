@@ -0,0 +1,211 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/models"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+/**
+Run with -race, readers and writers of every kind hit the storage at the same time
+*/
+func TestStorageConcurrentReadersAndWriters(t *testing.T) {
+	storage := NewCountriesStorage()
+	storage.AddCountry(context.Background(), constructCountryGreece())
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				name := "country-" + strconv.Itoa(w) + "-" + strconv.Itoa(i%10)
+				storage.AddCountry(context.Background(), models.Country{Name: name})
+				if i%3 == 0 {
+					storage.DeleteCountry(context.Background(), name)
+				}
+				if i%7 == 0 {
+					storage.RestoreCountry(name)
+				}
+			}
+		}(w)
+	}
+	for r := 0; r < 8; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				countries, err := storage.GetAllCountries(context.Background())
+				assert.Nil(t, err)
+				assert.True(t, len(*countries) >= 1)
+				_, err = storage.GetCountryById(context.Background(), "greece")
+				assert.Nil(t, err)
+				_, err = storage.GetRandomCountryId(context.Background())
+				assert.Nil(t, err)
+				storage.GetCountryHistory("greece")
+				storage.GetTrash()
+				storage.LastModified()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestStorageReadsDoNotBlockEachOther(t *testing.T) {
+	storage := NewCountriesStorage()
+	storage.AddCountry(context.Background(), constructCountryGreece())
+
+	// a slow reader holding the read lock
+	storage.RLock()
+	defer storage.RUnlock()
+
+	read := make(chan struct{})
+	go func() {
+		storage.GetCountryById(context.Background(), "greece")
+		storage.GetAllCountries(context.Background())
+		storage.GetRandomCountryId(context.Background())
+		storage.GetCountryHistory("greece")
+		close(read)
+	}()
+
+	select {
+	case <-read:
+	case <-time.After(time.Second):
+		t.Fatal("reads are blocked by another reader")
+	}
+}
+
+func TestConcurrentReadersSeeWholeTransactions(t *testing.T) {
+	storage := NewCountriesStorage()
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				countries, _ := storage.GetAllCountries(context.Background())
+				count := len(*countries)
+				assert.True(t, count == 0 || count == 2, "partial transaction visible: %d countries", count)
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		tx, _ := storage.Begin()
+		tx.AddCountry(constructCountryGreece())
+		tx.AddCountry(constructCountrySpain())
+		tx.Commit()
+
+		tx, _ = storage.Begin()
+		tx.DeleteCountry("greece")
+		tx.DeleteCountry("spain")
+		tx.Commit()
+	}
+	close(done)
+	readers.Wait()
+}
+
+/**
+The operations the benchmarks run, so they measure the RWMutex of CountriesStorage against
+a storage serializing every operation on a sync.Mutex
+*/
+type benchmarkedStorage interface {
+	AddCountry(ctx context.Context, country models.Country) (*models.Country, error)
+	GetAllCountries(ctx context.Context) (*[]models.Country, error)
+	GetCountryById(ctx context.Context, countryId string) (*models.Country, error)
+}
+
+type mutexStorage struct {
+	sync.Mutex
+	storage *CountriesStorage
+}
+
+func (m *mutexStorage) AddCountry(ctx context.Context, country models.Country) (*models.Country, error) {
+	m.Lock()
+	defer m.Unlock()
+	return m.storage.AddCountry(ctx, country)
+}
+
+func (m *mutexStorage) GetAllCountries(ctx context.Context) (*[]models.Country, error) {
+	m.Lock()
+	defer m.Unlock()
+	return m.storage.GetAllCountries(ctx)
+}
+
+func (m *mutexStorage) GetCountryById(ctx context.Context, countryId string) (*models.Country, error) {
+	m.Lock()
+	defer m.Unlock()
+	return m.storage.GetCountryById(ctx, countryId)
+}
+
+func benchmarkStorages(b *testing.B, run func(b *testing.B, storage benchmarkedStorage)) {
+	b.Run("RWMutex", func(b *testing.B) {
+		run(b, benchmarkStorage(250))
+	})
+	b.Run("Mutex", func(b *testing.B) {
+		run(b, &mutexStorage{storage: benchmarkStorage(250)})
+	})
+}
+
+func BenchmarkStorageGetCountryByIdParallel(b *testing.B) {
+	benchmarkStorages(b, func(b *testing.B, storage benchmarkedStorage) {
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				storage.GetCountryById(context.Background(), "country-"+strconv.Itoa(i%250))
+				i++
+			}
+		})
+	})
+}
+
+func BenchmarkStorageGetAllCountriesParallel(b *testing.B) {
+	benchmarkStorages(b, func(b *testing.B, storage benchmarkedStorage) {
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				storage.GetAllCountries(context.Background())
+			}
+		})
+	})
+}
+
+/**
+Read heavy load, one write for every 100 reads
+*/
+func BenchmarkStorageMixedParallel(b *testing.B) {
+	benchmarkStorages(b, func(b *testing.B, storage benchmarkedStorage) {
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				if i%100 == 0 {
+					storage.AddCountry(context.Background(), models.Country{Name: fmt.Sprintf("country-%d", i%250)})
+				} else {
+					storage.GetCountryById(context.Background(), "country-"+strconv.Itoa(i%250))
+				}
+				i++
+			}
+		})
+	})
+}
+
+func benchmarkStorage(size int) *CountriesStorage {
+	storage := NewCountriesStorage()
+	for i := 0; i < size; i++ {
+		storage.AddCountry(context.Background(), models.Country{Name: "country-" + strconv.Itoa(i)})
+	}
+	return storage
+}
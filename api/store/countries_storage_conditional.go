@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"go-countries-rest-api/api/models"
+	"reflect"
+	"strings"
+)
+
+func (storage *CountriesStorage) InsertCountry(ctx context.Context, country models.Country) (*models.Country, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	storage.Lock()
+	defer storage.Unlock()
+	id := strings.ToLower(country.Name)
+	if _, exists := storage.store[id]; exists {
+		return nil, ErrCountryExists
+	}
+	storage.put(id, country)
+	return &country, nil
+}
+
+func (storage *CountriesStorage) UpdateCountryIf(ctx context.Context, expected models.Country, country models.Country) (*models.Country, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	storage.Lock()
+	defer storage.Unlock()
+	id := strings.ToLower(country.Name)
+	current, exists := storage.store[id]
+	if !exists {
+		return nil, ErrCountryNotFound
+	}
+	if !reflect.DeepEqual(current, expected) {
+		return nil, ErrCountryChanged
+	}
+	storage.put(id, country)
+	return &country, nil
+}
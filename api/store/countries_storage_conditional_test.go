@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStorageInsertCountry(t *testing.T) {
+	storage := NewCountriesStorage()
+	greece := constructCountryGreece()
+
+	inserted, err := storage.InsertCountry(context.Background(), greece)
+	assert.Nil(t, err)
+	assert.Equal(t, &greece, inserted)
+
+	moved := constructCountryGreece()
+	moved.Capital = "Nafplio"
+	_, err = storage.InsertCountry(context.Background(), moved)
+	assert.Equal(t, ErrCountryExists, err)
+	country, _ := storage.GetCountryById(context.Background(), "greece")
+	assert.Equal(t, "Athens", country.Capital)
+}
+
+func TestStorageUpdateCountryIf(t *testing.T) {
+	storage := NewCountriesStorage()
+	greece := constructCountryGreece()
+	moved := constructCountryGreece()
+	moved.Capital = "Nafplio"
+
+	_, err := storage.UpdateCountryIf(context.Background(), greece, moved)
+	assert.Equal(t, ErrCountryNotFound, err)
+
+	storage.AddCountry(context.Background(), greece)
+	updated, err := storage.UpdateCountryIf(context.Background(), greece, moved)
+	assert.Nil(t, err)
+	assert.Equal(t, &moved, updated)
+
+	_, err = storage.UpdateCountryIf(context.Background(), greece, greece)
+	assert.Equal(t, ErrCountryChanged, err)
+	country, _ := storage.GetCountryById(context.Background(), "greece")
+	assert.Equal(t, "Nafplio", country.Capital)
+}
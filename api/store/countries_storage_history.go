@@ -0,0 +1,72 @@
+package store
+
+import (
+	"go-countries-rest-api/api/models"
+	"strings"
+	"time"
+)
+
+/**
+Record a revision of a country, nil for a deletion. The lock must be held.
+*/
+func (storage *CountriesStorage) addRevision(id string, country *models.Country) {
+	revisions := storage.history[id]
+	revision := Revision{Number: len(revisions) + 1, Time: storage.lastModified.UTC(), Deleted: country == nil}
+	if country != nil {
+		copied := *country
+		copied.Currencies = append([]models.Currency{}, country.Currencies...)
+		revision.Country = &copied
+	}
+	storage.history[id] = append(revisions, revision)
+}
+
+func (storage *CountriesStorage) GetCountryHistory(countryId string) ([]Revision, error) {
+	storage.RLock()
+	defer storage.RUnlock()
+
+	revisions, ok := storage.history[strings.ToLower(countryId)]
+	if !ok {
+		return nil, ErrCountryNotFound
+	}
+	return append([]Revision{}, revisions...), nil
+}
+
+func (storage *CountriesStorage) GetCountryAsOf(countryId string, asOf time.Time) (*models.Country, error) {
+	storage.RLock()
+	defer storage.RUnlock()
+
+	var found *Revision
+	for i, revision := range storage.history[strings.ToLower(countryId)] {
+		if revision.Time.After(asOf) {
+			break
+		}
+		found = &storage.history[strings.ToLower(countryId)][i]
+	}
+	if found == nil || found.Deleted {
+		return nil, ErrCountryNotFound
+	}
+	country := *found.Country
+	return &country, nil
+}
+
+func (storage *CountriesStorage) RestoreRevision(countryId string, number int) (*models.Country, *models.Country, error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	id := strings.ToLower(countryId)
+	revisions, ok := storage.history[id]
+	if !ok {
+		return nil, nil, ErrCountryNotFound
+	}
+	if number < 1 || number > len(revisions) || revisions[number-1].Deleted {
+		return nil, nil, ErrRevisionNotFound
+	}
+
+	var previous *models.Country
+	if current, exists := storage.store[id]; exists {
+		previous = &current
+	}
+	country := *revisions[number-1].Country
+	storage.put(id, country)
+	return previous, &country, nil
+}
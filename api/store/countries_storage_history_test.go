@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStorageRecordsRevisionsOnMutations(t *testing.T) {
+	storage := NewCountriesStorage()
+	greece := constructCountryGreece()
+	storage.AddCountry(context.Background(), greece)
+	greece.Capital = "Nafplio"
+	storage.AddCountry(context.Background(), greece)
+	storage.DeleteCountry(context.Background(), "Greece")
+	storage.DeleteCountry(context.Background(), "greece")
+
+	revisions, err := storage.GetCountryHistory("greece")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(revisions))
+	assert.Equal(t, 1, revisions[0].Number)
+	assert.Equal(t, "Athens", revisions[0].Country.Capital)
+	assert.Equal(t, "Nafplio", revisions[1].Country.Capital)
+	assert.True(t, revisions[2].Deleted)
+	assert.Nil(t, revisions[2].Country)
+
+	_, err = storage.GetCountryHistory("spain")
+	assert.Equal(t, ErrCountryNotFound, err)
+}
+
+func TestStorageGetCountryAsOf(t *testing.T) {
+	storage := NewCountriesStorage()
+	before := time.Now()
+	time.Sleep(time.Millisecond)
+	greece := constructCountryGreece()
+	storage.AddCountry(context.Background(), greece)
+	afterAdd := storage.LastModified()
+	time.Sleep(time.Millisecond)
+	greece.Capital = "Nafplio"
+	storage.AddCountry(context.Background(), greece)
+	afterUpdate := storage.LastModified()
+	time.Sleep(time.Millisecond)
+	storage.DeleteCountry(context.Background(), "greece")
+
+	_, err := storage.GetCountryAsOf("greece", before)
+	assert.Equal(t, ErrCountryNotFound, err)
+
+	country, err := storage.GetCountryAsOf("greece", afterAdd)
+	assert.Nil(t, err)
+	assert.Equal(t, "Athens", country.Capital)
+
+	country, err = storage.GetCountryAsOf("greece", afterUpdate)
+	assert.Nil(t, err)
+	assert.Equal(t, "Nafplio", country.Capital)
+
+	_, err = storage.GetCountryAsOf("greece", time.Now())
+	assert.Equal(t, ErrCountryNotFound, err)
+}
+
+func TestStorageRestoreRevision(t *testing.T) {
+	storage := NewCountriesStorage()
+	greece := constructCountryGreece()
+	storage.AddCountry(context.Background(), greece)
+	storage.DeleteCountry(context.Background(), "greece")
+
+	_, _, err := storage.RestoreRevision("greece", 2)
+	assert.Equal(t, ErrRevisionNotFound, err)
+	_, _, err = storage.RestoreRevision("greece", 3)
+	assert.Equal(t, ErrRevisionNotFound, err)
+	_, _, err = storage.RestoreRevision("spain", 1)
+	assert.Equal(t, ErrCountryNotFound, err)
+
+	previous, restored, err := storage.RestoreRevision("greece", 1)
+	assert.Nil(t, err)
+	assert.Nil(t, previous)
+	assert.Equal(t, "Athens", restored.Capital)
+
+	country, err := storage.GetCountryById(context.Background(), "greece")
+	assert.Nil(t, err)
+	assert.Equal(t, "Athens", country.Capital)
+
+	revisions, _ := storage.GetCountryHistory("greece")
+	assert.Equal(t, 3, len(revisions))
+	assert.Equal(t, 3, revisions[2].Number)
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"go-countries-rest-api/api/models"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+/**
+The whole replacement holds the lock of the storage. Countries that do not change keep
+their revisions, the others get a revision and publish an event like any write, the
+deleted ones going to the trash.
+*/
+func (storage *CountriesStorage) ReplaceCountries(ctx context.Context, countries []models.Country) ([]Replacement, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	replacing := map[string]models.Country{}
+	ids := []string{}
+	for _, country := range countries {
+		id := strings.ToLower(country.Name)
+		if _, ok := replacing[id]; !ok {
+			ids = append(ids, id)
+		}
+		replacing[id] = country
+	}
+	sort.Strings(ids)
+
+	storage.Lock()
+	defer storage.Unlock()
+	removed := []string{}
+	for id := range storage.store {
+		if _, ok := replacing[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	sort.Strings(removed)
+	replacements := []Replacement{}
+	for _, id := range removed {
+		previous := storage.store[id]
+		storage.remove(id)
+		replacements = append(replacements, Replacement{Id: id, Before: &previous})
+	}
+	for _, id := range ids {
+		current, ok := storage.store[id]
+		country := replacing[id]
+		if ok && reflect.DeepEqual(current, country) {
+			continue
+		}
+		replacement := Replacement{Id: id, After: &country}
+		if ok {
+			replacement.Before = &current
+		}
+		storage.put(id, country)
+		replacements = append(replacements, replacement)
+	}
+	return replacements, nil
+}
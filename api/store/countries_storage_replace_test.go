@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/models"
+	"testing"
+)
+
+func TestReplaceCountries(t *testing.T) {
+	ctx := context.Background()
+	storage := NewCountriesStorage()
+	storage.AddCountry(ctx, models.Country{Name: "Greece", Capital: "Athens"})
+	storage.AddCountry(ctx, models.Country{Name: "Spain", Capital: "Madrid"})
+	storage.AddCountry(ctx, models.Country{Name: "Japan", Capital: "Tokyo"})
+
+	replacements, err := storage.ReplaceCountries(ctx, []models.Country{
+		{Name: "Greece", Capital: "Nafplio"},
+		{Name: "Japan", Capital: "Tokyo"},
+		{Name: "France", Capital: "Paris"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []Replacement{
+		{Id: "spain", Before: &models.Country{Name: "Spain", Capital: "Madrid"}},
+		{Id: "france", After: &models.Country{Name: "France", Capital: "Paris"}},
+		{Id: "greece", Before: &models.Country{Name: "Greece", Capital: "Athens"}, After: &models.Country{Name: "Greece", Capital: "Nafplio"}},
+	}, replacements)
+
+	countries, _ := storage.GetAllCountries(ctx)
+	assert.Equal(t, 3, len(*countries))
+	greece, _ := storage.GetCountryById(ctx, "greece")
+	assert.Equal(t, "Nafplio", greece.Capital)
+	_, err = storage.GetCountryById(ctx, "spain")
+	assert.Equal(t, ErrCountryNotFound, err)
+
+	trash, _ := storage.GetTrash()
+	assert.Equal(t, "spain", trash[0].ID)
+	history, _ := storage.GetCountryHistory("japan")
+	assert.Equal(t, 1, len(history))
+	history, _ = storage.GetCountryHistory("greece")
+	assert.Equal(t, 2, len(history))
+}
+
+func TestReplaceCountriesHonorsTheContext(t *testing.T) {
+	storage := NewCountriesStorage()
+	storage.AddCountry(context.Background(), models.Country{Name: "Greece"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := storage.ReplaceCountries(ctx, []models.Country{})
+	assert.Equal(t, context.Canceled, err)
+	countries, _ := storage.GetAllCountries(context.Background())
+	assert.Equal(t, 1, len(*countries))
+}
@@ -1,14 +1,17 @@
 package store
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/events"
 	"go-countries-rest-api/api/models"
 	"testing"
+	"time"
 )
 
 func TestStorageGetAllCountriesWithEmptyMemory(t *testing.T) {
 	storage := NewCountriesStorage()
-	actualCountries, getAllCountriesError := storage.GetAllCountries()
+	actualCountries, getAllCountriesError := storage.GetAllCountries(context.Background())
 	assert.Equal(t, nil, getAllCountriesError)
 	assert.Equal(t, 0, len(*actualCountries))
 }
@@ -16,8 +19,8 @@ func TestStorageGetAllCountriesWithEmptyMemory(t *testing.T) {
 func TestStorageAddOneCountryAndGetAllCountries(t *testing.T) {
 	storage := NewCountriesStorage()
 	country := constructCountryGreece()
-	_, addCountryError := storage.AddCountry(country)
-	actualCountries, getAllCountriesError := storage.GetAllCountries()
+	_, addCountryError := storage.AddCountry(context.Background(), country)
+	actualCountries, getAllCountriesError := storage.GetAllCountries(context.Background())
 	assert.Nil(t, addCountryError)
 	assert.Nil(t, getAllCountriesError)
 	assert.Equal(t, 1, len(*actualCountries))
@@ -32,9 +35,9 @@ func TestStorageAddTwoCountriesAndGetAllCountries(t *testing.T) {
 	storage := NewCountriesStorage()
 	greece := constructCountryGreece()
 	spain := constructCountrySpain()
-	_, addGreeceCountryError := storage.AddCountry(greece)
-	_, addSpainCountryError := storage.AddCountry(spain)
-	actualCountries, getAllCountriesError := storage.GetAllCountries()
+	_, addGreeceCountryError := storage.AddCountry(context.Background(), greece)
+	_, addSpainCountryError := storage.AddCountry(context.Background(), spain)
+	actualCountries, getAllCountriesError := storage.GetAllCountries(context.Background())
 	assert.Nil(t, addGreeceCountryError)
 	assert.Nil(t, addSpainCountryError)
 	assert.Nil(t, getAllCountriesError)
@@ -45,15 +48,15 @@ func TestStorageAddTwoCountriesAndGetSpecificCountry(t *testing.T) {
 	storage := NewCountriesStorage()
 	greece := constructCountryGreece()
 	spain := constructCountrySpain()
-	_, addGreeceCountryError := storage.AddCountry(greece)
-	_, addSpainCountryError := storage.AddCountry(spain)
-	actualCountries, getAllCountriesError := storage.GetAllCountries()
+	_, addGreeceCountryError := storage.AddCountry(context.Background(), greece)
+	_, addSpainCountryError := storage.AddCountry(context.Background(), spain)
+	actualCountries, getAllCountriesError := storage.GetAllCountries(context.Background())
 	assert.Nil(t, addGreeceCountryError)
 	assert.Nil(t, addSpainCountryError)
 	assert.Nil(t, getAllCountriesError)
 	assert.Equal(t, 2, len(*actualCountries))
 
-	actual, addGreeceCountryError := storage.GetCountryById("greece")
+	actual, addGreeceCountryError := storage.GetCountryById(context.Background(), "greece")
 	assert.Equal(t, "Greece", actual.Name)
 	assert.Equal(t, "GR", actual.Alpha2Code)
 	assert.Equal(t, "Athens", actual.Capital)
@@ -65,22 +68,22 @@ func TestStorageAddTwoCountriesAndDeleteSpecificCountry(t *testing.T) {
 	storage := NewCountriesStorage()
 	greece := constructCountryGreece()
 	spain := constructCountrySpain()
-	_, addGreeceCountryError := storage.AddCountry(greece)
-	_, addSpainCountryError := storage.AddCountry(spain)
-	actualCountries, getAllCountriesError := storage.GetAllCountries()
+	_, addGreeceCountryError := storage.AddCountry(context.Background(), greece)
+	_, addSpainCountryError := storage.AddCountry(context.Background(), spain)
+	actualCountries, getAllCountriesError := storage.GetAllCountries(context.Background())
 	assert.Nil(t, addGreeceCountryError)
 	assert.Nil(t, addSpainCountryError)
 	assert.Nil(t, getAllCountriesError)
 	assert.Equal(t, 2, len(*actualCountries))
 
-	deleteSpainCountryError := storage.DeleteCountry("spain")
+	deleteSpainCountryError := storage.DeleteCountry(context.Background(), "spain")
 	assert.Nil(t, deleteSpainCountryError)
 
-	actualCountriesAfterDeletion, getAllCountriesErrorAfterDeletion := storage.GetAllCountries()
+	actualCountriesAfterDeletion, getAllCountriesErrorAfterDeletion := storage.GetAllCountries(context.Background())
 	assert.Nil(t, getAllCountriesErrorAfterDeletion)
 	assert.Equal(t, 1, len(*actualCountriesAfterDeletion))
 
-	actual, addGreeceCountryError := storage.GetCountryById("greece")
+	actual, addGreeceCountryError := storage.GetCountryById(context.Background(), "greece")
 	assert.Equal(t, "Greece", actual.Name)
 	assert.Equal(t, "GR", actual.Alpha2Code)
 	assert.Equal(t, "Athens", actual.Capital)
@@ -92,15 +95,15 @@ func TestStorageAddTwoCountriesAndGetRandomCountry(t *testing.T) {
 	storage := NewCountriesStorage()
 	greece := constructCountryGreece()
 	spain := constructCountrySpain()
-	_, addGreeceCountryError := storage.AddCountry(greece)
-	_, addSpainCountryError := storage.AddCountry(spain)
-	actualCountries, getAllCountriesError := storage.GetAllCountries()
+	_, addGreeceCountryError := storage.AddCountry(context.Background(), greece)
+	_, addSpainCountryError := storage.AddCountry(context.Background(), spain)
+	actualCountries, getAllCountriesError := storage.GetAllCountries(context.Background())
 	assert.Nil(t, addGreeceCountryError)
 	assert.Nil(t, addSpainCountryError)
 	assert.Nil(t, getAllCountriesError)
 	assert.Equal(t, 2, len(*actualCountries))
 
-	actual, randomCountryError := storage.GetRandomCountryId()
+	actual, randomCountryError := storage.GetRandomCountryId(context.Background())
 	assert.Nil(t, randomCountryError)
 	assert.Contains(t, [2]string{"greece", "spain"}, *actual)
 }
@@ -108,24 +111,24 @@ func TestStorageAddTwoCountriesAndGetRandomCountry(t *testing.T) {
 func TestStorageAddOneCountriesAndGetRandomCountry(t *testing.T) {
 	storage := NewCountriesStorage()
 	greece := constructCountryGreece()
-	_, addGreeceCountryError := storage.AddCountry(greece)
-	actualCountries, getAllCountriesError := storage.GetAllCountries()
+	_, addGreeceCountryError := storage.AddCountry(context.Background(), greece)
+	actualCountries, getAllCountriesError := storage.GetAllCountries(context.Background())
 	assert.Nil(t, addGreeceCountryError)
 	assert.Nil(t, getAllCountriesError)
 	assert.Equal(t, 1, len(*actualCountries))
 
-	actual, randomCountryError := storage.GetRandomCountryId()
+	actual, randomCountryError := storage.GetRandomCountryId(context.Background())
 	assert.Nil(t, randomCountryError)
 	assert.Equal(t, "greece", *actual)
 }
 
 func TestStorageNoCountryAddedAndGetRandomCountry(t *testing.T) {
 	storage := NewCountriesStorage()
-	actualCountries, getAllCountriesError := storage.GetAllCountries()
+	actualCountries, getAllCountriesError := storage.GetAllCountries(context.Background())
 	assert.Nil(t, getAllCountriesError)
 	assert.Equal(t, 0, len(*actualCountries))
 
-	actual, randomCountryError := storage.GetRandomCountryId()
+	actual, randomCountryError := storage.GetRandomCountryId(context.Background())
 	assert.Equal(t, "No countries available to choose randomly.", randomCountryError.Error())
 	assert.Nil(t, actual)
 }
@@ -134,19 +137,85 @@ func TestStorageAddTwoCountriesAndGetNotExistingCountry(t *testing.T) {
 	storage := NewCountriesStorage()
 	greece := constructCountryGreece()
 	spain := constructCountrySpain()
-	_, addGreeceCountryError := storage.AddCountry(greece)
-	_, addSpainCountryError := storage.AddCountry(spain)
-	actualCountries, getAllCountriesError := storage.GetAllCountries()
+	_, addGreeceCountryError := storage.AddCountry(context.Background(), greece)
+	_, addSpainCountryError := storage.AddCountry(context.Background(), spain)
+	actualCountries, getAllCountriesError := storage.GetAllCountries(context.Background())
 	assert.Nil(t, addGreeceCountryError)
 	assert.Nil(t, addSpainCountryError)
 	assert.Nil(t, getAllCountriesError)
 	assert.Equal(t, 2, len(*actualCountries))
 
-	actual, addFranceCountryError := storage.GetCountryById("france")
+	actual, addFranceCountryError := storage.GetCountryById(context.Background(), "france")
 	assert.Equal(t, "Country not found.", addFranceCountryError.Error())
 	assert.Nil(t, actual)
 }
 
+func TestStorageLastModifiedChangesOnWrite(t *testing.T) {
+	storage := NewCountriesStorage()
+	created := storage.LastModified()
+
+	time.Sleep(time.Millisecond)
+	storage.AddCountry(context.Background(), constructCountryGreece())
+	afterAdd := storage.LastModified()
+
+	time.Sleep(time.Millisecond)
+	storage.GetCountryById(context.Background(), "greece")
+	afterGet := storage.LastModified()
+
+	time.Sleep(time.Millisecond)
+	storage.DeleteCountry(context.Background(), "greece")
+	afterDelete := storage.LastModified()
+
+	assert.True(t, afterAdd.After(created))
+	assert.Equal(t, afterAdd, afterGet)
+	assert.True(t, afterDelete.After(afterGet))
+}
+
+func TestStoragePublishesEventsOnMutations(t *testing.T) {
+	storage := NewCountriesStorage()
+	subscription := storage.Events().Subscribe(10)
+	defer subscription.Close()
+
+	storage.AddCountry(context.Background(), constructCountryGreece())
+	storage.AddCountry(context.Background(), constructCountryGreece())
+	storage.DeleteCountry(context.Background(), "greece")
+	storage.DeleteCountry(context.Background(), "greece")
+	storage.AddCountry(context.Background(), constructCountrySpain())
+
+	assert.Equal(t, events.Created, (<-subscription.Events()).Type)
+	assert.Equal(t, events.Updated, (<-subscription.Events()).Type)
+	deleted := <-subscription.Events()
+	assert.Equal(t, events.Deleted, deleted.Type)
+	assert.Equal(t, "Greece", deleted.Country.Name)
+	// deleting a missing country publishes nothing
+	assert.Equal(t, "spain", (<-subscription.Events()).CountryId)
+}
+
+func TestStorageSwapAndTakeCountry(t *testing.T) {
+	storage := NewCountriesStorage()
+	greece := constructCountryGreece()
+	moved := constructCountryGreece()
+	moved.Capital = "Nafplio"
+
+	previous, saved, err := storage.SwapCountry(context.Background(), greece)
+	assert.Nil(t, err)
+	assert.Nil(t, previous)
+	assert.Equal(t, &greece, saved)
+
+	previous, saved, err = storage.SwapCountry(context.Background(), moved)
+	assert.Nil(t, err)
+	assert.Equal(t, &greece, previous)
+	assert.Equal(t, &moved, saved)
+
+	taken, err := storage.TakeCountry(context.Background(), "GREECE")
+	assert.Nil(t, err)
+	assert.Equal(t, &moved, taken)
+	_, err = storage.TakeCountry(context.Background(), "greece")
+	assert.Equal(t, ErrCountryNotFound, err)
+	trash, _ := storage.GetTrash()
+	assert.Equal(t, "greece", trash[0].ID)
+}
+
 func constructCountryGreece() models.Country {
 	return models.Country{
 		Name:       "Greece",
@@ -163,4 +232,4 @@ func constructCountrySpain() models.Country {
 		Capital:    "Madrid",
 		Currencies: []models.Currency{{Code: "EUR", Name: "Euro", Symbol: "E"}},
 	}
-}
\ No newline at end of file
+}
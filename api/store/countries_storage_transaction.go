@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"go-countries-rest-api/api/models"
+	"strings"
+)
+
+type stagedKind int
+
+const (
+	stagedAdd stagedKind = iota
+	stagedUpdate
+	stagedDelete
+)
+
+type stagedOperation struct {
+	kind    stagedKind
+	id      string
+	country models.Country
+}
+
+type countriesTransaction struct {
+	storage    *CountriesStorage
+	operations []stagedOperation
+	closed     bool
+}
+
+func (storage *CountriesStorage) Begin() (Transaction, error) {
+	return &countriesTransaction{storage: storage}, nil
+}
+
+func (tx *countriesTransaction) AddCountry(country models.Country) error {
+	return tx.stage(stagedAdd, strings.ToLower(country.Name), country)
+}
+
+func (tx *countriesTransaction) UpdateCountry(country models.Country) error {
+	return tx.stage(stagedUpdate, strings.ToLower(country.Name), country)
+}
+
+func (tx *countriesTransaction) DeleteCountry(countryId string) error {
+	return tx.stage(stagedDelete, strings.ToLower(countryId), models.Country{})
+}
+
+func (tx *countriesTransaction) stage(kind stagedKind, id string, country models.Country) error {
+	if tx.closed {
+		return ErrTransactionClosed
+	}
+	tx.operations = append(tx.operations, stagedOperation{kind: kind, id: id, country: country})
+	return nil
+}
+
+func (tx *countriesTransaction) GetCountryById(countryId string) (*models.Country, error) {
+	if tx.closed {
+		return nil, ErrTransactionClosed
+	}
+
+	id := strings.ToLower(countryId)
+	for i := len(tx.operations) - 1; i >= 0; i-- {
+		operation := tx.operations[i]
+		if operation.id != id {
+			continue
+		}
+		if operation.kind == stagedDelete {
+			return nil, ErrCountryNotFound
+		}
+		country := operation.country
+		return &country, nil
+	}
+	return tx.storage.GetCountryById(context.Background(), id)
+}
+
+/**
+The whole commit holds the lock of the storage, so readers see either none or all of the changes.
+The operations are checked against the storage first and applied only when all of them can be.
+*/
+func (tx *countriesTransaction) Commit() ([]Replacement, error) {
+	if tx.closed {
+		return nil, ErrTransactionClosed
+	}
+	tx.closed = true
+
+	storage := tx.storage
+	storage.Lock()
+	defer storage.Unlock()
+
+	exists := map[string]bool{}
+	for i, operation := range tx.operations {
+		found, staged := exists[operation.id]
+		if !staged {
+			_, found = storage.store[operation.id]
+		}
+		if operation.kind != stagedAdd && !found {
+			return nil, &OperationError{Index: i, Err: ErrCountryNotFound}
+		}
+		exists[operation.id] = operation.kind != stagedDelete
+	}
+
+	replacements := []Replacement{}
+	for _, operation := range tx.operations {
+		replacement := Replacement{Id: operation.id}
+		if current, ok := storage.store[operation.id]; ok {
+			replacement.Before = &current
+		}
+		if operation.kind == stagedDelete {
+			storage.remove(operation.id)
+		} else {
+			country := operation.country
+			storage.put(operation.id, country)
+			replacement.After = &country
+		}
+		replacements = append(replacements, replacement)
+	}
+	return replacements, nil
+}
+
+func (tx *countriesTransaction) Rollback() error {
+	if tx.closed {
+		return ErrTransactionClosed
+	}
+	tx.closed = true
+	tx.operations = nil
+	return nil
+}
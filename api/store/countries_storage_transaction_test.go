@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTransactionChangesAreInvisibleUntilCommit(t *testing.T) {
+	storage := NewCountriesStorage()
+	storage.AddCountry(context.Background(), constructCountrySpain())
+
+	tx, _ := storage.Begin()
+	assert.Nil(t, tx.AddCountry(constructCountryGreece()))
+	assert.Nil(t, tx.DeleteCountry("spain"))
+
+	_, err := storage.GetCountryById(context.Background(), "greece")
+	assert.Equal(t, ErrCountryNotFound, err)
+	_, err = storage.GetCountryById(context.Background(), "spain")
+	assert.Nil(t, err)
+
+	staged, err := tx.GetCountryById("greece")
+	assert.Nil(t, err)
+	assert.Equal(t, "Athens", staged.Capital)
+	_, err = tx.GetCountryById("spain")
+	assert.Equal(t, ErrCountryNotFound, err)
+
+	replacements, err := tx.Commit()
+	assert.Nil(t, err)
+	spain := constructCountrySpain()
+	greece := constructCountryGreece()
+	assert.Equal(t, []Replacement{{Id: "greece", After: &greece}, {Id: "spain", Before: &spain}}, replacements)
+	_, err = storage.GetCountryById(context.Background(), "greece")
+	assert.Nil(t, err)
+	_, err = storage.GetCountryById(context.Background(), "spain")
+	assert.Equal(t, ErrCountryNotFound, err)
+}
+
+func TestTransactionIsAllOrNothing(t *testing.T) {
+	storage := NewCountriesStorage()
+	storage.AddCountry(context.Background(), constructCountrySpain())
+
+	tx, _ := storage.Begin()
+	greece := constructCountryGreece()
+	tx.AddCountry(greece)
+	tx.DeleteCountry("spain")
+	tx.UpdateCountry(constructCountrySpain())
+
+	_, err := tx.Commit()
+	var operationError *OperationError
+	assert.True(t, errors.As(err, &operationError))
+	assert.Equal(t, 2, operationError.Index)
+	assert.True(t, errors.Is(err, ErrCountryNotFound))
+	assert.Equal(t, "operation 2: Country not found.", err.Error())
+
+	countries, _ := storage.GetAllCountries(context.Background())
+	assert.Equal(t, 1, len(*countries))
+	assert.Equal(t, "Spain", (*countries)[0].Name)
+	_, err = tx.Commit()
+	assert.Equal(t, ErrTransactionClosed, err)
+}
+
+func TestTransactionUpdateOfStagedAddition(t *testing.T) {
+	storage := NewCountriesStorage()
+	tx, _ := storage.Begin()
+	greece := constructCountryGreece()
+	tx.AddCountry(greece)
+	greece.Capital = "Nafplio"
+	tx.UpdateCountry(greece)
+
+	_, err := tx.Commit()
+	assert.Nil(t, err)
+	country, _ := storage.GetCountryById(context.Background(), "greece")
+	assert.Equal(t, "Nafplio", country.Capital)
+}
+
+func TestTransactionReplacementsAreReadAtCommit(t *testing.T) {
+	storage := NewCountriesStorage()
+	tx, _ := storage.Begin()
+	greece := constructCountryGreece()
+	tx.AddCountry(greece)
+
+	concurrent := constructCountryGreece()
+	concurrent.Capital = "Nafplio"
+	storage.AddCountry(context.Background(), concurrent)
+
+	replacements, err := tx.Commit()
+	assert.Nil(t, err)
+	assert.Equal(t, []Replacement{{Id: "greece", Before: &concurrent, After: &greece}}, replacements)
+}
+
+func TestTransactionRollback(t *testing.T) {
+	storage := NewCountriesStorage()
+	tx, _ := storage.Begin()
+	tx.AddCountry(constructCountryGreece())
+
+	assert.Nil(t, tx.Rollback())
+	assert.Equal(t, ErrTransactionClosed, tx.Rollback())
+	assert.Equal(t, ErrTransactionClosed, tx.AddCountry(constructCountrySpain()))
+	_, err := tx.Commit()
+	assert.Equal(t, ErrTransactionClosed, err)
+
+	countries, _ := storage.GetAllCountries(context.Background())
+	assert.Equal(t, 0, len(*countries))
+}
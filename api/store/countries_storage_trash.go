@@ -0,0 +1,48 @@
+package store
+
+import (
+	"go-countries-rest-api/api/models"
+	"sort"
+	"strings"
+)
+
+func (storage *CountriesStorage) GetTrash() ([]TrashedCountry, error) {
+	storage.RLock()
+	defer storage.RUnlock()
+
+	trashed := make([]TrashedCountry, 0, len(storage.trash))
+	for _, country := range storage.trash {
+		trashed = append(trashed, country)
+	}
+	sort.Slice(trashed, func(i, j int) bool {
+		return trashed[i].DeletedAt.After(trashed[j].DeletedAt)
+	})
+	return trashed, nil
+}
+
+func (storage *CountriesStorage) RestoreCountry(countryId string) (*models.Country, error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	id := strings.ToLower(countryId)
+	trashed, ok := storage.trash[id]
+	if !ok {
+		return nil, ErrCountryNotFound
+	}
+	storage.put(id, trashed.Country)
+	return &trashed.Country, nil
+}
+
+func (storage *CountriesStorage) PurgeCountry(countryId string) (*models.Country, error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	id := strings.ToLower(countryId)
+	trashed, ok := storage.trash[id]
+	if !ok {
+		return nil, ErrCountryNotFound
+	}
+	delete(storage.trash, id)
+	delete(storage.history, id)
+	return &trashed.Country, nil
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStorageDeleteMovesCountryToTrash(t *testing.T) {
+	storage := NewCountriesStorage()
+	storage.AddCountry(context.Background(), constructCountryGreece())
+	storage.AddCountry(context.Background(), constructCountrySpain())
+
+	assert.Nil(t, storage.DeleteCountry(context.Background(), "Spain"))
+	assert.Equal(t, ErrCountryNotFound, storage.DeleteCountry(context.Background(), "spain"))
+	assert.Equal(t, ErrCountryNotFound, storage.DeleteCountry(context.Background(), "atlantis"))
+
+	_, err := storage.GetCountryById(context.Background(), "spain")
+	assert.Equal(t, ErrCountryNotFound, err)
+	countries, _ := storage.GetAllCountries(context.Background())
+	assert.Equal(t, 1, len(*countries))
+
+	trashed, err := storage.GetTrash()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(trashed))
+	assert.Equal(t, "spain", trashed[0].ID)
+	assert.Equal(t, "Madrid", trashed[0].Country.Capital)
+}
+
+func TestStorageRestoreCountryFromTrash(t *testing.T) {
+	storage := NewCountriesStorage()
+	storage.AddCountry(context.Background(), constructCountrySpain())
+	storage.DeleteCountry(context.Background(), "spain")
+
+	restored, err := storage.RestoreCountry("spain")
+	assert.Nil(t, err)
+	assert.Equal(t, "Spain", restored.Name)
+
+	country, err := storage.GetCountryById(context.Background(), "spain")
+	assert.Nil(t, err)
+	assert.Equal(t, "Madrid", country.Capital)
+	trashed, _ := storage.GetTrash()
+	assert.Equal(t, 0, len(trashed))
+
+	_, err = storage.RestoreCountry("spain")
+	assert.Equal(t, ErrCountryNotFound, err)
+}
+
+func TestStorageAddingCountryAgainEmptiesItsTrash(t *testing.T) {
+	storage := NewCountriesStorage()
+	storage.AddCountry(context.Background(), constructCountrySpain())
+	storage.DeleteCountry(context.Background(), "spain")
+	storage.AddCountry(context.Background(), constructCountrySpain())
+
+	trashed, _ := storage.GetTrash()
+	assert.Equal(t, 0, len(trashed))
+}
+
+func TestStoragePurgeCountry(t *testing.T) {
+	storage := NewCountriesStorage()
+	storage.AddCountry(context.Background(), constructCountrySpain())
+
+	_, err := storage.PurgeCountry("spain")
+	assert.Equal(t, ErrCountryNotFound, err, "only trashed countries can be purged")
+
+	storage.DeleteCountry(context.Background(), "spain")
+	purged, err := storage.PurgeCountry("spain")
+	assert.Nil(t, err)
+	assert.Equal(t, "Spain", purged.Name)
+
+	trashed, _ := storage.GetTrash()
+	assert.Equal(t, 0, len(trashed))
+	_, err = storage.RestoreCountry("spain")
+	assert.Equal(t, ErrCountryNotFound, err)
+	_, err = storage.GetCountryHistory("spain")
+	assert.Equal(t, ErrCountryNotFound, err)
+}
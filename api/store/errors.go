@@ -0,0 +1,31 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrCountryNotFound   = errors.New("Country not found.")
+	ErrCountryExists     = errors.New("Country already exists.")
+	ErrCountryChanged    = errors.New("Country was changed concurrently.")
+	ErrNoCountries       = errors.New("No countries available to choose randomly.")
+	ErrRevisionNotFound  = errors.New("Revision not found.")
+	ErrTransactionClosed = errors.New("Transaction is already committed or rolled back.")
+)
+
+/**
+The failure of one operation of a transaction, Index is zero based
+*/
+type OperationError struct {
+	Index int
+	Err   error
+}
+
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.Index, e.Err.Error())
+}
+
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
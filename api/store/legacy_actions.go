@@ -0,0 +1,127 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"go-countries-rest-api/api/models"
+)
+
+/**
+The methods of Actions before they took a context
+*/
+type LegacyActions interface {
+	AddCountry(country models.Country) (*models.Country, error)
+	DeleteCountry(countryId string) error
+	GetCountryById(countryId string) (*models.Country, error)
+	GetAllCountries() (*[]models.Country, error)
+	GetRandomCountryId() (*string, error)
+}
+
+/**
+Adapt a store without context support to Actions. Nothing is called once the context is done.
+Reads stop waiting for the store when the context is done while they are running, writes are
+always waited for, so an error is never returned for a write that was applied.
+*/
+func FromLegacy(legacy LegacyActions) Actions {
+	return &legacyAdapter{legacy: legacy}
+}
+
+type legacyAdapter struct {
+	legacy LegacyActions
+}
+
+func (a *legacyAdapter) AddCountry(ctx context.Context, country models.Country) (*models.Country, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.legacy.AddCountry(country)
+}
+
+func (a *legacyAdapter) DeleteCountry(ctx context.Context, countryId string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.legacy.DeleteCountry(countryId)
+}
+
+func (a *legacyAdapter) GetCountryById(ctx context.Context, countryId string) (*models.Country, error) {
+	result, err := read(ctx, func() (interface{}, error) {
+		country, err := a.legacy.GetCountryById(countryId)
+		return country, asSentinel(err, ErrCountryNotFound)
+	})
+	country, _ := result.(*models.Country)
+	return country, err
+}
+
+func (a *legacyAdapter) GetAllCountries(ctx context.Context) (*[]models.Country, error) {
+	result, err := read(ctx, func() (interface{}, error) {
+		return a.legacy.GetAllCountries()
+	})
+	countries, _ := result.(*[]models.Country)
+	return countries, err
+}
+
+func (a *legacyAdapter) GetRandomCountryId(ctx context.Context) (*string, error) {
+	result, err := read(ctx, func() (interface{}, error) {
+		id, err := a.legacy.GetRandomCountryId()
+		return id, asSentinel(err, ErrNoCountries)
+	})
+	id, _ := result.(*string)
+	return id, err
+}
+
+/**
+An error of a legacy store carrying the message of a sentinel, like errors.New("Country not found."),
+as legacy stores reported a missing country with their own errors
+*/
+type legacyError struct {
+	sentinel error
+	err      error
+}
+
+func (e *legacyError) Error() string {
+	return e.err.Error()
+}
+
+func (e *legacyError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+func (e *legacyError) Unwrap() error {
+	return e.err
+}
+
+/**
+Make an error of a legacy read with the message of the sentinel match it with errors.Is.
+Other errors, like a timeout of the backend, are returned unchanged.
+*/
+func asSentinel(err error, sentinel error) error {
+	if err == nil || errors.Is(err, sentinel) || err.Error() != sentinel.Error() {
+		return err
+	}
+	return &legacyError{sentinel: sentinel, err: err}
+}
+
+type readResult struct {
+	value interface{}
+	err   error
+}
+
+func read(ctx context.Context, call func() (interface{}, error)) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan readResult, 1)
+	go func() {
+		value, err := call()
+		done <- readResult{value: value, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		return result.value, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
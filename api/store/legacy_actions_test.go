@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/models"
+	"testing"
+	"time"
+)
+
+/**
+A store without context support, its reads block until release is closed
+*/
+type legacyStorage struct {
+	countries map[string]models.Country
+	release   chan struct{}
+}
+
+func (l *legacyStorage) AddCountry(country models.Country) (*models.Country, error) {
+	l.countries[country.Name] = country
+	return &country, nil
+}
+
+func (l *legacyStorage) DeleteCountry(countryId string) error {
+	delete(l.countries, countryId)
+	return nil
+}
+
+func (l *legacyStorage) GetCountryById(countryId string) (*models.Country, error) {
+	<-l.release
+	country, ok := l.countries[countryId]
+	if !ok {
+		return nil, errors.New("Country not found.")
+	}
+	return &country, nil
+}
+
+func (l *legacyStorage) GetAllCountries() (*[]models.Country, error) {
+	<-l.release
+	countries := []models.Country{}
+	return &countries, nil
+}
+
+func (l *legacyStorage) GetRandomCountryId() (*string, error) {
+	<-l.release
+	return nil, errors.New("No countries available to choose randomly.")
+}
+
+func TestLegacyAdapterDelegates(t *testing.T) {
+	legacy := &legacyStorage{countries: map[string]models.Country{}, release: make(chan struct{})}
+	close(legacy.release)
+	actions := FromLegacy(legacy)
+	ctx := context.Background()
+
+	_, err := actions.AddCountry(ctx, constructCountryGreece())
+	assert.Nil(t, err)
+	country, err := actions.GetCountryById(ctx, "Greece")
+	assert.Nil(t, err)
+	assert.Equal(t, "Athens", country.Capital)
+	assert.Nil(t, actions.DeleteCountry(ctx, "Greece"))
+	_, err = actions.GetCountryById(ctx, "Greece")
+	assert.True(t, errors.Is(err, ErrCountryNotFound))
+	assert.Equal(t, "Country not found.", err.Error())
+	_, err = actions.GetRandomCountryId(ctx)
+	assert.True(t, errors.Is(err, ErrNoCountries))
+	assert.False(t, errors.Is(err, ErrCountryNotFound))
+}
+
+func TestLegacyAdapterKeepsOtherErrors(t *testing.T) {
+	failure := errors.New("i/o timeout")
+	assert.Equal(t, failure, asSentinel(failure, ErrCountryNotFound))
+	assert.False(t, errors.Is(asSentinel(failure, ErrCountryNotFound), ErrCountryNotFound))
+	assert.Equal(t, ErrCountryNotFound, asSentinel(ErrCountryNotFound, ErrCountryNotFound))
+}
+
+func TestLegacyAdapterStopsWaitingForReadsAtDeadline(t *testing.T) {
+	legacy := &legacyStorage{countries: map[string]models.Country{}, release: make(chan struct{})}
+	defer close(legacy.release)
+	actions := FromLegacy(legacy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	countries, err := actions.GetAllCountries(ctx)
+	assert.Nil(t, countries)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestLegacyAdapterDoesNotWriteWithCancelledContext(t *testing.T) {
+	legacy := &legacyStorage{countries: map[string]models.Country{}, release: make(chan struct{})}
+	actions := FromLegacy(legacy)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := actions.AddCountry(ctx, constructCountryGreece())
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 0, len(legacy.countries))
+}
+
+func TestStorageRespectsCancelledContext(t *testing.T) {
+	storage := NewCountriesStorage()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := storage.AddCountry(ctx, constructCountryGreece())
+	assert.Equal(t, context.Canceled, err)
+	_, err = storage.GetAllCountries(ctx)
+	assert.Equal(t, context.Canceled, err)
+	_, err = storage.GetCountryById(ctx, "greece")
+	assert.Equal(t, context.Canceled, err)
+	_, err = storage.GetRandomCountryId(ctx)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, storage.DeleteCountry(ctx, "greece"))
+
+	countries, _ := storage.GetAllCountries(context.Background())
+	assert.Equal(t, 0, len(*countries))
+}
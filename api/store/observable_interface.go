@@ -0,0 +1,10 @@
+package store
+
+import "go-countries-rest-api/api/events"
+
+/**
+Implemented by the stores publishing an event for every mutation
+*/
+type Observable interface {
+	Events() *events.Hub
+}
@@ -0,0 +1,28 @@
+package store
+
+import (
+	"context"
+	"go-countries-rest-api/api/models"
+)
+
+/**
+Implemented by the stores able to replace all their countries at once
+*/
+type Replaceable interface {
+	/**
+	Replace every country by the given ones atomically, readers see either the previous
+	countries or the new ones. Countries missing from the given ones are deleted. The
+	replacements are the countries that actually changed, as seen under the same lock.
+	*/
+	ReplaceCountries(ctx context.Context, countries []models.Country) ([]Replacement, error)
+}
+
+/**
+A country changed by ReplaceCountries or a transaction. Before is nil when the country
+was added, After when it was deleted.
+*/
+type Replacement struct {
+	Id     string
+	Before *models.Country
+	After  *models.Country
+}
@@ -0,0 +1,23 @@
+package store
+
+import (
+	"context"
+	"go-countries-rest-api/api/models"
+)
+
+/**
+Implemented by the stores able to return the country a write replaced, read under the
+same lock as the write
+*/
+type Swappable interface {
+	/**
+	Add or replace a country like AddCountry, also returning the replaced country, nil when
+	the country is added
+	*/
+	SwapCountry(ctx context.Context, country models.Country) (previous *models.Country, saved *models.Country, err error)
+
+	/**
+	Delete a country like DeleteCountry, returning the deleted country
+	*/
+	TakeCountry(ctx context.Context, countryId string) (*models.Country, error)
+}
@@ -0,0 +1,11 @@
+package store
+
+import "time"
+
+/**
+Implemented by the stores tracking the time of their last write.
+It is used for the Last-Modified and If-Modified-Since HTTP caching headers.
+*/
+type Timestamped interface {
+	LastModified() time.Time
+}
@@ -0,0 +1,50 @@
+package store
+
+import "go-countries-rest-api/api/models"
+
+/**
+Changes staged in a transaction are invisible to the other readers of the store
+until Commit, which applies all of them or none. A Transaction is not safe for
+concurrent use.
+*/
+type Transaction interface {
+	/**
+	Stage the addition of a country, replacing it when it exists
+	*/
+	AddCountry(country models.Country) error
+
+	/**
+	Stage the replacement of a country, which must exist at Commit
+	*/
+	UpdateCountry(country models.Country) error
+
+	/**
+	Stage the deletion of a country, which must exist at Commit
+	*/
+	DeleteCountry(countryId string) error
+
+	/**
+	Read a country, including the changes staged in the transaction
+	*/
+	GetCountryById(countryId string) (*models.Country, error)
+
+	/**
+	Apply the staged changes atomically. When an operation fails, nothing is applied
+	and an *OperationError is returned. The transaction is closed either way.
+	The replacements are one per operation, in order, with the version each one
+	replaced as seen under the same lock.
+	*/
+	Commit() ([]Replacement, error)
+
+	/**
+	Discard the staged changes and close the transaction
+	*/
+	Rollback() error
+}
+
+/**
+Implemented by the stores supporting transactions
+*/
+type Transactional interface {
+	Begin() (Transaction, error)
+}
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"go-countries-rest-api/api/models"
+	"time"
+)
+
+/**
+A soft deleted country, hidden from the reads until it is restored or purged
+*/
+type TrashedCountry struct {
+	ID        string         `json:"id"`
+	DeletedAt time.Time      `json:"deletedAt"`
+	Country   models.Country `json:"country"`
+}
+
+/**
+Implemented by the stores where DeleteCountry moves the country to a trash
+*/
+type Trash interface {
+	/**
+	The soft deleted countries, most recently deleted first
+	*/
+	GetTrash() ([]TrashedCountry, error)
+
+	/**
+	Move a country out of the trash, making it visible again
+	*/
+	RestoreCountry(countryId string) (*models.Country, error)
+
+	/**
+	Remove a country of the trash permanently, with its revisions
+	*/
+	PurgeCountry(countryId string) (*models.Country, error)
+}
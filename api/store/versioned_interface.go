@@ -0,0 +1,38 @@
+package store
+
+import (
+	"go-countries-rest-api/api/models"
+	"time"
+)
+
+/**
+A version of a country. Every write of a country creates a new revision, numbered from 1.
+Deleted revisions record the deletion of the country and have no Country.
+*/
+type Revision struct {
+	Number  int             `json:"revision"`
+	Time    time.Time       `json:"time"`
+	Deleted bool            `json:"deleted"`
+	Country *models.Country `json:"country,omitempty"`
+}
+
+/**
+Implemented by the stores keeping every revision of the countries
+*/
+type Versioned interface {
+	/**
+	All revisions of a country, oldest first
+	*/
+	GetCountryHistory(countryId string) ([]Revision, error)
+
+	/**
+	The country as it was at the given time
+	*/
+	GetCountryAsOf(countryId string, asOf time.Time) (*models.Country, error)
+
+	/**
+	Write a previous revision of a country again, as a new revision. Also returns the
+	country it replaced, nil when the country was deleted.
+	*/
+	RestoreRevision(countryId string, revision int) (previous *models.Country, restored *models.Country, err error)
+}
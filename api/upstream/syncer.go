@@ -0,0 +1,318 @@
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go-countries-rest-api/api/dataset"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/store"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultInterval = time.Hour
+	DefaultTimeout  = 30 * time.Second
+)
+
+type Config struct {
+	// path of a dataset file, or http(s) URL of one
+	Source string
+
+	// defaults to the Content-Type of an HTTP response, then to the extension of the source
+	Format dataset.Format
+
+	// between two syncs, defaults to DefaultInterval
+	Interval time.Duration
+
+	// of every sync, defaults to DefaultTimeout
+	Timeout time.Duration
+
+	// defaults to http.DefaultClient
+	Client *http.Client
+
+	// optional, called with the countries every sync changed, e.g. to audit them
+	OnApplied func(replacements []store.Replacement)
+}
+
+/**
+The ids of the countries a sync added, removed and changed
+*/
+type Summary = dataset.Summary
+
+/**
+The state of the syncs. LastSync is the start of the last sync, successful or not,
+Error its failure and Summary its changes when it succeeded.
+*/
+type Status struct {
+	Source      string     `json:"source"`
+	Interval    string     `json:"interval"`
+	Syncs       int        `json:"syncs"`
+	Failures    int        `json:"failures"`
+	LastSync    *time.Time `json:"lastSync,omitempty"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	Duration    string     `json:"duration,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	Summary     *Summary   `json:"summary,omitempty"`
+	NextSync    *time.Time `json:"nextSync,omitempty"`
+}
+
+/**
+Syncer makes a store track an upstream dataset. Every sync fetches the dataset and
+replaces the countries of the store by it, see apply. Countries missing upstream are
+deleted, so an empty or invalid dataset fails the sync instead of being applied.
+*/
+type Syncer struct {
+	sync.Mutex
+	config  Config
+	actions store.Actions
+	status  Status
+
+	// one sync at a time
+	running sync.Mutex
+	stop    chan struct{}
+	done    sync.WaitGroup
+}
+
+func NewSyncer(config Config, actions store.Actions) *Syncer {
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	return &Syncer{
+		config:  config,
+		actions: actions,
+		status:  Status{Source: config.Source, Interval: config.Interval.String()},
+		stop:    make(chan struct{}),
+	}
+}
+
+/**
+Sync now, then every Interval until Stop
+*/
+func (s *Syncer) Start() {
+	s.done.Add(1)
+	go func() {
+		defer s.done.Done()
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+		for {
+			s.Sync(context.Background())
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+/**
+Stop the periodic syncs, waiting for the running one
+*/
+func (s *Syncer) Stop() {
+	close(s.stop)
+	s.done.Wait()
+}
+
+func (s *Syncer) Status() Status {
+	s.Lock()
+	defer s.Unlock()
+	return s.status
+}
+
+/**
+Fetch the upstream dataset and apply its differences with the store, recording the
+outcome in the status
+*/
+func (s *Syncer) Sync(ctx context.Context) (*Summary, error) {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+	start := time.Now().UTC()
+	summary, err := s.sync(ctx)
+
+	s.Lock()
+	defer s.Unlock()
+	s.status.Syncs++
+	s.status.LastSync = &start
+	s.status.Duration = time.Since(start).String()
+	next := start.Add(s.config.Interval)
+	s.status.NextSync = &next
+	if err != nil {
+		s.status.Failures++
+		s.status.Error = err.Error()
+		s.status.Summary = nil
+		return nil, err
+	}
+	s.status.LastSuccess = &start
+	s.status.Error = ""
+	s.status.Summary = summary
+	return summary, nil
+}
+
+func (s *Syncer) sync(ctx context.Context) (*Summary, error) {
+	upstream, err := s.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(upstream) == 0 {
+		return nil, errors.New("upstream dataset has no countries")
+	}
+	if violations := dataset.Validate(upstream); len(violations) > 0 {
+		return nil, fmt.Errorf("upstream dataset is invalid, %d violations: %s", len(violations), violations[0].String())
+	}
+
+	replacements, err := apply(ctx, s.actions, upstream)
+	if err != nil {
+		return nil, err
+	}
+	if s.config.OnApplied != nil && len(replacements) > 0 {
+		s.config.OnApplied(replacements)
+	}
+	summary := dataset.Summarize(replacements)
+	return &summary, nil
+}
+
+/**
+Read the dataset from the file or the URL of the source
+*/
+func (s *Syncer) fetch(ctx context.Context) ([]models.Country, error) {
+	source := s.config.Source
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		data, err := ioutil.ReadFile(source)
+		if err != nil {
+			return nil, err
+		}
+		return s.decode(data, "", source)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "GET", source, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.config.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", source, response.Status)
+	}
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	path := source
+	if parsed, err := url.Parse(source); err == nil {
+		path = parsed.Path
+	}
+	return s.decode(data, response.Header.Get("Content-Type"), path)
+}
+
+func (s *Syncer) decode(data []byte, contentType string, path string) ([]models.Country, error) {
+	format := s.config.Format
+	if format == "" {
+		format = formatOfContentType(contentType)
+	}
+	if format == "" {
+		format = dataset.FormatOf(path)
+	}
+	countries, err := dataset.Read(bytes.NewReader(data), format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", s.config.Source, err)
+	}
+	return countries, nil
+}
+
+func formatOfContentType(contentType string) dataset.Format {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/x-ndjson", "application/ndjson":
+		return dataset.NDJSON
+	case "text/csv":
+		return dataset.CSV
+	case "application/json":
+		return dataset.JSON
+	default:
+		return ""
+	}
+}
+
+/**
+Replace the countries of the store by the upstream ones. A store.Replaceable compares and
+writes under one lock, so the replaced versions it returns are the ones actually
+overwritten. Otherwise the differences with the current countries are applied in a
+transaction when the store supports them, else one country after the other.
+*/
+func apply(ctx context.Context, actions store.Actions, upstream []models.Country) ([]store.Replacement, error) {
+	if replaceable, ok := actions.(store.Replaceable); ok {
+		return replaceable.ReplaceCountries(ctx, upstream)
+	}
+
+	current, err := actions.GetAllCountries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	diff := dataset.Compare(*current, upstream)
+	if transactional, ok := actions.(store.Transactional); ok {
+		transaction, err := transactional.Begin()
+		if err != nil {
+			return nil, err
+		}
+		for _, country := range diff.Added {
+			if err := transaction.AddCountry(country); err != nil {
+				transaction.Rollback()
+				return nil, err
+			}
+		}
+		for _, changed := range diff.Changed {
+			if err := transaction.UpdateCountry(changed.After); err != nil {
+				transaction.Rollback()
+				return nil, err
+			}
+		}
+		for _, country := range diff.Removed {
+			if err := transaction.DeleteCountry(strings.ToLower(country.Name)); err != nil {
+				transaction.Rollback()
+				return nil, err
+			}
+		}
+		if ctx.Err() != nil {
+			transaction.Rollback()
+			return nil, ctx.Err()
+		}
+		return transaction.Commit()
+	}
+
+	for _, country := range diff.Added {
+		if _, err := actions.AddCountry(ctx, country); err != nil {
+			return nil, err
+		}
+	}
+	for _, changed := range diff.Changed {
+		if _, err := actions.AddCountry(ctx, changed.After); err != nil {
+			return nil, err
+		}
+	}
+	for _, country := range diff.Removed {
+		if err := actions.DeleteCountry(ctx, strings.ToLower(country.Name)); err != nil {
+			return nil, err
+		}
+	}
+	return diff.Replacements(), nil
+}
@@ -0,0 +1,150 @@
+package upstream
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/store"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const upstreamNdjson = `{"name": "Greece", "alpha2Code": "GR", "capital": "Nafplio", "currencies": [{"code": "EUR"}]}
+{"name": "Japan", "alpha2Code": "JP", "capital": "Tokyo", "currencies": [{"code": "JPY"}]}
+`
+
+func storeWith(countries ...models.Country) *store.CountriesStorage {
+	storage := store.NewCountriesStorage()
+	for _, country := range countries {
+		storage.AddCountry(context.Background(), country)
+	}
+	return storage
+}
+
+func countryNames(t *testing.T, actions store.Actions) map[string]string {
+	countries, err := actions.GetAllCountries(context.Background())
+	assert.Nil(t, err)
+	capitals := map[string]string{}
+	for _, country := range *countries {
+		capitals[country.Name] = country.Capital
+	}
+	return capitals
+}
+
+func TestSyncFromHttp(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/x-ndjson")
+		writer.Write([]byte(upstreamNdjson))
+	}))
+	defer upstream.Close()
+	storage := storeWith(
+		models.Country{Name: "Greece", Alpha2Code: "GR", Capital: "Athens", Currencies: []models.Currency{{Code: "EUR"}}},
+		models.Country{Name: "Spain", Alpha2Code: "ES", Capital: "Madrid"},
+	)
+	applied := 0
+	onApplied := func(replacements []store.Replacement) { applied++ }
+	syncer := NewSyncer(Config{Source: upstream.URL + "/countries", OnApplied: onApplied}, storage)
+
+	summary, err := syncer.Sync(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, &Summary{Added: []string{"japan"}, Removed: []string{"spain"}, Changed: []string{"greece"}}, summary)
+	assert.Equal(t, 1, applied)
+	assert.Equal(t, map[string]string{"Greece": "Nafplio", "Japan": "Tokyo"}, countryNames(t, storage))
+
+	status := syncer.Status()
+	assert.Equal(t, upstream.URL+"/countries", status.Source)
+	assert.Equal(t, 1, status.Syncs)
+	assert.Equal(t, summary, status.Summary)
+	assert.Equal(t, status.LastSync, status.LastSuccess)
+	assert.Equal(t, "1h0m0s", status.Interval)
+
+	summary, err = syncer.Sync(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, &Summary{Added: []string{}, Removed: []string{}, Changed: []string{}}, summary)
+	assert.Equal(t, 1, applied)
+}
+
+func TestSyncFromFileWithoutTransactions(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "countries.csv")
+	ioutil.WriteFile(source, []byte("name,alpha2Code,capital\nGreece,GR,Athens\nJapan,JP,Tokyo\n"), 0644)
+	// hides the transactions of the store
+	actions := struct{ store.Actions }{storeWith(models.Country{Name: "Spain", Alpha2Code: "ES"})}
+
+	summary, err := NewSyncer(Config{Source: source}, actions).Sync(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, &Summary{Added: []string{"greece", "japan"}, Removed: []string{"spain"}, Changed: []string{}}, summary)
+	assert.Equal(t, map[string]string{"Greece": "Athens", "Japan": "Tokyo"}, countryNames(t, actions))
+}
+
+func TestSyncInATransaction(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "countries.csv")
+	ioutil.WriteFile(source, []byte("name,alpha2Code,capital\nGreece,GR,Nafplio\nJapan,JP,Tokyo\n"), 0644)
+	storage := storeWith(models.Country{Name: "Greece", Alpha2Code: "GR", Capital: "Athens"}, models.Country{Name: "Spain", Alpha2Code: "ES"})
+	// hides the replacement of the store, not its transactions
+	actions := struct {
+		store.Actions
+		store.Transactional
+	}{storage, storage}
+
+	var replaced []store.Replacement
+	onApplied := func(replacements []store.Replacement) { replaced = replacements }
+	summary, err := NewSyncer(Config{Source: source, OnApplied: onApplied}, actions).Sync(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, &Summary{Added: []string{"japan"}, Removed: []string{"spain"}, Changed: []string{"greece"}}, summary)
+	assert.Equal(t, 3, len(replaced))
+	assert.Equal(t, map[string]string{"Greece": "Nafplio", "Japan": "Tokyo"}, countryNames(t, storage))
+}
+
+func TestFailedSyncLeavesTheStore(t *testing.T) {
+	body := "[]"
+	statusCode := http.StatusOK
+	upstream := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(statusCode)
+		writer.Write([]byte(body))
+	}))
+	defer upstream.Close()
+	storage := storeWith(models.Country{Name: "Spain", Alpha2Code: "ES"})
+	syncer := NewSyncer(Config{Source: upstream.URL + "/countries.json"}, storage)
+
+	_, err := syncer.Sync(context.Background())
+	assert.EqualError(t, err, "upstream dataset has no countries")
+
+	body = `[{"name": "Greece", "alpha2Code": "gr"}]`
+	_, err = syncer.Sync(context.Background())
+	assert.EqualError(t, err, "upstream dataset is invalid, 1 violations: /0/alpha2Code: must match the pattern ^[A-Z]{2}$")
+
+	statusCode = http.StatusBadGateway
+	_, err = syncer.Sync(context.Background())
+	assert.EqualError(t, err, "GET "+upstream.URL+"/countries.json: 502 Bad Gateway")
+
+	status := syncer.Status()
+	assert.Equal(t, 3, status.Syncs)
+	assert.Equal(t, 3, status.Failures)
+	assert.Equal(t, "GET "+upstream.URL+"/countries.json: 502 Bad Gateway", status.Error)
+	assert.Nil(t, status.LastSuccess)
+	assert.Nil(t, status.Summary)
+	assert.Equal(t, map[string]string{"Spain": ""}, countryNames(t, storage))
+}
+
+func TestStartSyncsPeriodically(t *testing.T) {
+	var fetches int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		writer.Write([]byte(`[{"name": "Greece", "alpha2Code": "GR"}]`))
+	}))
+	defer upstream.Close()
+
+	syncer := NewSyncer(Config{Source: upstream.URL, Interval: 10 * time.Millisecond}, store.NewCountriesStorage())
+	syncer.Start()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&fetches) >= 3 }, time.Second, 5*time.Millisecond)
+	syncer.Stop()
+
+	status := syncer.Status()
+	assert.True(t, status.Syncs >= 3)
+	assert.Equal(t, 0, status.Failures)
+}
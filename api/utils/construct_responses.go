@@ -1,6 +1,9 @@
 package utils
 
-import "net/http"
+import (
+	"encoding/json"
+	"net/http"
+)
 
 func ConstructErrorResponse(writer http.ResponseWriter, errorMessage string, serverError int) {
 	writer.WriteHeader(serverError)
@@ -14,3 +17,27 @@ func ConstructSuccessfulResponse(writer http.ResponseWriter, statusCode int, jso
 		writer.Write(jsonBytes)
 	}
 }
+
+/**
+Problem details as described in RFC 7807
+*/
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func ConstructProblemResponse(writer http.ResponseWriter, statusCode int, detail string) {
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Detail: detail,
+	}
+	jsonBytes, _ := json.Marshal(problem)
+
+	writer.Header().Set("content-type", "application/problem+json")
+	writer.WriteHeader(statusCode)
+	writer.Write(jsonBytes)
+}
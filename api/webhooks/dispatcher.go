@@ -0,0 +1,385 @@
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go-countries-rest-api/api/events"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+const (
+	// deliveries kept per subscription, the oldest are forgotten first
+	deliveriesPerSubscription = 100
+	maxDeadLetters            = 1000
+)
+
+type Attempt struct {
+	Number     int       `json:"number"`
+	Time       time.Time `json:"time"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+/**
+A payload sent to a subscription, with every attempt made to deliver it.
+A delivery failing MaxAttempts times is moved to the dead letters.
+*/
+type Delivery struct {
+	ID             string       `json:"id"`
+	SubscriptionId string       `json:"subscriptionId"`
+	Event          events.Event `json:"event"`
+	Status         string       `json:"status"`
+	Attempts       []Attempt    `json:"attempts"`
+}
+
+/**
+The body POSTed to the subscriptions
+*/
+type Payload struct {
+	DeliveryId     string       `json:"deliveryId"`
+	SubscriptionId string       `json:"subscriptionId"`
+	Event          events.Event `json:"event"`
+}
+
+type Config struct {
+	// defaults to 5
+	MaxAttempts int
+
+	// delay before the given attempt (2 for the first retry), defaults to ExponentialBackoff
+	Backoff func(attempt int) time.Duration
+
+	// defaults to a client with a 10 seconds timeout
+	Client *http.Client
+
+	// concurrent deliveries, defaults to 4
+	Workers int
+}
+
+/**
+1s, 2s, 4s... up to 5 minutes
+*/
+func ExponentialBackoff(attempt int) time.Duration {
+	delay := time.Second << uint(attempt-2)
+	if attempt < 2 || delay <= 0 || delay > 5*time.Minute {
+		return 5 * time.Minute
+	}
+	return delay
+}
+
+/**
+Dispatcher keeps the webhook subscriptions and delivers the events of a hub to them.
+Deliveries are made by a pool of workers and failed ones are retried with backoff.
+*/
+type Dispatcher struct {
+	sync.Mutex
+	config        Config
+	subscriptions map[string]*Subscription
+	deliveries    map[string][]*Delivery
+	deadLetters   []*Delivery
+
+	queue   chan *Delivery
+	stop    chan struct{}
+	workers sync.WaitGroup
+}
+
+func NewDispatcher(config Config) *Dispatcher {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+	if config.Backoff == nil {
+		config.Backoff = ExponentialBackoff
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if config.Workers <= 0 {
+		config.Workers = 4
+	}
+
+	return &Dispatcher{
+		config:        config,
+		subscriptions: map[string]*Subscription{},
+		deliveries:    map[string][]*Delivery{},
+		queue:         make(chan *Delivery, 1024),
+		stop:          make(chan struct{}),
+	}
+}
+
+/**
+Start the workers and the delivery of the events published on the hub
+*/
+func (d *Dispatcher) Start(hub *events.Hub) {
+	for i := 0; i < d.config.Workers; i++ {
+		d.workers.Add(1)
+		go d.work()
+	}
+	d.workers.Add(1)
+	go d.listen(hub, hub.Subscribe(256))
+}
+
+/**
+Stop the workers. Pending retries are abandoned.
+*/
+func (d *Dispatcher) Stop() {
+	close(d.stop)
+	d.workers.Wait()
+}
+
+func (d *Dispatcher) AddSubscription(subscription Subscription) (*Subscription, error) {
+	if err := subscription.validate(); err != nil {
+		return nil, err
+	}
+	subscription.ID = newId()
+	subscription.CreatedAt = time.Now().UTC()
+	if subscription.Secret == "" {
+		subscription.Secret = newId() + newId()
+	}
+
+	d.Lock()
+	defer d.Unlock()
+	d.subscriptions[subscription.ID] = &subscription
+	created := subscription
+	return &created, nil
+}
+
+/**
+All subscriptions, without their secrets, oldest first
+*/
+func (d *Dispatcher) Subscriptions() []Subscription {
+	d.Lock()
+	defer d.Unlock()
+
+	subscriptions := make([]Subscription, 0, len(d.subscriptions))
+	for _, subscription := range d.subscriptions {
+		withoutSecret := *subscription
+		withoutSecret.Secret = ""
+		subscriptions = append(subscriptions, withoutSecret)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+	return subscriptions
+}
+
+func (d *Dispatcher) Subscription(id string) (*Subscription, error) {
+	d.Lock()
+	defer d.Unlock()
+
+	subscription, ok := d.subscriptions[id]
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	withoutSecret := *subscription
+	withoutSecret.Secret = ""
+	return &withoutSecret, nil
+}
+
+/**
+Delete a subscription. Its pending deliveries are dropped.
+*/
+func (d *Dispatcher) DeleteSubscription(id string) error {
+	d.Lock()
+	defer d.Unlock()
+
+	if _, ok := d.subscriptions[id]; !ok {
+		return ErrSubscriptionNotFound
+	}
+	delete(d.subscriptions, id)
+	delete(d.deliveries, id)
+	return nil
+}
+
+/**
+The latest deliveries of a subscription with their attempts, newest first
+*/
+func (d *Dispatcher) Deliveries(subscriptionId string) ([]Delivery, error) {
+	d.Lock()
+	defer d.Unlock()
+
+	if _, ok := d.subscriptions[subscriptionId]; !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	deliveries := d.deliveries[subscriptionId]
+	copies := make([]Delivery, 0, len(deliveries))
+	for i := len(deliveries) - 1; i >= 0; i-- {
+		copies = append(copies, copyDelivery(deliveries[i]))
+	}
+	return copies, nil
+}
+
+/**
+The deliveries that failed MaxAttempts times, newest first
+*/
+func (d *Dispatcher) DeadLetters() []Delivery {
+	d.Lock()
+	defer d.Unlock()
+
+	copies := make([]Delivery, 0, len(d.deadLetters))
+	for i := len(d.deadLetters) - 1; i >= 0; i-- {
+		copies = append(copies, copyDelivery(d.deadLetters[i]))
+	}
+	return copies
+}
+
+/**
+Turn the events of the hub into deliveries. When the dispatcher falls behind,
+the hub closes the subscription, and the missed events are replayed from the hub log.
+*/
+func (d *Dispatcher) listen(hub *events.Hub, subscription *events.Subscription) {
+	defer d.workers.Done()
+
+	var lastId uint64
+	for {
+		select {
+		case <-d.stop:
+			subscription.Close()
+			return
+		case event, open := <-subscription.Events():
+			if !open {
+				var missed []events.Event
+				subscription, missed, _ = hub.SubscribeSince(lastId, 256)
+				for _, event := range missed {
+					d.dispatch(event)
+					lastId = event.ID
+				}
+				continue
+			}
+			d.dispatch(event)
+			lastId = event.ID
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch(event events.Event) {
+	d.Lock()
+	pending := []*Delivery{}
+	for _, subscription := range d.subscriptions {
+		if !subscription.wants(event) {
+			continue
+		}
+		delivery := &Delivery{ID: newId(), SubscriptionId: subscription.ID, Event: event, Status: StatusPending}
+		deliveries := append(d.deliveries[subscription.ID], delivery)
+		if len(deliveries) > deliveriesPerSubscription {
+			deliveries = deliveries[len(deliveries)-deliveriesPerSubscription:]
+		}
+		d.deliveries[subscription.ID] = deliveries
+		pending = append(pending, delivery)
+	}
+	d.Unlock()
+
+	for _, delivery := range pending {
+		d.enqueue(delivery)
+	}
+}
+
+func (d *Dispatcher) enqueue(delivery *Delivery) {
+	select {
+	case d.queue <- delivery:
+	case <-d.stop:
+	}
+}
+
+func (d *Dispatcher) work() {
+	defer d.workers.Done()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case delivery := <-d.queue:
+			d.attempt(delivery)
+		}
+	}
+}
+
+func (d *Dispatcher) attempt(delivery *Delivery) {
+	d.Lock()
+	subscription, ok := d.subscriptions[delivery.SubscriptionId]
+	var target Subscription
+	if ok {
+		target = *subscription
+	}
+	number := len(delivery.Attempts) + 1
+	d.Unlock()
+	if !ok {
+		// the subscription was deleted in the meantime
+		return
+	}
+
+	attempt := Attempt{Number: number, Time: time.Now().UTC()}
+	statusCode, err := d.send(target, delivery)
+	attempt.StatusCode = statusCode
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	d.Lock()
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	retry := false
+	switch {
+	case err == nil:
+		delivery.Status = StatusDelivered
+	case number >= d.config.MaxAttempts:
+		delivery.Status = StatusFailed
+		d.deadLetters = append(d.deadLetters, delivery)
+		if len(d.deadLetters) > maxDeadLetters {
+			d.deadLetters = d.deadLetters[len(d.deadLetters)-maxDeadLetters:]
+		}
+	default:
+		retry = true
+	}
+	d.Unlock()
+
+	if retry {
+		time.AfterFunc(d.config.Backoff(number+1), func() {
+			d.enqueue(delivery)
+		})
+	}
+}
+
+func (d *Dispatcher) send(subscription Subscription, delivery *Delivery) (int, error) {
+	body, err := json.Marshal(Payload{DeliveryId: delivery.ID, SubscriptionId: subscription.ID, Event: delivery.Event})
+	if err != nil {
+		return 0, err
+	}
+
+	request, err := http.NewRequest("POST", subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "go-countries-rest-api-webhooks")
+	request.Header.Set("X-Webhook-Id", subscription.ID)
+	request.Header.Set("X-Webhook-Delivery", delivery.ID)
+	request.Header.Set("X-Webhook-Event", string(delivery.Event.Type))
+	request.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(SignatureHeader, Sign(subscription.Secret, timestamp, body))
+
+	response, err := d.config.Client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
+
+func copyDelivery(delivery *Delivery) Delivery {
+	copied := *delivery
+	copied.Attempts = append([]Attempt{}, delivery.Attempts...)
+	return copied
+}
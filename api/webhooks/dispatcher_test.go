@@ -0,0 +1,149 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/events"
+	"go-countries-rest-api/api/models"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDeliveryIsSignedAndRecorded(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		received <- request
+		bodies <- body
+	}))
+	defer receiver.Close()
+
+	hub := events.NewHub(10)
+	dispatcher := NewDispatcher(Config{})
+	dispatcher.Start(hub)
+	defer dispatcher.Stop()
+
+	subscription, err := dispatcher.AddSubscription(Subscription{URL: receiver.URL, Secret: "s3cret"})
+	assert.Nil(t, err)
+	hub.Publish(events.Created, "greece", &models.Country{Name: "Greece"}, nil)
+
+	request := <-received
+	body := <-bodies
+	timestamp, _ := strconv.ParseInt(request.Header.Get(TimestampHeader), 10, 64)
+	assert.True(t, Verify("s3cret", timestamp, body, request.Header.Get(SignatureHeader)))
+	assert.Equal(t, "created", request.Header.Get("X-Webhook-Event"))
+
+	var payload Payload
+	json.Unmarshal(body, &payload)
+	assert.Equal(t, subscription.ID, payload.SubscriptionId)
+	assert.Equal(t, "Greece", payload.Event.Country.Name)
+
+	deliveries := waitForDeliveries(dispatcher, subscription.ID, StatusDelivered)
+	assert.Equal(t, 1, len(deliveries[0].Attempts))
+	assert.Equal(t, http.StatusOK, deliveries[0].Attempts[0].StatusCode)
+}
+
+func TestFailedDeliveryIsRetried(t *testing.T) {
+	var calls int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	hub := events.NewHub(10)
+	dispatcher := NewDispatcher(Config{Backoff: func(int) time.Duration { return time.Millisecond }})
+	dispatcher.Start(hub)
+	defer dispatcher.Stop()
+
+	subscription, _ := dispatcher.AddSubscription(Subscription{URL: receiver.URL})
+	hub.Publish(events.Deleted, "greece", &models.Country{Name: "Greece"}, nil)
+
+	deliveries := waitForDeliveries(dispatcher, subscription.ID, StatusDelivered)
+	assert.Equal(t, 3, len(deliveries[0].Attempts))
+	assert.Equal(t, http.StatusServiceUnavailable, deliveries[0].Attempts[0].StatusCode)
+	assert.Equal(t, "unexpected status 503", deliveries[0].Attempts[0].Error)
+	assert.Equal(t, 3, deliveries[0].Attempts[2].Number)
+	assert.Equal(t, 0, len(dispatcher.DeadLetters()))
+}
+
+func TestDeliveryIsDeadLetteredAfterMaxAttempts(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	hub := events.NewHub(10)
+	dispatcher := NewDispatcher(Config{MaxAttempts: 2, Backoff: func(int) time.Duration { return time.Millisecond }})
+	dispatcher.Start(hub)
+	defer dispatcher.Stop()
+
+	subscription, _ := dispatcher.AddSubscription(Subscription{URL: receiver.URL})
+	hub.Publish(events.Created, "greece", &models.Country{Name: "Greece"}, nil)
+
+	deliveries := waitForDeliveries(dispatcher, subscription.ID, StatusFailed)
+	deadLetters := dispatcher.DeadLetters()
+	assert.Equal(t, 2, len(deliveries[0].Attempts))
+	assert.Equal(t, 1, len(deadLetters))
+	assert.Equal(t, deliveries[0].ID, deadLetters[0].ID)
+}
+
+func TestSubscriptionFiltersEvents(t *testing.T) {
+	euro := &models.Country{Name: "Greece", Currencies: []models.Currency{{Code: "EUR"}}}
+	pound := &models.Country{Name: "Jersey", Currencies: []models.Currency{{Code: "GBP"}}}
+	subscription := Subscription{Events: []events.EventType{events.Deleted}, Filter: models.CountryFilter{Currency: "EUR"}}
+
+	assert.True(t, subscription.wants(events.Event{Type: events.Deleted, Country: euro}))
+	assert.False(t, subscription.wants(events.Event{Type: events.Created, Country: euro}))
+	assert.False(t, subscription.wants(events.Event{Type: events.Deleted, Country: pound}))
+}
+
+func TestSubscriptionValidation(t *testing.T) {
+	dispatcher := NewDispatcher(Config{})
+	_, relativeError := dispatcher.AddSubscription(Subscription{URL: "/hooks"})
+	_, schemeError := dispatcher.AddSubscription(Subscription{URL: "ftp://partner.example.com/hooks"})
+	_, eventError := dispatcher.AddSubscription(Subscription{URL: "https://partner.example.com/hooks", Events: []events.EventType{"renamed"}})
+	created, err := dispatcher.AddSubscription(Subscription{URL: "https://partner.example.com/hooks"})
+
+	assert.Equal(t, ErrInvalidURL, relativeError)
+	assert.Equal(t, ErrInvalidURL, schemeError)
+	assert.Equal(t, ErrInvalidEventType, eventError)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, created.Secret)
+	assert.Equal(t, "", dispatcher.Subscriptions()[0].Secret)
+}
+
+func TestDeleteSubscription(t *testing.T) {
+	dispatcher := NewDispatcher(Config{})
+	created, _ := dispatcher.AddSubscription(Subscription{URL: "https://partner.example.com/hooks"})
+
+	assert.Nil(t, dispatcher.DeleteSubscription(created.ID))
+	assert.Equal(t, ErrSubscriptionNotFound, dispatcher.DeleteSubscription(created.ID))
+	_, err := dispatcher.Subscription(created.ID)
+	assert.Equal(t, ErrSubscriptionNotFound, err)
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, ExponentialBackoff(2))
+	assert.Equal(t, 2*time.Second, ExponentialBackoff(3))
+	assert.Equal(t, 8*time.Second, ExponentialBackoff(5))
+	assert.Equal(t, 5*time.Minute, ExponentialBackoff(40))
+}
+
+func waitForDeliveries(dispatcher *Dispatcher, subscriptionId string, status string) []Delivery {
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		deliveries, _ := dispatcher.Deliveries(subscriptionId)
+		if (len(deliveries) > 0 && deliveries[0].Status == status) || time.Now().After(deadline) {
+			return deliveries
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,16 @@
+package webhooks
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	signature := Sign("s3cret", 1600000000, []byte(`{"id":1}`))
+	assert.Equal(t, "sha256=", signature[:7])
+	assert.True(t, Verify("s3cret", 1600000000, []byte(`{"id":1}`), signature))
+	assert.False(t, Verify("s3cret", 1600000001, []byte(`{"id":1}`), signature))
+	assert.False(t, Verify("other", 1600000000, []byte(`{"id":1}`), signature))
+	assert.False(t, Verify("s3cret", 1600000000, []byte(`{"id":2}`), signature))
+	assert.False(t, Verify("s3cret", 1600000000, []byte(`{"id":1}`), signature[7:]))
+}
@@ -0,0 +1,65 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"go-countries-rest-api/api/events"
+	"go-countries-rest-api/api/models"
+	"net/url"
+	"time"
+)
+
+var (
+	ErrSubscriptionNotFound = errors.New("Webhook subscription not found.")
+	ErrInvalidURL           = errors.New("Webhook URL must be an absolute http or https URL.")
+	ErrInvalidEventType     = errors.New("Unknown webhook event type.")
+)
+
+/**
+A partner endpoint notified of the country changes. Events limits the notified
+event types (all when empty) and Filter the countries, like the WebSocket subscriptions.
+The Secret signs the payloads, it is only returned when the subscription is created.
+*/
+type Subscription struct {
+	ID        string               `json:"id"`
+	URL       string               `json:"url"`
+	Events    []events.EventType   `json:"events,omitempty"`
+	Filter    models.CountryFilter `json:"filter"`
+	Secret    string               `json:"secret,omitempty"`
+	CreatedAt time.Time            `json:"createdAt"`
+}
+
+func (s *Subscription) validate() error {
+	target, err := url.Parse(s.URL)
+	if err != nil || !target.IsAbs() || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return ErrInvalidURL
+	}
+	for _, eventType := range s.Events {
+		if eventType != events.Created && eventType != events.Updated && eventType != events.Deleted {
+			return ErrInvalidEventType
+		}
+	}
+	return nil
+}
+
+func (s *Subscription) wants(event events.Event) bool {
+	if !event.Matches(s.Filter) {
+		return false
+	}
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, eventType := range s.Events {
+		if eventType == event.Type {
+			return true
+		}
+	}
+	return false
+}
+
+func newId() string {
+	bytes := make([]byte, 12)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
@@ -0,0 +1,135 @@
+package websocket
+
+import (
+	"bufio"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEchoTextMessage(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(echo))
+	defer httpServer.Close()
+
+	client, err := Dial(httpServer.URL, nil)
+	assert.Nil(t, err)
+	defer client.Close()
+
+	client.WriteMessage(TextMessage, []byte("hello"))
+	messageType, data, readError := client.ReadMessage()
+	assert.Nil(t, readError)
+	assert.Equal(t, TextMessage, messageType)
+	assert.Equal(t, "hello", string(data))
+}
+
+func TestLargeMessageUsesExtendedLength(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(echo))
+	defer httpServer.Close()
+
+	client, _ := Dial(httpServer.URL, nil)
+	defer client.Close()
+
+	large := strings.Repeat("a", 70000)
+	client.MaxMessageSize = 100000
+	client.WriteMessage(BinaryMessage, []byte(large[:300]))
+	_, medium, _ := client.ReadMessage()
+	client.WriteMessage(BinaryMessage, []byte(large[:60000]))
+	_, big, _ := client.ReadMessage()
+	assert.Equal(t, 300, len(medium))
+	assert.Equal(t, 60000, len(big))
+}
+
+func TestMessageTooBigClosesConnection(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(echo))
+	defer httpServer.Close()
+
+	client, _ := Dial(httpServer.URL, nil)
+	defer client.Close()
+
+	client.WriteMessage(BinaryMessage, []byte(strings.Repeat("a", DefaultMaxMessageSize+1)))
+	_, _, err := client.ReadMessage()
+	closeError, ok := err.(*CloseError)
+	assert.True(t, ok)
+	assert.Equal(t, CloseMessageTooBig, closeError.Code)
+}
+
+func TestPingIsAnsweredWithPong(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(echo))
+	defer httpServer.Close()
+
+	client, _ := Dial(httpServer.URL, nil)
+	defer client.Close()
+
+	pongs := make(chan string, 1)
+	client.PongHandler = func(payload []byte) { pongs <- string(payload) }
+	client.WritePing([]byte("are you there"))
+	client.WriteMessage(TextMessage, []byte("after ping"))
+	_, data, _ := client.ReadMessage()
+
+	assert.Equal(t, "are you there", <-pongs)
+	assert.Equal(t, "after ping", string(data))
+}
+
+func TestFragmentedMessageIsReassembled(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	server := newConn(serverConn, bufio.NewReader(serverConn), false)
+	client := newConn(clientConn, bufio.NewReader(clientConn), true)
+	defer server.Close()
+	defer client.Close()
+
+	go func() {
+		client.writeMutex.Lock()
+		// first fragment without FIN, then a continuation frame with FIN
+		client.conn.Write(maskedFrame(0x01, "hel"))
+		client.conn.Write(maskedFrame(0x80, "lo"))
+		client.writeMutex.Unlock()
+	}()
+
+	server.SetReadDeadline(time.Now().Add(time.Second))
+	messageType, data, err := server.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, TextMessage, messageType)
+	assert.Equal(t, "hello", string(data))
+}
+
+func TestUpgradeWithoutHeadersIsRejected(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/", nil)
+	conn, err := Upgrade(recorder, request)
+	assert.Nil(t, conn)
+	assert.Equal(t, ErrHandshake, err)
+	assert.Equal(t, http.StatusUpgradeRequired, recorder.Code)
+}
+
+func TestAcceptKey(t *testing.T) {
+	// example from RFC 6455 section 1.3
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", acceptKey("dGhlIHNhbXBsZSBub25jZQ=="))
+}
+
+func echo(writer http.ResponseWriter, request *http.Request) {
+	conn, err := Upgrade(writer, request)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.WriteMessage(messageType, data)
+	}
+}
+
+func maskedFrame(firstByte byte, payload string) []byte {
+	mask := []byte{1, 2, 3, 4}
+	masked := []byte(payload)
+	maskBytes(mask, masked)
+	frame := []byte{firstByte, 0x80 | byte(len(payload))}
+	frame = append(frame, mask...)
+	return append(frame, masked...)
+}
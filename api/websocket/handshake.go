@@ -0,0 +1,131 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var ErrHandshake = errors.New("WebSocket handshake failed.")
+
+func IsUpgrade(request *http.Request) bool {
+	return headerContains(request.Header, "Connection", "upgrade") && headerContains(request.Header, "Upgrade", "websocket")
+}
+
+/**
+Upgrade an HTTP request to a WebSocket connection. On failure an error response has
+already been written and the error is returned.
+*/
+func Upgrade(writer http.ResponseWriter, request *http.Request) (*Conn, error) {
+	if request.Method != "GET" || !IsUpgrade(request) {
+		http.Error(writer, "websocket upgrade required", http.StatusUpgradeRequired)
+		return nil, ErrHandshake
+	}
+	if request.Header.Get("Sec-WebSocket-Version") != "13" {
+		writer.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(writer, "unsupported websocket version", http.StatusBadRequest)
+		return nil, ErrHandshake
+	}
+	key := request.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(writer, "missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, ErrHandshake
+	}
+
+	hijacker, ok := writer.(http.Hijacker)
+	if !ok {
+		http.Error(writer, "websocket is not supported", http.StatusInternalServerError)
+		return nil, ErrHandshake
+	}
+	conn, buffered, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return newConn(conn, buffered.Reader, false), nil
+}
+
+/**
+Open a client connection to a ws:// (or http://) URL
+*/
+func Dial(rawURL string, header http.Header) (*Conn, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme != "ws" && target.Scheme != "http" {
+		return nil, fmt.Errorf("Unsupported scheme '%s'.", target.Scheme)
+	}
+
+	host := target.Host
+	if target.Port() == "" {
+		host += ":80"
+	}
+	conn, err := net.Dial("tcp", host)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	target.Scheme = "http"
+	request, _ := http.NewRequest("GET", target.String(), nil)
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("Sec-WebSocket-Version", "13")
+	request.Header.Set("Sec-WebSocket-Key", key)
+	if err := request.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols || response.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		conn.Close()
+		return nil, ErrHandshake
+	}
+	return newConn(conn, reader, true), nil
+}
+
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func headerContains(header http.Header, name string, token string) bool {
+	for _, value := range header[http.CanonicalHeaderKey(name)] {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
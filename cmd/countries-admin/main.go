@@ -0,0 +1,249 @@
+/**
+countries-admin checks country datasets offline, without a running server
+
+	countries-admin validate [-format f] <file>
+	countries-admin diff [-format f] [-o text|json] <before> <after>
+	countries-admin convert [-from f] [-to f] <input> <output>
+
+Files are JSON, NDJSON or CSV, see the dataset package, their format is the one of
+their extension unless given. "-" is stdin or stdout.
+validate and diff exit with 1 when the dataset is invalid or the datasets differ,
+every command exits with 2 on any other failure.
+*/
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go-countries-rest-api/api/dataset"
+	"go-countries-rest-api/api/models"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	c := &cli{stdin: stdin, stdout: stdout, stderr: stderr}
+	if len(args) == 0 {
+		c.printUsage()
+		return 2
+	}
+
+	var command func(args []string) (int, error)
+	switch args[0] {
+	case "validate":
+		command = c.validate
+	case "diff":
+		command = c.diff
+	case "convert":
+		command = c.convert
+	case "-h", "-help", "help":
+		c.printUsage()
+		return 0
+	default:
+		fmt.Fprintf(stderr, "countries-admin: unknown command '%s'\n", args[0])
+		c.printUsage()
+		return 2
+	}
+
+	code, err := command(args[1:])
+	if err != nil {
+		fmt.Fprintf(stderr, "countries-admin: %v\n", err)
+	}
+	return code
+}
+
+func (c *cli) printUsage() {
+	fmt.Fprint(c.stderr, `Usage: countries-admin <command> [flags] [arguments]
+
+Commands:
+  validate  Check a dataset against the model rules
+  diff      List the added, removed and changed countries of two datasets
+  convert   Convert a dataset between json, ndjson and csv
+
+Run 'countries-admin <command> -h' for the flags of a command.
+`)
+}
+
+func (c *cli) flagSet(name string, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: countries-admin %s\n", usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+/**
+Parse the flags, returning the exit code when the command must stop
+*/
+func parse(flags *flag.FlagSet, args []string, arguments int) (bool, int) {
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return false, 0
+	} else if err != nil {
+		return false, 2
+	}
+	if flags.NArg() != arguments {
+		flags.Usage()
+		return false, 2
+	}
+	return true, 0
+}
+
+func (c *cli) validate(args []string) (int, error) {
+	flags := c.flagSet("validate", "validate [-format f] <file>")
+	format := flags.String("format", "", "json, ndjson or csv, from the extension of the file by default")
+	if ok, code := parse(flags, args, 1); !ok {
+		return code, nil
+	}
+
+	file := flags.Arg(0)
+	countries, err := c.read(file, *format)
+	if err != nil {
+		return 2, err
+	}
+	violations := dataset.Validate(countries)
+	for _, violation := range violations {
+		fmt.Fprintln(c.stdout, violation.String())
+	}
+	if len(violations) > 0 {
+		return 1, fmt.Errorf("%s: %d violations in %d countries", file, len(violations), len(countries))
+	}
+	fmt.Fprintf(c.stdout, "%s: %d countries, valid\n", file, len(countries))
+	return 0, nil
+}
+
+func (c *cli) diff(args []string) (int, error) {
+	flags := c.flagSet("diff", "diff [-format f] [-o text|json] <before> <after>")
+	format := flags.String("format", "", "json, ndjson or csv of both files, from their extensions by default")
+	output := flags.String("o", "text", "output format: text or json")
+	if ok, code := parse(flags, args, 2); !ok {
+		return code, nil
+	}
+	if *output != "text" && *output != "json" {
+		return 2, fmt.Errorf("unknown output '%s', expected text or json", *output)
+	}
+
+	before, err := c.read(flags.Arg(0), *format)
+	if err != nil {
+		return 2, err
+	}
+	after, err := c.read(flags.Arg(1), *format)
+	if err != nil {
+		return 2, err
+	}
+
+	diff := dataset.Compare(before, after)
+	if *output == "json" {
+		jsonBytes, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return 2, err
+		}
+		fmt.Fprintln(c.stdout, string(jsonBytes))
+	} else {
+		printDiff(c.stdout, diff)
+	}
+	if diff.Empty() {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+func (c *cli) convert(args []string) (int, error) {
+	flags := c.flagSet("convert", "convert [-from f] [-to f] <input> <output>")
+	from := flags.String("from", "", "json, ndjson or csv, from the extension of the input by default")
+	to := flags.String("to", "", "json, ndjson or csv, from the extension of the output by default")
+	if ok, code := parse(flags, args, 2); !ok {
+		return code, nil
+	}
+
+	countries, err := c.read(flags.Arg(0), *from)
+	if err != nil {
+		return 2, err
+	}
+	toFormat, err := formatOf(flags.Arg(1), *to)
+	if err != nil {
+		return 2, err
+	}
+
+	var converted bytes.Buffer
+	if err := dataset.Write(&converted, toFormat, countries); err != nil {
+		return 2, err
+	}
+	if flags.Arg(1) == "-" {
+		_, err = c.stdout.Write(converted.Bytes())
+	} else {
+		err = ioutil.WriteFile(flags.Arg(1), converted.Bytes(), 0644)
+	}
+	if err != nil {
+		return 2, err
+	}
+	return 0, nil
+}
+
+/**
+Read a dataset in the given format, or the one of the extension of the file
+*/
+func (c *cli) read(file string, format string) ([]models.Country, error) {
+	fileFormat, err := formatOf(file, format)
+	if err != nil {
+		return nil, err
+	}
+	var fileBytes []byte
+	if file == "-" {
+		fileBytes, err = ioutil.ReadAll(c.stdin)
+	} else {
+		fileBytes, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	countries, err := dataset.Read(bytes.NewReader(fileBytes), fileFormat)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return countries, nil
+}
+
+func formatOf(file string, format string) (dataset.Format, error) {
+	if format == "" {
+		return dataset.FormatOf(file), nil
+	}
+	return dataset.ParseFormat(format)
+}
+
+/**
+Print one line per country, "+ spain" when added, "- japan" when removed and "~ greece"
+when changed followed by its changed fields, then the number of each
+*/
+func printDiff(writer io.Writer, diff dataset.Diff) {
+	for _, country := range diff.Added {
+		fmt.Fprintf(writer, "+ %s\n", strings.ToLower(country.Name))
+	}
+	for _, country := range diff.Removed {
+		fmt.Fprintf(writer, "- %s\n", strings.ToLower(country.Name))
+	}
+	for _, changed := range diff.Changed {
+		fmt.Fprintf(writer, "~ %s\n", changed.Id)
+		for _, change := range changed.Changes {
+			before, _ := json.Marshal(change.Before)
+			after, _ := json.Marshal(change.After)
+			fmt.Fprintf(writer, "    %s: %s -> %s\n", change.Field, before, after)
+		}
+	}
+	fmt.Fprintf(writer, "%d added, %d removed, %d changed\n", len(diff.Added), len(diff.Removed), len(diff.Changed))
+}
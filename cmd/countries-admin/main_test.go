@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const countriesJson = `[
+  {"name": "Greece", "alpha2Code": "GR", "capital": "Athens", "region": "Europe", "currencies": [{"code": "EUR", "name": "Euro", "symbol": "€"}]},
+  {"name": "Japan", "alpha2Code": "JP", "capital": "Tokyo", "region": "Asia", "currencies": [{"code": "JPY", "name": "Japanese yen", "symbol": "¥"}]}
+]`
+
+func runAdmin(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	ioutil.WriteFile(path, []byte(content), 0644)
+	return path
+}
+
+func TestValidate(t *testing.T) {
+	code, stdout, _ := runAdmin(countriesJson, "validate", "-")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "-: 2 countries, valid\n", stdout)
+
+	file := writeFile(t, "countries.csv", "name,alpha2Code,currencies\nGreece,GR,EUR\nSpain,es,euro\n")
+	code, stdout, stderr := runAdmin("", "validate", file)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "/1/alpha2Code: must match the pattern ^[A-Z]{2}$\n/1/currencies/0/code: must match the pattern ^[A-Z]{3}$\n", stdout)
+	assert.Equal(t, "countries-admin: "+file+": 2 violations in 2 countries\n", stderr)
+
+	code, _, stderr = runAdmin("{\"name\": \"Greece\"}\n{\"name\": 1}\n", "validate", "-format", "ndjson", "-")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "countries-admin: -: line 2: ")
+}
+
+func TestDiff(t *testing.T) {
+	before := writeFile(t, "before.json", countriesJson)
+	after := writeFile(t, "after.ndjson", `{"name": "Greece", "alpha2Code": "GR", "capital": "Nafplio", "region": "Europe", "currencies": [{"code": "EUR", "name": "Euro", "symbol": "€"}]}
+{"name": "Spain", "alpha2Code": "ES", "capital": "Madrid", "region": "Europe"}
+`)
+
+	code, stdout, _ := runAdmin("", "diff", before, after)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "+ spain\n- japan\n~ greece\n    capital: \"Athens\" -> \"Nafplio\"\n1 added, 1 removed, 1 changed\n", stdout)
+
+	code, stdout, _ = runAdmin("", "diff", "-o", "json", before, after)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout, `"changes": [
+        {
+          "field": "capital",
+          "before": "Athens",
+          "after": "Nafplio"
+        }
+      ]`)
+
+	code, stdout, _ = runAdmin("", "diff", before, before)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "0 added, 0 removed, 0 changed\n", stdout)
+}
+
+func TestConvert(t *testing.T) {
+	input := writeFile(t, "countries.json", countriesJson)
+	output := filepath.Join(filepath.Dir(input), "countries.csv")
+
+	code, _, _ := runAdmin("", "convert", input, output)
+	assert.Equal(t, 0, code)
+	csvBytes, _ := ioutil.ReadFile(output)
+	assert.Equal(t, "name,alpha2Code,capital,region,currencies\nGreece,GR,Athens,Europe,EUR:Euro:€\nJapan,JP,Tokyo,Asia,JPY:Japanese yen:¥\n", string(csvBytes))
+
+	code, stdout, _ := runAdmin(string(csvBytes), "convert", "-from", "csv", "-to", "ndjson", "-", "-")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, `{"name":"Greece","alpha2Code":"GR","capital":"Athens","region":"Europe","currencies":[{"code":"EUR","name":"Euro","symbol":"€"}]}
+{"name":"Japan","alpha2Code":"JP","capital":"Tokyo","region":"Asia","currencies":[{"code":"JPY","name":"Japanese yen","symbol":"¥"}]}
+`, stdout)
+
+	code, _, stderr := runAdmin("", "convert", "-to", "xml", input, "-")
+	assert.Equal(t, 2, code)
+	assert.Equal(t, "countries-admin: unknown format 'xml', expected json, ndjson or csv\n", stderr)
+}
+
+func TestUsage(t *testing.T) {
+	code, _, stderr := runAdmin("", "merge")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "countries-admin: unknown command 'merge'\nUsage: countries-admin <command>")
+
+	code, _, stderr = runAdmin("", "diff", "before.json")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "Usage: countries-admin diff [-format f] [-o text|json] <before> <after>")
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go-countries-rest-api/api/client"
+	"go-countries-rest-api/api/dataset"
+	"go-countries-rest-api/api/models"
+	"go-countries-rest-api/api/restcountries"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+func listFlags(f *flag.FlagSet) func(c *cli, args []string) error {
+	options := client.ListOptions{}
+	f.StringVar(&options.Filter.Name, "name", "", "only the country with this name")
+	f.StringVar(&options.Filter.Alpha2Code, "alpha2Code", "", "only the country with this ISO 3166-1 alpha-2 code")
+	f.StringVar(&options.Filter.Capital, "capital", "", "only the countries with this capital")
+	f.StringVar(&options.Filter.Region, "region", "", "only the countries of this region, e.g. Europe")
+	f.StringVar(&options.Filter.Currency, "currency", "", "only the countries using this currency code, e.g. EUR")
+	f.IntVar(&options.Limit, "limit", 0, "maximum number of countries, all of them when 0")
+	f.IntVar(&options.Offset, "offset", 0, "number of countries skipped")
+
+	return func(c *cli, args []string) error {
+		if len(args) > 0 {
+			return usageError("list takes no arguments")
+		}
+		page, err := c.client.ListCountries(context.Background(), options)
+		if err != nil {
+			return err
+		}
+		return c.printCountries(page.Countries)
+	}
+}
+
+func getFlags(f *flag.FlagSet) func(c *cli, args []string) error {
+	return func(c *cli, args []string) error {
+		if len(args) != 1 {
+			return usageError("get takes the id of a country")
+		}
+		country, err := c.client.GetCountryById(context.Background(), args[0])
+		if err != nil {
+			return err
+		}
+		return c.printCountry(*country)
+	}
+}
+
+func addFlags(f *flag.FlagSet) func(c *cli, args []string) error {
+	country := models.Country{}
+	f.StringVar(&country.Name, "name", "", "common name of the country")
+	f.StringVar(&country.Alpha2Code, "alpha2Code", "", "ISO 3166-1 alpha-2 code")
+	f.StringVar(&country.Capital, "capital", "", "capital of the country")
+	f.StringVar(&country.Region, "region", "", "region of the country, e.g. Europe")
+	currencies := f.String("currencies", "", "currencies as code:name:symbol separated by ';', e.g. 'EUR:Euro:€'")
+	file := f.String("file", "", "JSON file of the country, '-' for stdin, instead of the other flags")
+
+	return func(c *cli, args []string) error {
+		if len(args) > 0 {
+			return usageError("add takes no arguments")
+		}
+		if *file != "" {
+			if country.Name != "" || country.Alpha2Code != "" || country.Capital != "" || country.Region != "" || *currencies != "" {
+				return usageError("add takes either -file or the fields of the country")
+			}
+			countryBytes, err := c.readFile(*file)
+			if err != nil {
+				return err
+			}
+			if err := json.Unmarshal(countryBytes, &country); err != nil {
+				return fmt.Errorf("%s: %v", *file, err)
+			}
+		} else {
+			if country.Name == "" {
+				return usageError("add needs -name or -file")
+			}
+			country.Currencies = dataset.ParseCurrencies(*currencies)
+		}
+
+		added, err := c.client.AddCountry(context.Background(), country)
+		if err != nil {
+			return err
+		}
+		return c.printCountry(*added)
+	}
+}
+
+func deleteFlags(f *flag.FlagSet) func(c *cli, args []string) error {
+	return func(c *cli, args []string) error {
+		if len(args) == 0 {
+			return usageError("delete takes the ids of the countries")
+		}
+		for _, id := range args {
+			if err := c.client.DeleteCountry(context.Background(), id); err != nil {
+				return fmt.Errorf("%s: %v", id, err)
+			}
+			fmt.Fprintf(c.stderr, "deleted %s\n", id)
+		}
+		return nil
+	}
+}
+
+func randomFlags(f *flag.FlagSet) func(c *cli, args []string) error {
+	return func(c *cli, args []string) error {
+		if len(args) > 0 {
+			return usageError("random takes no arguments")
+		}
+		id, err := c.client.GetRandomCountryId(context.Background())
+		if err != nil {
+			return err
+		}
+		country, err := c.client.GetCountryById(context.Background(), *id)
+		if err != nil {
+			return err
+		}
+		return c.printCountry(*country)
+	}
+}
+
+func importFlags(f *flag.FlagSet) func(c *cli, args []string) error {
+	format := f.String("format", "", "json, ndjson or csv, from the extension of the file by default")
+
+	return func(c *cli, args []string) error {
+		if len(args) != 1 {
+			return usageError("import takes a file")
+		}
+		fileFormat, err := formatFlag(*format, args[0])
+		if err != nil {
+			return err
+		}
+		fileBytes, err := c.readFile(args[0])
+		if err != nil {
+			return err
+		}
+		countries, err := dataset.Read(bytes.NewReader(fileBytes), fileFormat)
+		if err != nil {
+			return fmt.Errorf("%s: %v", args[0], err)
+		}
+
+		for i, country := range countries {
+			if _, err := c.client.AddCountry(context.Background(), country); err != nil {
+				fmt.Fprintf(c.stderr, "imported %d of %d countries\n", i, len(countries))
+				return fmt.Errorf("%s: %v", country.Name, err)
+			}
+		}
+		fmt.Fprintf(c.stderr, "imported %d countries\n", len(countries))
+		return nil
+	}
+}
+
+func exportFlags(f *flag.FlagSet) func(c *cli, args []string) error {
+	format := f.String("format", "", "json, ndjson or csv, from the extension of the file by default")
+	file := f.String("file", "-", "file written, '-' for stdout")
+
+	return func(c *cli, args []string) error {
+		if len(args) > 0 {
+			return usageError("export takes no arguments")
+		}
+		fileFormat, err := formatFlag(*format, *file)
+		if err != nil {
+			return err
+		}
+		countries, err := c.client.GetAllCountries(context.Background())
+		if err != nil {
+			return err
+		}
+
+		var writer io.Writer = c.stdout
+		if *file != "-" {
+			output, err := os.Create(*file)
+			if err != nil {
+				return err
+			}
+			defer output.Close()
+			writer = output
+		}
+		if err := dataset.Write(writer, fileFormat, *countries); err != nil {
+			return err
+		}
+		if *file != "-" {
+			fmt.Fprintf(c.stderr, "exported %d countries to %s\n", len(*countries), *file)
+		}
+		return nil
+	}
+}
+
+/**
+Map a dump of https://restcountries.com into countries, reporting the fields that have
+no place in a country, then add them unless -dry-run is given
+*/
+func importRestCountriesFlags(f *flag.FlagSet) func(c *cli, args []string) error {
+	dryRun := f.Bool("dry-run", false, "print the mapped countries instead of adding them")
+
+	return func(c *cli, args []string) error {
+		if len(args) != 1 {
+			return usageError("import-restcountries takes a file")
+		}
+		fileBytes, err := c.readFile(args[0])
+		if err != nil {
+			return err
+		}
+		result, err := restcountries.Decode(fileBytes)
+		if err != nil {
+			return fmt.Errorf("%s: %v", args[0], err)
+		}
+
+		fmt.Fprintf(c.stderr, "read %d countries of REST Countries v%d\n", len(result.Countries), result.Version)
+		for _, field := range result.UnmappedFields() {
+			fmt.Fprintf(c.stderr, "unmapped field %s in %d countries\n", field, result.Unmapped[field])
+		}
+		if *dryRun {
+			return c.printCountries(result.Countries)
+		}
+
+		loaded, err := restcountries.Load(context.Background(), c.client, result.Countries)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "imported %d of %d countries\n", loaded, len(result.Countries))
+			return err
+		}
+		fmt.Fprintf(c.stderr, "imported %d countries\n", loaded)
+		return nil
+	}
+}
+
+/**
+The format of the -format flag, or else the one of the extension of the file
+*/
+func formatFlag(format string, file string) (dataset.Format, error) {
+	if format == "" {
+		return dataset.FormatOf(file), nil
+	}
+	return dataset.ParseFormat(format)
+}
+
+func (c *cli) readFile(path string) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(c.stdin)
+	}
+	return ioutil.ReadFile(path)
+}
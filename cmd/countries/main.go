@@ -0,0 +1,181 @@
+/**
+countries is the operator CLI of the countries API
+
+	countries [common flags] <command> [flags] [arguments]
+
+Commands are list, get, add, delete, random, import, export and import-restcountries, run
+"countries <command> -h" for their flags. The base URL and the API key are read from
+the -url and -api-key flags, or the COUNTRIES_URL and COUNTRIES_API_KEY environment
+variables. The common flags (-url, -api-key, -timeout, -retries and -o) go before or
+after the command.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go-countries-rest-api/api/client"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultURL = "http://localhost:8080"
+
+/**
+A subcommand, run with the client configured from the common flags and the
+arguments left after its own flags
+*/
+type command struct {
+	usage       string
+	description string
+	flags       func(f *flag.FlagSet) func(c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"list":                 {"list [flags]", "List the countries", listFlags},
+	"get":                  {"get [flags] <id>", "Show a country", getFlags},
+	"add":                  {"add [flags]", "Add or replace a country, from flags or a JSON file", addFlags},
+	"delete":               {"delete [flags] <id>...", "Delete countries", deleteFlags},
+	"random":               {"random [flags]", "Show a random country", randomFlags},
+	"import":               {"import [flags] <file>", "Add every country of a JSON, NDJSON or CSV file, \"-\" for stdin", importFlags},
+	"export":               {"export [flags]", "Write every country to a JSON, NDJSON or CSV file", exportFlags},
+	"import-restcountries": {"import-restcountries [flags] <file>", "Add every country of a REST Countries v2 or v3 JSON file, \"-\" for stdin", importRestCountriesFlags},
+}
+
+/**
+The flags of every command, also accepted before the command
+*/
+type commonFlags struct {
+	baseURL string
+	apiKey  string
+	timeout time.Duration
+	retries int
+	output  string
+}
+
+/**
+Define the common flags on a flag set, their defaults are the current values
+*/
+func (o *commonFlags) define(f *flag.FlagSet) {
+	f.StringVar(&o.baseURL, "url", o.baseURL, "base URL of the API, or COUNTRIES_URL")
+	f.StringVar(&o.apiKey, "api-key", o.apiKey, "API key sent in X-API-Key, or COUNTRIES_API_KEY")
+	f.DurationVar(&o.timeout, "timeout", o.timeout, "timeout of every request")
+	f.IntVar(&o.retries, "retries", o.retries, "retries of the requests failing with a transient error, 0 disables them")
+	f.StringVar(&o.output, "o", o.output, "output format: table, json or csv")
+}
+
+/**
+The state of a run: the client, the output format and the streams
+*/
+type cli struct {
+	client *client.Client
+	output string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Getenv, os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, getenv func(string) string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	common := &commonFlags{
+		baseURL: valueOr(getenv("COUNTRIES_URL"), defaultURL),
+		apiKey:  getenv("COUNTRIES_API_KEY"),
+		timeout: client.DefaultTimeout,
+		retries: client.DefaultMaxRetries,
+		output:  "table",
+	}
+	global := flag.NewFlagSet("countries", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.Usage = func() { printUsage(stderr) }
+	common.define(global)
+	if err := global.Parse(args); err != nil {
+		return 2
+	}
+	args = global.Args()
+
+	if len(args) == 0 || args[0] == "help" {
+		printUsage(stderr)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "countries: unknown command '%s'\n", args[0])
+		printUsage(stderr)
+		return 2
+	}
+
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: countries %s\n\n%s\n\n", cmd.usage, cmd.description)
+		flags.PrintDefaults()
+	}
+	common.define(flags)
+	runCommand := cmd.flags(flags)
+	if err := flags.Parse(args[1:]); err == flag.ErrHelp {
+		return 0
+	} else if err != nil {
+		return 2
+	}
+	if common.output != "table" && common.output != "json" && common.output != "csv" {
+		fmt.Fprintf(stderr, "countries: unknown output '%s', expected table, json or csv\n", common.output)
+		return 2
+	}
+	retries := common.retries
+	if retries == 0 {
+		retries = -1
+	}
+
+	c := &cli{
+		client: client.New(client.Config{BaseURL: common.baseURL, APIKey: common.apiKey, Timeout: common.timeout, MaxRetries: retries}),
+		output: common.output,
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+	if err := runCommand(c, flags.Args()); err != nil {
+		fmt.Fprintf(stderr, "countries: %v\n", err)
+		if _, ok := err.(usageError); ok {
+			flags.Usage()
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+func printUsage(writer io.Writer) {
+	fmt.Fprintln(writer, "Usage: countries [common flags] <command> [flags] [arguments]\n\nCommands:")
+	names := []string{}
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(writer, "  %-20s %s\n", name, commands[name].description)
+	}
+	fmt.Fprintln(writer, "\nThe common flags -url, -api-key, -timeout, -retries and -o go before or after the command.")
+	fmt.Fprintln(writer, "Run 'countries <command> -h' for the flags of a command.")
+}
+
+func valueOr(value string, fallback string) string {
+	if strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	return value
+}
+
+/**
+An error of the command line itself, exiting with 2 instead of 1
+*/
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"go-countries-rest-api/api/auth"
+	"go-countries-rest-api/api/server"
+	"go-countries-rest-api/api/store"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func startServer(t *testing.T) *httptest.Server {
+	s := &server.Server{
+		Mux:     http.NewServeMux(),
+		Actions: store.NewCountriesStorage(),
+		Auth: &auth.Authenticator{APIKeys: map[string]auth.Principal{
+			"editor-key": {Subject: "operator", Role: auth.RoleEditor},
+		}},
+	}
+	testServer := httptest.NewServer(s.Handler())
+	t.Cleanup(testServer.Close)
+	return testServer
+}
+
+/**
+Run the command line against the server, with its URL and key in the environment
+*/
+func runCli(testServer *httptest.Server, stdin string, args ...string) (int, string, string) {
+	env := map[string]string{"COUNTRIES_URL": testServer.URL, "COUNTRIES_API_KEY": "editor-key"}
+	var stdout, stderr bytes.Buffer
+	code := run(args, func(name string) string { return env[name] }, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestAddGetAndDelete(t *testing.T) {
+	testServer := startServer(t)
+
+	code, stdout, _ := runCli(testServer, "", "add", "-name", "Greece", "-alpha2Code", "GR", "-capital", "Athens", "-region", "Europe", "-currencies", "EUR:Euro:€")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "NAME    ALPHA2  CAPITAL  REGION  CURRENCIES\nGreece  GR      Athens   Europe  EUR\n", stdout)
+
+	code, stdout, _ = runCli(testServer, `{"name": "Japan", "alpha2Code": "JP", "currencies": [{"code": "JPY"}]}`, "add", "-file", "-")
+	assert.Equal(t, 0, code)
+
+	code, stdout, _ = runCli(testServer, "", "get", "-o", "json", "greece")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, `{
+  "name": "Greece",
+  "alpha2Code": "GR",
+  "capital": "Athens",
+  "region": "Europe",
+  "currencies": [
+    {
+      "code": "EUR",
+      "name": "Euro",
+      "symbol": "€"
+    }
+  ]
+}
+`, stdout)
+
+	code, stdout, _ = runCli(testServer, "", "list", "-o", "csv", "-currency", "JPY")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "name,alpha2Code,capital,region,currencies\nJapan,JP,,,JPY\n", stdout)
+
+	code, _, stderr := runCli(testServer, "", "delete", "greece", "japan")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "deleted greece\ndeleted japan\n", stderr)
+
+	code, _, stderr = runCli(testServer, "", "get", "greece")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "countries: Country not found.\n", stderr)
+
+	code, _, stderr = runCli(testServer, "", "random")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "countries: No countries available to choose randomly.\n", stderr)
+}
+
+func TestAddWithoutCurrencies(t *testing.T) {
+	testServer := startServer(t)
+
+	code, stdout, stderr := runCli(testServer, "", "add", "-name", "Greece", "-alpha2Code", "GR")
+	assert.Equal(t, 0, code, stderr)
+	assert.Equal(t, "NAME    ALPHA2  CAPITAL  REGION  CURRENCIES\nGreece  GR                       \n", stdout)
+}
+
+func TestImportAndExport(t *testing.T) {
+	testServer := startServer(t)
+	dir := t.TempDir()
+	csvFile := filepath.Join(dir, "countries.csv")
+	ioutil.WriteFile(csvFile, []byte("name,alpha2Code,currencies\nSpain,ES,EUR\nGreece,GR,EUR:Euro\n"), 0644)
+
+	code, _, stderr := runCli(testServer, "", "import", csvFile)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "imported 2 countries\n", stderr)
+
+	code, stdout, _ := runCli(testServer, "", "export", "-format", "ndjson")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, `{"name":"Greece","alpha2Code":"GR","capital":"","region":"","currencies":[{"code":"EUR","name":"Euro","symbol":""}]}
+{"name":"Spain","alpha2Code":"ES","capital":"","region":"","currencies":[{"code":"EUR","name":"","symbol":""}]}
+`, stdout)
+
+	jsonFile := filepath.Join(dir, "countries.json")
+	code, _, stderr = runCli(testServer, "", "export", "-file", jsonFile)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "exported 2 countries to "+jsonFile+"\n", stderr)
+
+	code, stdout, _ = runCli(testServer, "", "random", "-o", "csv")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "name,alpha2Code,capital,region,currencies\n")
+
+	code, _, stderr = runCli(testServer, "[{\"name\": \"France\", \"alpha2Code\": \"FR\"}, {\"name\": \"Spain\", \"alpha2Code\": \"es\"}]", "import", "-format", "json", "-")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "imported 1 of 2 countries\ncountries: Spain: PUT ")
+	assert.Contains(t, stderr, ": 400 Bad Request: ")
+}
+
+func TestUsageErrors(t *testing.T) {
+	testServer := startServer(t)
+
+	code, _, stderr := runCli(testServer, "")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "Usage: countries [common flags] <command> [flags] [arguments]")
+
+	code, _, stderr = runCli(testServer, "", "rename")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "countries: unknown command 'rename'\n")
+
+	code, _, stderr = runCli(testServer, "", "get")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "countries: get takes the id of a country\nUsage: countries get [flags] <id>")
+
+	code, _, stderr = runCli(testServer, "", "list", "-o", "xml")
+	assert.Equal(t, 2, code)
+	assert.Equal(t, "countries: unknown output 'xml', expected table, json or csv\n", stderr)
+
+	code, _, _ = runCli(testServer, "", "list", "-h")
+	assert.Equal(t, 0, code)
+}
+
+func TestCredentialsFromFlags(t *testing.T) {
+	testServer := startServer(t)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"list", "-url", testServer.URL}, func(string) string { return "" }, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "401 Unauthorized: Missing credentials.")
+
+	stderr.Reset()
+	code = run([]string{"list", "-url", testServer.URL, "-api-key", "editor-key"}, func(string) string { return "" }, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "NAME  ALPHA2  CAPITAL  REGION  CURRENCIES\n", stdout.String())
+
+	stdout.Reset()
+	code = run([]string{"-url", testServer.URL, "-api-key", "editor-key", "list", "-o", "csv"}, func(string) string { return "" }, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "name,alpha2Code,capital,region,currencies\n", stdout.String())
+}
+
+func TestImportRestCountries(t *testing.T) {
+	testServer := startServer(t)
+	v3 := `[
+  {"name": {"common": "Greece", "official": "Hellenic Republic"}, "cca2": "GR", "capital": ["Athens"], "region": "Europe", "currencies": {"EUR": {"name": "Euro", "symbol": "€"}}, "population": 10715549},
+  {"name": {"common": "Japan", "official": "Japan"}, "cca2": "JP", "capital": ["Tokyo"], "region": "Asia", "currencies": {"JPY": {"name": "Japanese yen", "symbol": "¥"}}}
+]`
+
+	code, stdout, stderr := runCli(testServer, v3, "import-restcountries", "-dry-run", "-o", "csv", "-")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "read 2 countries of REST Countries v3\nunmapped field name.official in 2 countries\nunmapped field population in 1 countries\n", stderr)
+	assert.Equal(t, "name,alpha2Code,capital,region,currencies\nGreece,GR,Athens,Europe,EUR:Euro:€\nJapan,JP,Tokyo,Asia,JPY:Japanese yen:¥\n", stdout)
+
+	code, stdout, _ = runCli(testServer, "", "list")
+	assert.Equal(t, "NAME  ALPHA2  CAPITAL  REGION  CURRENCIES\n", stdout)
+
+	code, _, stderr = runCli(testServer, v3, "import-restcountries", "-")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stderr, "imported 2 countries\n")
+	code, stdout, _ = runCli(testServer, "", "get", "-o", "csv", "japan")
+	assert.Equal(t, "name,alpha2Code,capital,region,currencies\nJapan,JP,Tokyo,Asia,JPY:Japanese yen:¥\n", stdout)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-countries-rest-api/api/dataset"
+	"go-countries-rest-api/api/models"
+	"strings"
+	"text/tabwriter"
+)
+
+/**
+Print a country in the output format of the run, a JSON object for -o json
+*/
+func (c *cli) printCountry(country models.Country) error {
+	if c.output == "json" {
+		return c.printJson(country)
+	}
+	return c.printCountries([]models.Country{country})
+}
+
+func (c *cli) printCountries(countries []models.Country) error {
+	switch c.output {
+	case "json":
+		return c.printJson(countries)
+	case "csv":
+		return dataset.Write(c.stdout, dataset.CSV, countries)
+	default:
+		return c.printTable(countries)
+	}
+}
+
+func (c *cli) printJson(value interface{}) error {
+	jsonBytes, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(c.stdout, string(jsonBytes))
+	return err
+}
+
+func (c *cli) printTable(countries []models.Country) error {
+	table := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "NAME\tALPHA2\tCAPITAL\tREGION\tCURRENCIES")
+	for _, country := range countries {
+		codes := []string{}
+		for _, currency := range country.Currencies {
+			codes = append(codes, currency.Code)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n", country.Name, country.Alpha2Code, country.Capital, country.Region, strings.Join(codes, ","))
+	}
+	return table.Flush()
+}
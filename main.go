@@ -6,5 +6,9 @@ import (
 
 func main() {
 	app := api.App{Port: ":8080"}
+	err := app.LoadEnv()
+	if err != nil {
+		panic(err)
+	}
 	app.Run()
 }